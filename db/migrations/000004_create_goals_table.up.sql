@@ -0,0 +1,8 @@
+CREATE TABLE "goals" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "volume" integer NOT NULL,
+  "effective_date" date NOT NULL,
+  "created_at" timestamp DEFAULT current_timestamp,
+  UNIQUE ("user_id", "effective_date")
+)
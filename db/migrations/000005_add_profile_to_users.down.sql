@@ -0,0 +1,5 @@
+ALTER TABLE "users"
+  DROP COLUMN IF EXISTS "weight_kg",
+  DROP COLUMN IF EXISTS "age",
+  DROP COLUMN IF EXISTS "sex",
+  DROP COLUMN IF EXISTS "activity_level";
@@ -0,0 +1,5 @@
+ALTER TABLE "users"
+  ADD COLUMN "weight_kg" numeric(5, 1),
+  ADD COLUMN "age" smallint,
+  ADD COLUMN "sex" varchar,
+  ADD COLUMN "activity_level" varchar;
@@ -0,0 +1,21 @@
+CREATE TABLE "beverages" (
+  "id" bigserial PRIMARY KEY,
+  -- user_idがNULLのものは全ユーザー共通の飲み物
+  "user_id" bigint REFERENCES users(id) ON DELETE CASCADE,
+  "code" varchar UNIQUE,
+  "name" varchar NOT NULL,
+  "hydration_factor" numeric(3, 2) NOT NULL DEFAULT 1.00,
+  "created_at" timestamp DEFAULT current_timestamp
+);
+
+-- アルコールは利尿作用があるため、飲んだ量よりも水分を失うものとして負の係数にする
+INSERT INTO "beverages" ("code", "name", "hydration_factor") VALUES
+  ('water', '水', 1.00),
+  ('tea', 'お茶', 0.90),
+  ('coffee', 'コーヒー', 0.80),
+  ('milk', '牛乳', 1.10),
+  ('juice', 'ジュース', 0.85),
+  ('sports_drink', 'スポーツドリンク', 1.00),
+  ('alcohol', 'アルコール', -0.40);
+
+ALTER TABLE "waters" ADD COLUMN "beverage_id" bigint REFERENCES beverages(id) ON DELETE SET NULL;
@@ -0,0 +1,9 @@
+ALTER TABLE "users" DROP COLUMN IF EXISTS "caffeine_limit_mg";
+
+ALTER TABLE "waters"
+  DROP COLUMN IF EXISTS "caffeine_mg",
+  DROP COLUMN IF EXISTS "alcohol_g";
+
+ALTER TABLE "beverages"
+  DROP COLUMN IF EXISTS "caffeine_mg_per_100ml",
+  DROP COLUMN IF EXISTS "alcohol_g_per_100ml";
@@ -0,0 +1,15 @@
+ALTER TABLE "beverages"
+  ADD COLUMN "caffeine_mg_per_100ml" numeric(6, 1) NOT NULL DEFAULT 0,
+  ADD COLUMN "alcohol_g_per_100ml" numeric(5, 1) NOT NULL DEFAULT 0;
+
+UPDATE "beverages" SET "caffeine_mg_per_100ml" = 20 WHERE "code" = 'tea';
+UPDATE "beverages" SET "caffeine_mg_per_100ml" = 60 WHERE "code" = 'coffee';
+-- ビール(アルコール度数5%)相当
+UPDATE "beverages" SET "alcohol_g_per_100ml" = 4 WHERE "code" = 'alcohol';
+
+-- 記録ごとに値が分かる場合は飲み物の既定値より優先する
+ALTER TABLE "waters"
+  ADD COLUMN "caffeine_mg" numeric(6, 1),
+  ADD COLUMN "alcohol_g" numeric(5, 1);
+
+ALTER TABLE "users" ADD COLUMN "caffeine_limit_mg" integer NOT NULL DEFAULT 400;
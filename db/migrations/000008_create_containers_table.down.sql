@@ -0,0 +1,2 @@
+ALTER TABLE "waters" DROP COLUMN IF EXISTS "container_id";
+DROP TABLE IF EXISTS containers;
@@ -0,0 +1,11 @@
+CREATE TABLE "containers" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "name" varchar NOT NULL,
+  "volume" integer NOT NULL,
+  "beverage_id" bigint REFERENCES beverages(id) ON DELETE SET NULL,
+  "usage_count" integer NOT NULL DEFAULT 0,
+  "created_at" timestamp DEFAULT current_timestamp
+);
+
+ALTER TABLE "waters" ADD COLUMN "container_id" bigint REFERENCES containers(id) ON DELETE SET NULL;
@@ -0,0 +1,7 @@
+CREATE TABLE "user_achievements" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "badge_key" varchar NOT NULL,
+  "earned_at" timestamp NOT NULL DEFAULT current_timestamp,
+  UNIQUE ("user_id", "badge_key")
+)
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS alerts;
+DROP TABLE IF EXISTS alert_settings;
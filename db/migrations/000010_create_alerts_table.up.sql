@@ -0,0 +1,22 @@
+CREATE TABLE "alert_settings" (
+  "user_id" bigint PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  "enabled" boolean NOT NULL DEFAULT true,
+  "max_hours_without_drink" integer NOT NULL DEFAULT 4,
+  "daily_minimum" integer NOT NULL DEFAULT 1000,
+  "daily_minimum_deadline_hour" smallint NOT NULL DEFAULT 18,
+  "active_start_hour" smallint NOT NULL DEFAULT 8,
+  "active_end_hour" smallint NOT NULL DEFAULT 21
+);
+
+-- created_atはUTCで保存する。drank_atと比較するときはdrank_atをUTCに変換する
+CREATE TABLE "alerts" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "kind" varchar NOT NULL,
+  "message" varchar NOT NULL,
+  "alert_date" date NOT NULL,
+  "created_at" timestamp NOT NULL,
+  "acknowledged_at" timestamp
+);
+
+CREATE INDEX "alerts_user_id_kind_idx" ON "alerts" ("user_id", "kind", "created_at");
@@ -0,0 +1,17 @@
+CREATE TABLE "reminders" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "kind" varchar NOT NULL,
+  "interval_minutes" integer NOT NULL DEFAULT 0,
+  -- "09:00,12:30" のようにカンマ区切りで保存する
+  "times" varchar NOT NULL DEFAULT '',
+  -- 曜日(0が日曜日)のカンマ区切り。空の場合は毎日
+  "days" varchar NOT NULL DEFAULT '',
+  "quiet_start" varchar NOT NULL DEFAULT '',
+  "quiet_end" varchar NOT NULL DEFAULT '',
+  "skip_if_drank_within_minutes" integer NOT NULL DEFAULT 0,
+  "enabled" boolean NOT NULL DEFAULT true,
+  -- 最後に通知した(または直前に飲んでいたため見送った)日時。UTCで保存する
+  "last_triggered_at" timestamp,
+  "created_at" timestamp DEFAULT current_timestamp
+)
@@ -0,0 +1,8 @@
+CREATE TABLE "push_subscriptions" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "endpoint" varchar NOT NULL UNIQUE,
+  "p256dh" varchar NOT NULL,
+  "auth" varchar NOT NULL,
+  "created_at" timestamp DEFAULT current_timestamp
+)
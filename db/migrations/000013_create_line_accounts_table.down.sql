@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS line_link_codes;
+DROP TABLE IF EXISTS line_accounts;
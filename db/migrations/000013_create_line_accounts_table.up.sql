@@ -0,0 +1,13 @@
+CREATE TABLE "line_accounts" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+  "line_user_id" varchar NOT NULL UNIQUE,
+  "created_at" timestamp DEFAULT current_timestamp
+);
+
+CREATE TABLE "line_link_codes" (
+  "code" varchar PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  -- UTCで保存する
+  "expires_at" timestamp NOT NULL
+)
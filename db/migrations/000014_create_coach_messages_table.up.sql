@@ -0,0 +1,10 @@
+CREATE TABLE "coach_messages" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  -- user または assistant
+  "role" varchar NOT NULL,
+  "content" text NOT NULL,
+  "created_at" timestamp DEFAULT current_timestamp
+);
+
+CREATE INDEX "coach_messages_user_id_idx" ON "coach_messages" ("user_id", "id")
@@ -0,0 +1,19 @@
+-- リフレッシュトークン1つにつき1行。ローテーションで発行したトークンは同じfamily_idを引き継ぐ
+CREATE TABLE "sessions" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "family_id" varchar NOT NULL,
+  "token_hash" varchar NOT NULL UNIQUE,
+  "user_agent" varchar NOT NULL DEFAULT '',
+  "ip_address" varchar NOT NULL DEFAULT '',
+  -- ログインした日時。ローテーションしても引き継ぐ。日時はすべてUTCで保存する
+  "created_at" timestamp NOT NULL,
+  "last_used_at" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  -- 新しいトークンと交換済みの場合に設定する。交換済みのトークンが再び使われた場合は系列ごと失効させる
+  "rotated_at" timestamp,
+  "revoked_at" timestamp
+);
+
+CREATE INDEX "sessions_family_id_idx" ON "sessions" ("family_id");
+CREATE INDEX "sessions_user_id_idx" ON "sessions" ("user_id")
@@ -0,0 +1,17 @@
+CREATE TABLE "personal_access_tokens" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "name" varchar NOT NULL,
+  -- 一覧で見分けるためのトークンの先頭部分
+  "prefix" varchar NOT NULL,
+  "token_hash" varchar NOT NULL UNIQUE,
+  -- "waters:read,waters:write" のようにカンマ区切りで保存する
+  "scopes" varchar NOT NULL,
+  -- 日時はすべてUTCで保存する
+  "created_at" timestamp NOT NULL,
+  "last_used_at" timestamp,
+  "expires_at" timestamp,
+  "revoked_at" timestamp
+);
+
+CREATE INDEX "personal_access_tokens_user_id_idx" ON "personal_access_tokens" ("user_id")
@@ -0,0 +1,13 @@
+-- 再設定用のトークンはハッシュだけを保存する
+CREATE TABLE "password_resets" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "token_hash" varchar NOT NULL UNIQUE,
+  -- 日時はすべてUTCで保存する
+  "created_at" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  -- 一度使ったトークンは再び使えない
+  "used_at" timestamp
+);
+
+CREATE INDEX "password_resets_user_id_idx" ON "password_resets" ("user_id")
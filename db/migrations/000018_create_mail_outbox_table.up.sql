@@ -0,0 +1,17 @@
+-- 送信するメールはいったんここに保存し、ジョブがSMTPで送る
+CREATE TABLE "mail_outbox" (
+  "id" bigserial PRIMARY KEY,
+  "to_address" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "body" text NOT NULL,
+  -- pending, sent, failed のいずれか
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "last_error" varchar NOT NULL DEFAULT '',
+  -- 日時はすべてUTCで保存する
+  "created_at" timestamp NOT NULL,
+  "next_attempt_at" timestamp NOT NULL,
+  "sent_at" timestamp
+);
+
+CREATE INDEX "mail_outbox_pending_idx" ON "mail_outbox" ("next_attempt_at") WHERE "status" = 'pending'
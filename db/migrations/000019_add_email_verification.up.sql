@@ -0,0 +1,17 @@
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamp;
+
+-- 確認を必須にしてもログインできなくならないように、既存のユーザーは確認済みとして扱う
+UPDATE "users" SET "email_verified_at" = (now() AT TIME ZONE 'UTC');
+
+-- 確認用のトークンはハッシュだけを保存する
+CREATE TABLE "email_verifications" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  "token_hash" varchar NOT NULL UNIQUE,
+  -- 日時はすべてUTCで保存する。再送の回数制限にも使う
+  "created_at" timestamp NOT NULL,
+  "expires_at" timestamp NOT NULL,
+  "used_at" timestamp
+);
+
+CREATE INDEX "email_verifications_user_id_created_at_idx" ON "email_verifications" ("user_id", "created_at")
@@ -0,0 +1,2 @@
+DELETE FROM goals WHERE source = 'recommended';
+ALTER TABLE goals DROP COLUMN IF EXISTS source;
@@ -0,0 +1,31 @@
+-- manual: ユーザーが設定した目標、recommended: プロフィールから計算して保存した推奨値
+ALTER TABLE "goals" ADD COLUMN "source" varchar NOT NULL DEFAULT 'manual';
+
+-- これまでは目標のない日に現在のプロフィールから計算した推奨値を使っていたため、
+-- 過去の日の判定が変わらないように、その推奨値をすべての日に適用される目標として保存する
+-- 計算はusecase/recommendation.goのrecommendGoalと同じ
+INSERT INTO "goals" ("user_id", "volume", "effective_date", "source")
+SELECT id, LEAST(GREATEST(ROUND(base * factor / 50) * 50, 1000), 10000), DATE '1970-01-01', 'recommended'
+FROM (
+  SELECT id,
+    CASE
+      WHEN weight_kg > 0 THEN weight_kg * CASE
+        WHEN COALESCE(age, 0) = 0 THEN 35
+        WHEN age <= 30 THEN 40
+        WHEN age <= 55 THEN 35
+        ELSE 30
+      END
+      WHEN sex = 'male' THEN 2500
+      WHEN sex = 'female' THEN 2000
+      ELSE 2200
+    END AS base,
+    CASE activity_level
+      WHEN 'light' THEN 1.05
+      WHEN 'moderate' THEN 1.1
+      WHEN 'active' THEN 1.2
+      WHEN 'very_active' THEN 1.3
+      ELSE 1.0
+    END AS factor
+  FROM users
+) AS r
+ON CONFLICT ("user_id", "effective_date") DO NOTHING;
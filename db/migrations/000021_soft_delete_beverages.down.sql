@@ -0,0 +1,5 @@
+ALTER TABLE "waters" DROP CONSTRAINT IF EXISTS "waters_beverage_id_fkey";
+ALTER TABLE "waters" ADD CONSTRAINT "waters_beverage_id_fkey" FOREIGN KEY ("beverage_id") REFERENCES beverages(id) ON DELETE SET NULL;
+DELETE FROM beverages WHERE deleted_at IS NOT NULL AND id NOT IN (SELECT beverage_id FROM waters WHERE beverage_id IS NOT NULL);
+UPDATE beverages SET deleted_at = NULL;
+ALTER TABLE "beverages" DROP COLUMN IF EXISTS "deleted_at";
@@ -0,0 +1,7 @@
+-- 削除した飲み物を参照している過去の記録が水として集計されないように、飲み物は論理削除にする
+ALTER TABLE "beverages" ADD COLUMN "deleted_at" timestamp;
+
+-- 物理削除で記録の飲み物が消えないように、参照されている飲み物は削除できなくする
+-- ユーザー削除時のカスケードと両立させるためRESTRICTではなくNO ACTIONにする
+ALTER TABLE "waters" DROP CONSTRAINT IF EXISTS "waters_beverage_id_fkey";
+ALTER TABLE "waters" ADD CONSTRAINT "waters_beverage_id_fkey" FOREIGN KEY ("beverage_id") REFERENCES beverages(id);
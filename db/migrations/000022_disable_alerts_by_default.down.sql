@@ -0,0 +1 @@
+ALTER TABLE "alert_settings" ALTER COLUMN "enabled" SET DEFAULT true;
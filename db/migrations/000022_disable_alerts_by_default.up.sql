@@ -0,0 +1,2 @@
+-- 設定を保存していないユーザーに返す初期値(model.DefaultAlertSettings)と同じく、判定は明示的に有効にするまで行わない
+ALTER TABLE "alert_settings" ALTER COLUMN "enabled" SET DEFAULT false;
@@ -0,0 +1,2 @@
+-- 消した本文は戻せない
+SELECT 1;
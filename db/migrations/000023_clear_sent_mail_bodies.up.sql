@@ -0,0 +1,2 @@
+-- 本文にはパスワード再設定などのトークンが含まれるため、再送しないメールの本文は残さない
+UPDATE "mail_outbox" SET "body" = '' WHERE "status" <> 'pending';
@@ -0,0 +1,2 @@
+-- 外したプリセットは元に戻せない
+SELECT 1;
@@ -0,0 +1,2 @@
+-- 論理削除した飲み物をプリセットにしている容器で記録できなくなっていたため、プリセットから外す
+UPDATE "containers" SET "beverage_id" = NULL WHERE "beverage_id" IN (SELECT "id" FROM "beverages" WHERE "deleted_at" IS NOT NULL);
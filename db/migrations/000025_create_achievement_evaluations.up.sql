@@ -0,0 +1,5 @@
+CREATE TABLE "achievement_evaluations" (
+  "user_id" bigint PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  "version" bigint NOT NULL DEFAULT 1,
+  "scheduled_at" timestamp NOT NULL DEFAULT current_timestamp
+)
@@ -2,6 +2,8 @@ package main
 
 import (
 	"flag"
+	// ユーザーごとのタイムゾーンで集計するため、実行環境にtzdataがなくても動くようにする
+	_ "time/tzdata"
 
 	server "github.com/mikaijun/aquagent/pkg/interfaces"
 )
@@ -0,0 +1,13 @@
+package coach
+
+import (
+	"context"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+// Coach ユーザーの状況と会話の履歴から返信を作る。規則による実装とLLMによる実装を差し替えられるようにする
+type Coach interface {
+	// Reply historyは古い順で、最後の要素が返信する対象のユーザーのメッセージ
+	Reply(ctx context.Context, coachContext *model.CoachContext, history []*model.CoachMessage) (string, error)
+}
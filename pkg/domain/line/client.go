@@ -0,0 +1,11 @@
+package line
+
+import "context"
+
+// Client LINE Messaging APIでメッセージを送る。ローカルの偽物に差し替えられるようにする
+type Client interface {
+	// Reply Webhookで受け取ったreplyTokenに返信する
+	Reply(ctx context.Context, replyToken string, messages ...string) error
+	// Push LINEのユーザーIDを指定してメッセージを送る
+	Push(ctx context.Context, lineUserId string, messages ...string) error
+}
@@ -0,0 +1,12 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+// Mailer メールの送信方法を差し替えられるようにする
+type Mailer interface {
+	Send(ctx context.Context, mail *model.Mail) error
+}
@@ -0,0 +1,26 @@
+package model
+
+// UserAchievement ユーザーが獲得したバッジ
+type UserAchievement struct {
+	ID       int64
+	UserID   int64
+	BadgeKey string
+	EarnedAt string
+}
+
+// Achievement バッジの獲得状況。未獲得のバッジも進捗とともに返す
+type Achievement struct {
+	Key         string  `json:"key"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Earned      bool    `json:"earned"`
+	EarnedAt    *string `json:"earned_at"`
+	Progress    int64   `json:"progress"`
+	Target      int64   `json:"target"`
+}
+
+// AchievementEvaluation バッジの判定を待っているユーザー。Versionは判定を依頼されるたびに増える
+type AchievementEvaluation struct {
+	UserID  int64
+	Version int64
+}
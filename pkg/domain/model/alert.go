@@ -0,0 +1,44 @@
+package model
+
+const (
+	// AlertKindInactivity 最後に飲んでから長時間経過している
+	AlertKindInactivity = "inactivity"
+	// AlertKindLowDailyTotal 期限の時刻までに1日の最低量を飲んでいない
+	AlertKindLowDailyTotal = "low_daily_total"
+)
+
+type Alert struct {
+	ID      int64  `json:"id"`
+	UserID  int64  `json:"user_id"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+	// AlertDate ユーザーのタイムゾーンにおける発生日
+	AlertDate      string  `json:"alert_date"`
+	CreatedAt      string  `json:"created_at"`
+	AcknowledgedAt *string `json:"acknowledged_at"`
+}
+
+// AlertSettings 脱水リスクを判定するしきい値。時刻はユーザーのタイムゾーンにおける時(0-23)
+type AlertSettings struct {
+	UserID                   int64 `json:"user_id"`
+	Enabled                  bool  `json:"enabled"`
+	MaxHoursWithoutDrink     int64 `json:"max_hours_without_drink"`
+	DailyMinimum             int64 `json:"daily_minimum"`
+	DailyMinimumDeadlineHour int64 `json:"daily_minimum_deadline_hour"`
+	// ActiveStartHourからActiveEndHourの間だけ判定する(就寝中に通知しないため)
+	ActiveStartHour int64 `json:"active_start_hour"`
+	ActiveEndHour   int64 `json:"active_end_hour"`
+}
+
+// DefaultAlertSettings 設定を保存していないユーザーに返す値。保存するまで判定は行わない
+func DefaultAlertSettings(userId int64) *AlertSettings {
+	return &AlertSettings{
+		UserID:                   userId,
+		Enabled:                  false,
+		MaxHoursWithoutDrink:     4,
+		DailyMinimum:             1000,
+		DailyMinimumDeadlineHour: 18,
+		ActiveStartHour:          8,
+		ActiveEndHour:            21,
+	}
+}
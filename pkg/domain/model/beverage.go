@@ -0,0 +1,20 @@
+package model
+
+// Beverage 飲み物の種類。UserIDが0のものは全ユーザー共通
+type Beverage struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"user_id"`
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	// HydrationFactor 飲んだ量のうち水分補給として数える割合
+	HydrationFactor    float64 `json:"hydration_factor"`
+	CaffeineMgPer100ml float64 `json:"caffeine_mg_per_100ml"`
+	AlcoholGPer100ml   float64 `json:"alcohol_g_per_100ml"`
+	// Deleted 削除済みの飲み物は新しい記録には使えない
+	Deleted bool `json:"-"`
+}
+
+// IsAvailableTo userIdのユーザーが記録に使える飲み物かどうか
+func (b *Beverage) IsAvailableTo(userId int64) bool {
+	return !b.Deleted && (b.UserID == 0 || b.UserID == userId)
+}
@@ -0,0 +1,28 @@
+package model
+
+const (
+	CoachRoleUser      = "user"
+	CoachRoleAssistant = "assistant"
+)
+
+type CoachMessage struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+// CoachContext コーチが助言に使うユーザーの状況。日時はユーザーのタイムゾーン
+type CoachContext struct {
+	Now      string        `json:"now"`
+	Timezone string        `json:"timezone"`
+	Goal     int64         `json:"goal"`
+	Today    *DailySummary `json:"today"`
+	// RecentDays 今日を含む直近の日ごとの集計。古い順
+	RecentDays []*DailySummary  `json:"recent_days"`
+	Streaks    *StreakStats     `json:"streaks"`
+	Pattern    *DrinkingPattern `json:"pattern"`
+	// MinutesSinceLastDrink 一度も記録していない場合はnil
+	MinutesSinceLastDrink *int64 `json:"minutes_since_last_drink"`
+}
@@ -0,0 +1,13 @@
+package model
+
+// Container よく使う容器(ボトル、マグカップなど)の量と飲み物のプリセット
+type Container struct {
+	ID     int64  `json:"id"`
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+	Volume int64  `json:"volume"`
+	// BeverageID 0の場合は水として扱う
+	BeverageID int64 `json:"beverage_id"`
+	// UsageCount 記録に使われた回数。よく使う順に並べるために使う
+	UsageCount int64 `json:"usage_count"`
+}
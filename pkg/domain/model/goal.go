@@ -0,0 +1,34 @@
+package model
+
+const (
+	GoalSourceManual = "manual"
+	// GoalSourceRecommended プロフィールから計算した推奨値。手動の目標がない間、プロフィールを変更するたびに保存する
+	GoalSourceRecommended = "recommended"
+)
+
+// Goal 1日の目標摂取量(ml)。EffectiveDate以降、次の目標が有効になるまで適用される
+type Goal struct {
+	ID            int64  `json:"id"`
+	UserID        int64  `json:"user_id"`
+	Volume        int64  `json:"volume"`
+	EffectiveDate string `json:"effective_date"`
+	Source        string `json:"source"`
+}
+
+type GoalSettings struct {
+	// Current 今日に適用される目標
+	Current int64 `json:"current"`
+	// IsDefault 手動の目標が設定されておらず、推奨値を使っている
+	IsDefault bool    `json:"is_default"`
+	History   []*Goal `json:"history"`
+}
+
+// GoalRecommendation プロフィールと運動量から計算した推奨摂取量
+type GoalRecommendation struct {
+	Volume          int64 `json:"volume"`
+	BaseVolume      int64 `json:"base_volume"`
+	ExerciseVolume  int64 `json:"exercise_volume"`
+	ExerciseMinutes int64 `json:"exercise_minutes"`
+	// ProfileComplete falseの場合は体重が未設定のため性別ごとの目安値を使っている
+	ProfileComplete bool `json:"profile_complete"`
+}
@@ -0,0 +1,30 @@
+package model
+
+const (
+	LineEventFollow   = "follow"
+	LineEventUnfollow = "unfollow"
+	LineEventMessage  = "message"
+)
+
+// LineAccount aquagentのユーザーと連携したLINEアカウント
+type LineAccount struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	LineUserID string `json:"line_user_id"`
+}
+
+// LineLinkCode LINEからアカウントを連携するための使い捨てのコード
+type LineLinkCode struct {
+	Code      string `json:"code"`
+	UserID    int64  `json:"user_id"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// LineEvent Webhookで受け取ったイベントのうち、処理に使う項目
+type LineEvent struct {
+	Type       string
+	ReplyToken string
+	LineUserID string
+	// Text テキストメッセージの場合のみ設定される
+	Text string
+}
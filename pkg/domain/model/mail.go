@@ -0,0 +1,22 @@
+package model
+
+const (
+	MailStatusPending = "pending"
+	MailStatusSent    = "sent"
+	// MailStatusFailed 再送しても届かなかったメール
+	MailStatusFailed = "failed"
+)
+
+// Mail outboxに保存するメール。日時はUTC
+type Mail struct {
+	ID            int64
+	To            string
+	Subject       string
+	Body          string
+	Status        string
+	Attempts      int64
+	LastError     string
+	CreatedAt     string
+	NextAttemptAt string
+	SentAt        *string
+}
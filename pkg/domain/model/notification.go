@@ -0,0 +1,14 @@
+package model
+
+const (
+	NotificationKindReminder = "reminder"
+	NotificationKindAlert    = "alert"
+)
+
+// Notification ユーザーに届ける通知。配信方法はNotifierの実装による
+type Notification struct {
+	UserID int64  `json:"user_id"`
+	Kind   string `json:"kind"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
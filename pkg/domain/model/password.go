@@ -0,0 +1,11 @@
+package model
+
+// PasswordReset パスワード再設定の依頼。日時はUTC
+type PasswordReset struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	CreatedAt string
+	ExpiresAt string
+	UsedAt    *string
+}
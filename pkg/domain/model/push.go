@@ -0,0 +1,10 @@
+package model
+
+// PushSubscription ブラウザのPushSubscriptionの内容。鍵はbase64url(パディングなし)
+type PushSubscription struct {
+	ID       int64  `json:"id"`
+	UserID   int64  `json:"user_id"`
+	Endpoint string `json:"endpoint"`
+	P256dh   string `json:"p256dh"`
+	Auth     string `json:"auth"`
+}
@@ -0,0 +1,26 @@
+package model
+
+const (
+	// ReminderKindInterval 前回の通知からIntervalMinutes経過するごとに通知する
+	ReminderKindInterval = "interval"
+	// ReminderKindFixed Timesで指定した時刻に通知する
+	ReminderKindFixed = "fixed"
+)
+
+// Reminder 時刻はすべてユーザーのタイムゾーンにおける"HH:MM"
+type Reminder struct {
+	ID              int64    `json:"id"`
+	UserID          int64    `json:"user_id"`
+	Kind            string   `json:"kind"`
+	IntervalMinutes int64    `json:"interval_minutes"`
+	Times           []string `json:"times"`
+	// Days 通知する曜日(0が日曜日)。空の場合は毎日
+	Days []int64 `json:"days"`
+	// QuietStartからQuietEndの間は通知しない。日付をまたいでもよい
+	QuietStart string `json:"quiet_start"`
+	QuietEnd   string `json:"quiet_end"`
+	// SkipIfDrankWithinMinutes この時間内に飲んでいれば通知を見送る。0の場合は見送らない
+	SkipIfDrankWithinMinutes int64   `json:"skip_if_drank_within_minutes"`
+	Enabled                  bool    `json:"enabled"`
+	LastTriggeredAt          *string `json:"last_triggered_at"`
+}
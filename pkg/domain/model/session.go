@@ -0,0 +1,27 @@
+package model
+
+// Session ログイン中の端末。日時はUTC
+type Session struct {
+	ID         int64   `json:"id"`
+	UserID     int64   `json:"user_id"`
+	FamilyID   string  `json:"-"`
+	TokenHash  string  `json:"-"`
+	UserAgent  string  `json:"user_agent"`
+	IPAddress  string  `json:"ip_address"`
+	CreatedAt  string  `json:"created_at"`
+	LastUsedAt string  `json:"last_used_at"`
+	ExpiresAt  string  `json:"expires_at"`
+	RotatedAt  *string `json:"-"`
+	RevokedAt  *string `json:"-"`
+	// Current リクエストを送った端末のセッション
+	Current bool `json:"current"`
+}
+
+// TokenPair ログインやリフレッシュで発行するトークン。ExpiresInは秒
+type TokenPair struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in"`
+}
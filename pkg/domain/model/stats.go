@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+type CompletionRate struct {
+	// Days 集計対象の日数。最初の記録より前の日は含めない
+	Days    int64   `json:"days"`
+	MetDays int64   `json:"met_days"`
+	Rate    float64 `json:"rate"`
+}
+
+// StreakStats 目標を達成した日の連続記録。日付はユーザーのタイムゾーンで区切る
+type StreakStats struct {
+	// CurrentStreak 今日が未達成でも、昨日まで続いていれば継続中として数える
+	CurrentStreak      int64                      `json:"current_streak"`
+	LongestStreak      int64                      `json:"longest_streak"`
+	LongestStreakStart *string                    `json:"longest_streak_start"`
+	LongestStreakEnd   *string                    `json:"longest_streak_end"`
+	TodayMet           bool                       `json:"today_met"`
+	CompletionRates    map[string]*CompletionRate `json:"completion_rates"`
+}
+
+// LocalDrink ユーザーのタイムゾーンにおける壁時計の時刻に変換した記録
+type LocalDrink struct {
+	Volume  int64
+	DrankAt time.Time
+}
+
+// DrinkingPattern 直近Days日間の飲み方の傾向
+type DrinkingPattern struct {
+	Days int64 `json:"days"`
+	// Heatmap [曜日][時]ごとの1日あたりの平均量(ml)。曜日は0が日曜日
+	Heatmap [7][24]float64 `json:"heatmap"`
+	// TypicalLongestGapMinutes 1日のうちで最も長く飲まなかった時間(分)の中央値
+	TypicalLongestGapMinutes *int64 `json:"typical_longest_gap_minutes"`
+	// MedianFirstDrink, MedianLastDrink 最初と最後に飲んだ時刻(HH:MM)の中央値
+	MedianFirstDrink *string `json:"median_first_drink"`
+	MedianLastDrink  *string `json:"median_last_drink"`
+}
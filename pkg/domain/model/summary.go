@@ -0,0 +1,70 @@
+package model
+
+// DailyTotal ユーザーのタイムゾーンにおける1日分の集計結果
+type DailyTotal struct {
+	Date        string
+	TotalVolume int64
+	// EffectiveVolume 飲み物ごとの係数を掛けた水分補給量
+	EffectiveVolume int64
+	CaffeineMg      float64
+	AlcoholG        float64
+	Count           int64
+	FirstDrankAt    string
+	LastDrankAt     string
+}
+
+// DailySummary 目標の達成率はEffectiveVolumeで計算する
+type DailySummary struct {
+	Date            string  `json:"date"`
+	TotalVolume     int64   `json:"total_volume"`
+	EffectiveVolume int64   `json:"effective_volume"`
+	Count           int64   `json:"count"`
+	FirstDrankAt    *string `json:"first_drank_at"`
+	LastDrankAt     *string `json:"last_drank_at"`
+	Goal            int64   `json:"goal"`
+	Progress        float64 `json:"progress"`
+	CaffeineMg      float64 `json:"caffeine_mg"`
+	CaffeineLimitMg int64   `json:"caffeine_limit_mg"`
+	AlcoholG        float64 `json:"alcohol_g"`
+	// CaffeineWarning カフェインの摂取量が上限を超えている
+	CaffeineWarning bool `json:"caffeine_warning"`
+	// AlcoholWarning アルコールによって水分補給量が差し引かれている
+	AlcoholWarning bool `json:"alcohol_warning"`
+}
+
+type ChartDay struct {
+	Date            string `json:"date"`
+	TotalVolume     int64  `json:"total_volume"`
+	EffectiveVolume int64  `json:"effective_volume"`
+}
+
+// WeeklyChart 1週間分のグラフ表示用データ。記録のない日は0で埋める
+// BestDayとWorstDayはEffectiveVolumeで比較する
+type WeeklyChart struct {
+	Start            string      `json:"start"`
+	End              string      `json:"end"`
+	Days             []*ChartDay `json:"days"`
+	Total            int64       `json:"total"`
+	EffectiveTotal   int64       `json:"effective_total"`
+	Average          float64     `json:"average"`
+	EffectiveAverage float64     `json:"effective_average"`
+	BestDay          *ChartDay   `json:"best_day"`
+	WorstDay         *ChartDay   `json:"worst_day"`
+}
+
+// CalendarDay GoalMetはEffectiveVolumeで判定する
+type CalendarDay struct {
+	Date            string `json:"date"`
+	TotalVolume     int64  `json:"total_volume"`
+	EffectiveVolume int64  `json:"effective_volume"`
+	Count           int64  `json:"count"`
+	Goal            int64  `json:"goal"`
+	GoalMet         bool   `json:"goal_met"`
+}
+
+// MonthlyCalendar カレンダー表示用の1ヶ月分のデータ。月のすべての日を含む
+type MonthlyCalendar struct {
+	Year  int            `json:"year"`
+	Month int            `json:"month"`
+	Days  []*CalendarDay `json:"days"`
+}
@@ -0,0 +1,26 @@
+package model
+
+// パーソナルアクセストークンに付けられるスコープ
+const (
+	ScopeWatersRead  = "waters:read"
+	ScopeWatersWrite = "waters:write"
+	ScopeStatsRead   = "stats:read"
+)
+
+var Scopes = []string{ScopeWatersRead, ScopeWatersWrite, ScopeStatsRead}
+
+// PersonalAccessToken スクリプトや連携サービスから使うトークン。日時はUTC
+type PersonalAccessToken struct {
+	ID        int64    `json:"id"`
+	UserID    int64    `json:"user_id"`
+	Name      string   `json:"name"`
+	Prefix    string   `json:"prefix"`
+	TokenHash string   `json:"-"`
+	Scopes    []string `json:"scopes"`
+	// Token 作成したときだけ設定する。保存するのはハッシュだけ
+	Token      string  `json:"token,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+	LastUsedAt *string `json:"last_used_at"`
+	ExpiresAt  *string `json:"expires_at"`
+	RevokedAt  *string `json:"-"`
+}
@@ -1,8 +1,33 @@
 package model
 
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+	SexOther  = "other"
+)
+
+const (
+	ActivitySedentary  = "sedentary"
+	ActivityLight      = "light"
+	ActivityModerate   = "moderate"
+	ActivityActive     = "active"
+	ActivityVeryActive = "very_active"
+)
+
 type User struct {
-  ID       int64
-  Username string
-  Email    string
-  Password string
+	ID       int64
+	Username string
+	Email    string
+	Password string
+	// Timezone IANAのタイムゾーン名(例: Asia/Tokyo)。日付単位の集計に使う
+	Timezone string
+	// 以下は推奨摂取量の計算に使うプロフィール。未設定の場合はゼロ値
+	WeightKg      float64
+	Age           int64
+	Sex           string
+	ActivityLevel string
+	// CaffeineLimitMg 1日のカフェイン摂取量の上限。超えた日は警告を出す
+	CaffeineLimitMg int64
+	// EmailVerifiedAt メールアドレスを確認した日時(UTC)。未確認の場合はnil
+	EmailVerifiedAt *string
 }
@@ -0,0 +1,11 @@
+package model
+
+// EmailVerification メールアドレスの確認の依頼。日時はUTC
+type EmailVerification struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	CreatedAt string
+	ExpiresAt string
+	UsedAt    *string
+}
@@ -1,8 +1,16 @@
 package model
 
 type Water struct {
-	ID      int64
-	UserID  int64
-	Volume  int64
+	ID     int64
+	UserID int64
+	Volume int64
+	// BeverageID 0の場合は水として扱う
+	BeverageID int64
+	// ContainerID 容器のプリセットから記録した場合に設定される
+	ContainerID int64
+	// CaffeineMgとAlcoholGはnilの場合、飲み物の既定値から計算する
+	CaffeineMg *float64
+	AlcoholG   *float64
+	// DrankAt ユーザーのタイムゾーンにおける時刻(YYYY-MM-DD HH:MM:SS)。変換せずにそのまま保存する
 	DrankAt string
 }
@@ -0,0 +1,12 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+// Notifier 通知の配信方法を差し替えられるようにする
+type Notifier interface {
+	Notify(ctx context.Context, notification *model.Notification) error
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+type AchievementRepository interface {
+	GetAchievements(ctx context.Context, userId int64) ([]*model.UserAchievement, error)
+	// CreateAchievement 獲得済みのバッジの場合は何もしない
+	CreateAchievement(ctx context.Context, achievement *model.UserAchievement) error
+	// ScheduleEvaluation 判定待ちとして記録する。すでに判定待ちの場合はVersionを増やす
+	ScheduleEvaluation(ctx context.Context, userId int64) error
+	// GetPendingEvaluations 判定待ちのユーザーを古い順に返す
+	GetPendingEvaluations(ctx context.Context, limit int64) ([]*model.AchievementEvaluation, error)
+	// CompleteEvaluation 判定待ちから外す。判定の間に再び依頼されてVersionが変わった場合は残す
+	CompleteEvaluation(ctx context.Context, evaluation *model.AchievementEvaluation) error
+}
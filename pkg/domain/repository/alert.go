@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+var (
+	ErrAlertNotFound         = errors.New("alert not found")
+	ErrAlertSettingsNotFound = errors.New("alert settings not found")
+)
+
+type AlertRepository interface {
+	GetSettings(ctx context.Context, userId int64) (*model.AlertSettings, error)
+	UpsertSettings(ctx context.Context, settings *model.AlertSettings) (*model.AlertSettings, error)
+	GetEnabledSettings(ctx context.Context) ([]*model.AlertSettings, error)
+	CreateAlert(ctx context.Context, alert *model.Alert) (*model.Alert, error)
+	// GetAlerts 新しい順に返す。onlyOpenがtrueの場合は確認済みのものを除く
+	GetAlerts(ctx context.Context, userId int64, onlyOpen bool) ([]*model.Alert, error)
+	GetAlert(ctx context.Context, alertId int64) (*model.Alert, error)
+	// GetLatestAlert kindの中で最も新しいものを返す。ない場合はErrAlertNotFound
+	GetLatestAlert(ctx context.Context, userId int64, kind string) (*model.Alert, error)
+	AcknowledgeAlert(ctx context.Context, alertId int64) error
+}
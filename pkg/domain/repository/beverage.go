@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+var ErrBeverageNotFound = errors.New("beverage not found")
+
+type BeverageRepository interface {
+	// GetBeverages 共通の飲み物とuserIdのユーザーが登録した飲み物を返す
+	GetBeverages(ctx context.Context, userId int64) ([]*model.Beverage, error)
+	// GetBeverage 過去の記録から参照されるため論理削除した飲み物も返す
+	GetBeverage(ctx context.Context, beverageId int64) (*model.Beverage, error)
+	CreateBeverage(ctx context.Context, beverage *model.Beverage) (*model.Beverage, error)
+	// DeleteBeverage 記録から参照されている飲み物があるため論理削除する。容器のプリセットからは外す
+	DeleteBeverage(ctx context.Context, beverageId int64, now time.Time) error
+}
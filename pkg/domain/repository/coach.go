@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+type CoachRepository interface {
+	CreateMessage(ctx context.Context, message *model.CoachMessage) (*model.CoachMessage, error)
+	// GetMessages 直近limit件を古い順に返す
+	GetMessages(ctx context.Context, userId int64, limit int) ([]*model.CoachMessage, error)
+	DeleteMessages(ctx context.Context, userId int64) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+var ErrContainerNotFound = errors.New("container not found")
+
+type ContainerRepository interface {
+	// GetContainers よく使われている順に返す
+	GetContainers(ctx context.Context, userId int64) ([]*model.Container, error)
+	GetContainer(ctx context.Context, containerId int64) (*model.Container, error)
+	CreateContainer(ctx context.Context, container *model.Container) (*model.Container, error)
+	UpdateContainer(ctx context.Context, container *model.Container) (*model.Container, error)
+	DeleteContainer(ctx context.Context, containerId int64) error
+	IncrementUsage(ctx context.Context, containerId int64) error
+}
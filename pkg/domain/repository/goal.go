@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+type GoalRepository interface {
+	// UpsertGoal 同じ日付の目標がすでにある場合は上書きする
+	UpsertGoal(ctx context.Context, goal *model.Goal) (*model.Goal, error)
+	// GetGoals EffectiveDateの昇順で返す
+	GetGoals(ctx context.Context, userId int64) ([]*model.Goal, error)
+}
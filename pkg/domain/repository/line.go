@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+var (
+	ErrLineAccountNotFound  = errors.New("line account not found")
+	ErrLineLinkCodeNotFound = errors.New("line link code not found")
+	ErrLineLinkCodeConflict = errors.New("line link code already exists")
+)
+
+type LineRepository interface {
+	// CreateLinkCode 同じコードがすでにある場合はErrLineLinkCodeConflict
+	CreateLinkCode(ctx context.Context, code *model.LineLinkCode) (*model.LineLinkCode, error)
+	// ConsumeLinkCode 有効期限内のコードを削除し、発行したユーザーのIDを返す
+	ConsumeLinkCode(ctx context.Context, code string, now time.Time) (int64, error)
+	// LinkAccount すでに連携している場合は新しいLINEアカウントで上書きする
+	LinkAccount(ctx context.Context, account *model.LineAccount) (*model.LineAccount, error)
+	GetAccountByUserID(ctx context.Context, userId int64) (*model.LineAccount, error)
+	GetAccountByLineUserID(ctx context.Context, lineUserId string) (*model.LineAccount, error)
+	DeleteAccount(ctx context.Context, userId int64) error
+}
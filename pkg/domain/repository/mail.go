@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+// MailRepository 本文にはパスワード再設定などのトークンが含まれるため、再送しなくなったメールの本文は消す
+type MailRepository interface {
+	CreateMail(ctx context.Context, mail *model.Mail) (*model.Mail, error)
+	// GetPendingMails 送信予定の日時を過ぎた未送信のメールを古い順に返す
+	GetPendingMails(ctx context.Context, now time.Time, limit int64) ([]*model.Mail, error)
+	// MarkMailSent 送信済みにして本文を消す
+	MarkMailSent(ctx context.Context, mailId int64, now time.Time) error
+	// MarkMailRetry 送信に失敗したメールをnextAttemptAtに再送する
+	MarkMailRetry(ctx context.Context, mailId int64, lastError string, nextAttemptAt time.Time) error
+	// MarkMailFailed 送信に失敗したメールを再送しないようにして本文を消す
+	MarkMailFailed(ctx context.Context, mailId int64, lastError string) error
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+var ErrPasswordResetNotFound = errors.New("password reset not found")
+
+type PasswordResetRepository interface {
+	CreatePasswordReset(ctx context.Context, reset *model.PasswordReset) (*model.PasswordReset, error)
+	GetPasswordResetByTokenHash(ctx context.Context, tokenHash string) (*model.PasswordReset, error)
+	// CountPasswordResetsSince since以降にユーザーへ発行した件数を返す
+	CountPasswordResetsSince(ctx context.Context, userId int64, since time.Time) (int64, error)
+	// ResetPassword 未使用のトークンを使用済みにしてパスワードを変更する。すでに使用済みの場合はfalseを返す
+	// 同時に、ユーザーの他のトークン、セッション、パーソナルアクセストークンをすべて無効にする
+	// これらは1つのトランザクションで行い、途中で失敗した場合は何も変更しない
+	ResetPassword(ctx context.Context, reset *model.PasswordReset, hashedPassword string, now time.Time) (bool, error)
+}
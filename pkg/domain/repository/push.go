@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+var ErrPushSubscriptionTaken = errors.New("push subscription belongs to another user")
+
+type PushSubscriptionRepository interface {
+	// CreateSubscription 同じユーザーが同じendpointをすでに登録している場合は鍵を上書きする
+	// 他のユーザーが登録しているendpointの場合はErrPushSubscriptionTaken
+	CreateSubscription(ctx context.Context, subscription *model.PushSubscription) (*model.PushSubscription, error)
+	GetSubscriptions(ctx context.Context, userId int64) ([]*model.PushSubscription, error)
+	DeleteSubscription(ctx context.Context, userId int64, endpoint string) error
+	// DeleteSubscriptionByEndpoint プッシュサービスから無効と返されたendpointを削除する
+	DeleteSubscriptionByEndpoint(ctx context.Context, endpoint string) error
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+var ErrReminderNotFound = errors.New("reminder not found")
+
+type ReminderRepository interface {
+	GetReminders(ctx context.Context, userId int64) ([]*model.Reminder, error)
+	GetEnabledReminders(ctx context.Context) ([]*model.Reminder, error)
+	GetReminder(ctx context.Context, reminderId int64) (*model.Reminder, error)
+	CreateReminder(ctx context.Context, reminder *model.Reminder) (*model.Reminder, error)
+	UpdateReminder(ctx context.Context, reminder *model.Reminder) (*model.Reminder, error)
+	DeleteReminder(ctx context.Context, reminderId int64) error
+	UpdateTriggeredAt(ctx context.Context, reminderId int64, triggeredAt time.Time) error
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *model.Session) (*model.Session, error)
+	GetSession(ctx context.Context, sessionId int64) (*model.Session, error)
+	GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error)
+	// GetActiveSessions 交換も失効もしておらず、有効期限内のセッションを最近使った順に返す
+	GetActiveSessions(ctx context.Context, userId int64, now time.Time) ([]*model.Session, error)
+	// RotateSession 未交換のトークンを交換済みにする。すでに交換済みの場合はfalseを返す
+	RotateSession(ctx context.Context, sessionId int64, now time.Time) (bool, error)
+	// RevokeFamily 同じ系列のトークンをすべて失効させる
+	RevokeFamily(ctx context.Context, familyId string, now time.Time) error
+	// RevokeUserSessions ユーザーのセッションをすべて失効させる
+	RevokeUserSessions(ctx context.Context, userId int64, now time.Time) error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+var ErrTokenNotFound = errors.New("personal access token not found")
+
+type TokenRepository interface {
+	CreateToken(ctx context.Context, token *model.PersonalAccessToken) (*model.PersonalAccessToken, error)
+	// GetTokens 失効していないトークンを作成した順に返す
+	GetTokens(ctx context.Context, userId int64) ([]*model.PersonalAccessToken, error)
+	GetToken(ctx context.Context, tokenId int64) (*model.PersonalAccessToken, error)
+	GetTokenByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error)
+	RevokeToken(ctx context.Context, tokenId int64, now time.Time) error
+	// RevokeUserTokens ユーザーのトークンをすべて失効させる
+	RevokeUserTokens(ctx context.Context, userId int64, now time.Time) error
+	UpdateLastUsedAt(ctx context.Context, tokenId int64, now time.Time) error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/mikaijun/aquagent/pkg/domain/model"
 )
@@ -10,4 +11,9 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user *model.User) (*model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
 	GetUserById(ctx context.Context, id int64) (*model.User, error)
+	UpdateUser(ctx context.Context, user *model.User) (*model.User, error)
+	// UpdatePassword hashedPasswordはハッシュ化済みのパスワード
+	UpdatePassword(ctx context.Context, userId int64, hashedPassword string) error
+	// VerifyEmail 確認済みの場合は日時を上書きしない
+	VerifyEmail(ctx context.Context, userId int64, now time.Time) error
 }
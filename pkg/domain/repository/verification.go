@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+var ErrEmailVerificationNotFound = errors.New("email verification not found")
+
+type EmailVerificationRepository interface {
+	CreateEmailVerification(ctx context.Context, verification *model.EmailVerification) (*model.EmailVerification, error)
+	GetEmailVerificationByTokenHash(ctx context.Context, tokenHash string) (*model.EmailVerification, error)
+	// VerifyEmail 未使用のトークンを使用済みにしてメールアドレスを確認済みにする。すでに使用済みの場合はfalseを返す
+	// 1つのトランザクションで行い、途中で失敗した場合はトークンを使用済みにしない
+	VerifyEmail(ctx context.Context, verification *model.EmailVerification, now time.Time) (bool, error)
+	// CountEmailVerificationsSince since以降にユーザーへ発行した件数を返す
+	CountEmailVerificationsSince(ctx context.Context, userId int64, since time.Time) (int64, error)
+}
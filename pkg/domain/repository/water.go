@@ -2,14 +2,29 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/mikaijun/aquagent/pkg/domain/model"
 )
 
+var ErrWaterNotFound = errors.New("water not found")
+
 type WaterRepository interface {
 	CreateWater(ctx context.Context, water *model.Water) (*model.Water, error)
+	// CreateWaters すべて記録するか、1件も記録しない
+	CreateWaters(ctx context.Context, waters []*model.Water) ([]*model.Water, error)
 	CreateRandomWaters(ctx context.Context) ([]*model.Water, error)
 	GetWaters(ctx context.Context, userId int64, filter map[string]interface{}) ([]*model.Water, error)
 	GetWater(ctx context.Context, waterId int64) (*model.Water, error)
-	DeleteWater(ctx context.Context, waterId int64) error
+	UpdateWater(ctx context.Context, water *model.Water) (*model.Water, error)
+	// DeleteWater userIdのユーザーの記録でない場合はErrWaterNotFoundを返す
+	DeleteWater(ctx context.Context, userId, waterId int64) error
+	CountWaters(ctx context.Context, userId int64) (int64, error)
+	// GetLastDrankAt drank_atをtimezoneの時刻として解釈して返す。記録がない場合はゼロ値を返す
+	GetLastDrankAt(ctx context.Context, userId int64, timezone string) (time.Time, error)
+	// GetDailyTotals drank_atの日付単位でstartからend(両端を含む)までを集計する
+	GetDailyTotals(ctx context.Context, userId int64, start, end string) ([]*model.DailyTotal, error)
+	// GetLocalDrinks drank_atの日付でstartからend(両端を含む)までの記録を古い順に返す
+	GetLocalDrinks(ctx context.Context, userId int64, start, end string) ([]*model.LocalDrink, error)
 }
@@ -0,0 +1,29 @@
+package coachimpl
+
+import (
+	"context"
+	"log"
+
+	"github.com/mikaijun/aquagent/pkg/domain/coach"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+type fallbackCoach struct {
+	primary  coach.Coach
+	fallback coach.Coach
+}
+
+// NewFallbackCoach primaryが返信を作れなかった場合はfallbackで返信する。LLMのAPIの障害でコーチが使えなくならないようにする
+func NewFallbackCoach(primary, fallback coach.Coach) coach.Coach {
+	return &fallbackCoach{primary: primary, fallback: fallback}
+}
+
+func (c *fallbackCoach) Reply(ctx context.Context, coachContext *model.CoachContext, history []*model.CoachMessage) (string, error) {
+	reply, err := c.primary.Reply(ctx, coachContext, history)
+	if err == nil {
+		return reply, nil
+	}
+
+	log.Printf("coach api failed, falling back: %v", err)
+	return c.fallback.Reply(ctx, coachContext, history)
+}
@@ -0,0 +1,97 @@
+package coachimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mikaijun/aquagent/pkg/domain/coach"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+const systemPrompt = `あなたは水分補給アプリ「aquagent」のコーチです。
+以下のJSONはユーザーの現在の状況です。量の単位はml、日時はユーザーのタイムゾーンです。
+状況を踏まえて、日本語で簡潔かつ具体的に助言してください。医療的な診断はしないでください。
+`
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAICoach struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// NewOpenAICoach OpenAI互換のChat Completions APIで返信を作る
+// baseURLは「https://api.openai.com/v1」のように/chat/completionsの手前までを指定する。ローカルのスタブにも向けられる
+func NewOpenAICoach(baseURL, apiKey, model string, client *http.Client) coach.Coach {
+	return &openAICoach{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  client,
+	}
+}
+
+func (c *openAICoach) Reply(ctx context.Context, coachContext *model.CoachContext, history []*model.CoachMessage) (string, error) {
+	situation, err := json.Marshal(coachContext)
+	if err != nil {
+		return "", err
+	}
+
+	messages := []chatMessage{{Role: "system", Content: systemPrompt + string(situation)}}
+	for _, message := range history {
+		messages = append(messages, chatMessage{Role: message.Role, Content: message.Content})
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"model":    c.model,
+		"messages": messages,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("coach api responded with status %d: %s", resp.StatusCode, detail)
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return "", err
+	}
+	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Message.Content) == "" {
+		return "", errors.New("coach api returned no reply")
+	}
+
+	return strings.TrimSpace(completion.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,202 @@
+package coachimpl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+// chatRequest スタブが受け取ったChat Completions APIのリクエスト
+type chatRequest struct {
+	path          string
+	authorization string
+	contentType   string
+	body          struct {
+		Model    string        `json:"model"`
+		Messages []chatMessage `json:"messages"`
+	}
+}
+
+// newChatStub statusとbodyを返すOpenAI互換のAPIのスタブ
+func newChatStub(t *testing.T, status int, body string) (*httptest.Server, *chatRequest) {
+	t.Helper()
+	received := &chatRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.path = r.URL.Path
+		received.authorization = r.Header.Get("Authorization")
+		received.contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&received.body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func testCoachContext() *model.CoachContext {
+	minutes := int64(30)
+	return &model.CoachContext{
+		Now:                   "2024-06-01 10:30",
+		Timezone:              "Asia/Tokyo",
+		Goal:                  2000,
+		Today:                 &model.DailySummary{Date: "2024-06-01", Goal: 2000, EffectiveVolume: 800},
+		MinutesSinceLastDrink: &minutes,
+	}
+}
+
+func testHistory() []*model.CoachMessage {
+	return []*model.CoachMessage{
+		{Role: model.CoachRoleUser, Content: "おはよう"},
+		{Role: model.CoachRoleAssistant, Content: "おはようございます"},
+		{Role: model.CoachRoleUser, Content: "今日あとどれくらい飲めばいい?"},
+	}
+}
+
+func TestOpenAICoachReply(t *testing.T) {
+	server, received := newChatStub(t, http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"  あと1200mlです。  "}}]}`)
+	c := NewOpenAICoach(server.URL+"/v1/", "secret", "test-model", server.Client())
+
+	reply, err := c.Reply(context.Background(), testCoachContext(), testHistory())
+	if err != nil {
+		t.Fatalf("Reply() error = %v", err)
+	}
+	if reply != "あと1200mlです。" {
+		t.Errorf("Reply() = %q, want the trimmed content", reply)
+	}
+
+	if received.path != "/v1/chat/completions" {
+		t.Errorf("path = %q, want /v1/chat/completions", received.path)
+	}
+	if received.authorization != "Bearer secret" {
+		t.Errorf("Authorization = %q, want Bearer secret", received.authorization)
+	}
+	if received.contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", received.contentType)
+	}
+	if received.body.Model != "test-model" {
+		t.Errorf("model = %q, want test-model", received.body.Model)
+	}
+
+	// 先頭に状況を含むシステムメッセージを置き、続けて履歴を古い順に送る
+	messages := received.body.Messages
+	if len(messages) != 4 {
+		t.Fatalf("messages = %v, want system and 3 history messages", messages)
+	}
+	if messages[0].Role != "system" || !strings.Contains(messages[0].Content, `"goal":2000`) || !strings.Contains(messages[0].Content, `"minutes_since_last_drink":30`) {
+		t.Errorf("system message = %q, want the coach context as json", messages[0].Content)
+	}
+	for i, message := range testHistory() {
+		if got := messages[i+1]; got.Role != message.Role || got.Content != message.Content {
+			t.Errorf("messages[%d] = %+v, want %+v", i+1, got, message)
+		}
+	}
+}
+
+func TestOpenAICoachWithoutAPIKey(t *testing.T) {
+	server, received := newChatStub(t, http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"はい"}}]}`)
+	c := NewOpenAICoach(server.URL, "", "local-model", server.Client())
+
+	if _, err := c.Reply(context.Background(), testCoachContext(), testHistory()); err != nil {
+		t.Fatalf("Reply() error = %v", err)
+	}
+	// ローカルのスタブなどキーが不要なAPIにはAuthorizationを送らない
+	if received.authorization != "" {
+		t.Errorf("Authorization = %q, want none", received.authorization)
+	}
+}
+
+func TestOpenAICoachErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "server error", status: http.StatusInternalServerError, body: `{"error":"overloaded"}`},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{"error":"invalid api key"}`},
+		{name: "rate limited", status: http.StatusTooManyRequests, body: `{"error":"slow down"}`},
+		{name: "no choices", status: http.StatusOK, body: `{"choices":[]}`},
+		{name: "empty content", status: http.StatusOK, body: `{"choices":[{"message":{"role":"assistant","content":"  "}}]}`},
+		{name: "invalid json", status: http.StatusOK, body: `not json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newChatStub(t, tt.status, tt.body)
+			c := NewOpenAICoach(server.URL, "secret", "test-model", server.Client())
+
+			if _, err := c.Reply(context.Background(), testCoachContext(), testHistory()); err == nil {
+				t.Error("Reply() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestOpenAICoachTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	c := NewOpenAICoach(server.URL, "", "test-model", &http.Client{Timeout: 50 * time.Millisecond})
+
+	if _, err := c.Reply(context.Background(), testCoachContext(), testHistory()); err == nil {
+		t.Error("Reply() error = nil, want a timeout")
+	}
+}
+
+// failingCoach 常に失敗する
+type failingCoach struct{}
+
+func (c *failingCoach) Reply(ctx context.Context, coachContext *model.CoachContext, history []*model.CoachMessage) (string, error) {
+	return "", errors.New("unavailable")
+}
+
+func TestFallbackCoach(t *testing.T) {
+	rule := NewRuleBasedCoach()
+	want, err := rule.Reply(context.Background(), testCoachContext(), testHistory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("primary succeeds", func(t *testing.T) {
+		server, _ := newChatStub(t, http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"LLMの返信"}}]}`)
+		c := NewFallbackCoach(NewOpenAICoach(server.URL, "", "test-model", server.Client()), rule)
+		if reply, err := c.Reply(context.Background(), testCoachContext(), testHistory()); err != nil || reply != "LLMの返信" {
+			t.Errorf("Reply() = %q, %v, want the llm reply", reply, err)
+		}
+	})
+
+	t.Run("api responds with an error", func(t *testing.T) {
+		server, _ := newChatStub(t, http.StatusServiceUnavailable, `{"error":"unavailable"}`)
+		c := NewFallbackCoach(NewOpenAICoach(server.URL, "", "test-model", server.Client()), rule)
+		if reply, err := c.Reply(context.Background(), testCoachContext(), testHistory()); err != nil || reply != want {
+			t.Errorf("Reply() = %q, %v, want the rule based reply %q", reply, err, want)
+		}
+	})
+
+	t.Run("api is unreachable", func(t *testing.T) {
+		server, _ := newChatStub(t, http.StatusOK, "")
+		server.Close()
+		c := NewFallbackCoach(NewOpenAICoach(server.URL, "", "test-model", server.Client()), rule)
+		if reply, err := c.Reply(context.Background(), testCoachContext(), testHistory()); err != nil || reply != want {
+			t.Errorf("Reply() = %q, %v, want the rule based reply %q", reply, err, want)
+		}
+	})
+
+	t.Run("fallback also fails", func(t *testing.T) {
+		c := NewFallbackCoach(&failingCoach{}, &failingCoach{})
+		if _, err := c.Reply(context.Background(), testCoachContext(), testHistory()); err == nil {
+			t.Error("Reply() error = nil, want an error")
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package coachimpl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/coach"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+const (
+	// endOfDayHour 残りの量を割り振る1日の終わりの時刻
+	endOfDayHour = 22
+	// inactiveMinutes この時間以上飲んでいない場合は今すぐ飲むように勧める
+	inactiveMinutes = 120
+	// longGapMinutes 普段この時間以上飲まない時間帯がある場合はリマインダーを勧める
+	longGapMinutes = 240
+	nowLayout      = "2006-01-02 15:04"
+)
+
+// topic 助言の話題と、ユーザーのメッセージでその話題を指す語
+type topic struct {
+	name     string
+	keywords []string
+}
+
+var topics = []topic{
+	{"progress", []string{"目標", "あと", "今日", "goal", "today", "left"}},
+	{"inactivity", []string{"最後", "いつ", "last"}},
+	{"caffeine", []string{"カフェイン", "コーヒー", "caffeine", "coffee"}},
+	{"alcohol", []string{"アルコール", "お酒", "ビール", "alcohol", "beer"}},
+	{"streak", []string{"連続", "記録", "streak"}},
+	{"week", []string{"週", "最近", "week", "recent"}},
+	{"pattern", []string{"習慣", "傾向", "リマインダー", "pattern", "habit", "reminder"}},
+}
+
+type ruleBasedCoach struct{}
+
+// NewRuleBasedCoach 外部のサービスを使わずに、規則に従って助言する
+func NewRuleBasedCoach() coach.Coach {
+	return &ruleBasedCoach{}
+}
+
+func (c *ruleBasedCoach) Reply(ctx context.Context, coachContext *model.CoachContext, history []*model.CoachMessage) (string, error) {
+	advice := map[string]string{}
+	if line := progressAdvice(coachContext); line != "" {
+		advice["progress"] = line
+	}
+	if line := inactivityAdvice(coachContext); line != "" {
+		advice["inactivity"] = line
+	}
+	if today := coachContext.Today; today != nil {
+		if today.CaffeineWarning {
+			advice["caffeine"] = fmt.Sprintf("カフェインが1日の上限(%dmg)を超えています。ここからは水や麦茶にしましょう。", today.CaffeineLimitMg)
+		}
+		if today.AlcoholWarning {
+			advice["alcohol"] = "アルコールには利尿作用があります。飲んだお酒と同じくらいの量の水を飲みましょう。"
+		}
+	}
+	if line := streakAdvice(coachContext.Streaks); line != "" {
+		advice["streak"] = line
+	}
+	if line := weekAdvice(coachContext.RecentDays); line != "" {
+		advice["week"] = line
+	}
+	if pattern := coachContext.Pattern; pattern != nil && pattern.TypicalLongestGapMinutes != nil && *pattern.TypicalLongestGapMinutes >= longGapMinutes {
+		advice["pattern"] = fmt.Sprintf("普段は1日のうち%d時間ほど何も飲まない時間があります。その時間帯にリマインダーを設定すると続けやすくなります。", *pattern.TypicalLongestGapMinutes/60)
+	}
+
+	// ユーザーが尋ねた話題の助言を先頭にする
+	var question string
+	if len(history) > 0 {
+		question = strings.ToLower(history[len(history)-1].Content)
+	}
+	var lines []string
+	for _, t := range topics {
+		if line, ok := advice[t.name]; ok && mentions(question, t.keywords) {
+			lines = append(lines, line)
+			delete(advice, t.name)
+		}
+	}
+	for _, t := range topics {
+		if line, ok := advice[t.name]; ok {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) == 0 {
+		return "今のところ順調です。この調子でこまめに飲みましょう。", nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func progressAdvice(coachContext *model.CoachContext) string {
+	if coachContext.Goal <= 0 {
+		return ""
+	}
+
+	var volume int64
+	if coachContext.Today != nil {
+		volume = coachContext.Today.EffectiveVolume
+	}
+	if volume >= coachContext.Goal {
+		return fmt.Sprintf("今日の目標(%dml)を達成しています。", coachContext.Goal)
+	}
+
+	remaining := coachContext.Goal - volume
+	now, err := time.Parse(nowLayout, coachContext.Now)
+	if err != nil || now.Hour() >= endOfDayHour-1 {
+		return fmt.Sprintf("今日の目標まであと%dmlです。", remaining)
+	}
+
+	hours := int64(endOfDayHour - now.Hour())
+	return fmt.Sprintf("今日の目標まであと%dmlです。%d時までに1時間あたり約%dmlを目安に飲みましょう。", remaining, endOfDayHour, (remaining+hours-1)/hours)
+}
+
+func inactivityAdvice(coachContext *model.CoachContext) string {
+	if coachContext.MinutesSinceLastDrink == nil {
+		return "まだ記録がありません。まずはコップ1杯(200ml)から始めましょう。"
+	}
+	if *coachContext.MinutesSinceLastDrink >= inactiveMinutes {
+		return fmt.Sprintf("最後に飲んでから%d時間以上経っています。今コップ1杯飲みましょう。", *coachContext.MinutesSinceLastDrink/60)
+	}
+	return ""
+}
+
+func streakAdvice(streaks *model.StreakStats) string {
+	switch {
+	case streaks == nil:
+		return ""
+	case streaks.CurrentStreak >= 2:
+		return fmt.Sprintf("%d日連続で目標を達成しています。記録を伸ばしましょう。", streaks.CurrentStreak)
+	case streaks.CurrentStreak == 0 && streaks.LongestStreak > 0:
+		return fmt.Sprintf("これまでの最長記録は%d日連続です。今日からまた始めましょう。", streaks.LongestStreak)
+	}
+	return ""
+}
+
+// weekAdvice 今日を除いた直近の日のうち、半分以上で目標に届いていない場合に伝える
+func weekAdvice(days []*model.DailySummary) string {
+	if len(days) < 2 {
+		return ""
+	}
+
+	past := days[:len(days)-1]
+	var met int
+	for _, day := range past {
+		if day.Goal > 0 && day.EffectiveVolume >= day.Goal {
+			met++
+		}
+	}
+	if met*2 >= len(past) {
+		return ""
+	}
+	return fmt.Sprintf("直近%d日間で目標を達成できたのは%d日です。起床後と食事のたびに1杯飲む習慣をつけましょう。", len(past), met)
+}
+
+func mentions(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package lineimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mikaijun/aquagent/pkg/domain/line"
+)
+
+const DefaultBaseURL = "https://api.line.me"
+
+type textMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type httpClient struct {
+	baseURL     string
+	accessToken string
+	client      *http.Client
+}
+
+// NewHTTPClient baseURLを差し替えることで、ローカルのHTTPサーバーをLINEの代わりにできる
+func NewHTTPClient(baseURL, accessToken string, client *http.Client) line.Client {
+	return &httpClient{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		accessToken: accessToken,
+		client:      client,
+	}
+}
+
+func (c *httpClient) Reply(ctx context.Context, replyToken string, messages ...string) error {
+	return c.post(ctx, "/v2/bot/message/reply", map[string]interface{}{
+		"replyToken": replyToken,
+		"messages":   textMessages(messages),
+	})
+}
+
+func (c *httpClient) Push(ctx context.Context, lineUserId string, messages ...string) error {
+	return c.post(ctx, "/v2/bot/message/push", map[string]interface{}{
+		"to":       lineUserId,
+		"messages": textMessages(messages),
+	})
+}
+
+func (c *httpClient) post(ctx context.Context, path string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("line api responded with status %d: %s", resp.StatusCode, detail)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func textMessages(messages []string) []textMessage {
+	var result []textMessage
+	for _, m := range messages {
+		result = append(result, textMessage{Type: "text", Text: m})
+	}
+	return result
+}
@@ -0,0 +1,25 @@
+package lineimpl
+
+import (
+	"context"
+	"log"
+
+	"github.com/mikaijun/aquagent/pkg/domain/line"
+)
+
+type logClient struct{}
+
+// NewLogClient LINEに送らずにログに出力する。アクセストークンが設定されていない環境で使う
+func NewLogClient() line.Client {
+	return &logClient{}
+}
+
+func (c *logClient) Reply(ctx context.Context, replyToken string, messages ...string) error {
+	log.Printf("line reply (%d messages) was not sent: access token is not configured", len(messages))
+	return nil
+}
+
+func (c *logClient) Push(ctx context.Context, lineUserId string, messages ...string) error {
+	log.Printf("line push to %s (%d messages) was not sent: access token is not configured", lineUserId, len(messages))
+	return nil
+}
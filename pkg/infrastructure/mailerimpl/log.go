@@ -0,0 +1,21 @@
+package mailerimpl
+
+import (
+	"context"
+	"log"
+
+	"github.com/mikaijun/aquagent/pkg/domain/mailer"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+type logMailer struct{}
+
+// NewLogMailer メールをログに出力する。SMTPサーバーが設定されていない環境で使う
+func NewLogMailer() mailer.Mailer {
+	return &logMailer{}
+}
+
+func (m *logMailer) Send(ctx context.Context, mail *model.Mail) error {
+	log.Printf("mail to %s: %s\n%s", mail.To, mail.Subject, mail.Body)
+	return nil
+}
@@ -0,0 +1,123 @@
+package mailerimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/mailer"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+// base64LineLength RFC 2045で決められた1行の最大文字数
+const base64LineLength = 76
+
+type smtpMailer struct {
+	host     string
+	addr     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer サーバーがSTARTTLSに対応している場合は暗号化して送る
+// usernameが空の場合は認証しないため、ローカルのSMTPサーバーにも送れる
+func NewSMTPMailer(host, port, username, password, from string) mailer.Mailer {
+	return &smtpMailer{
+		host:     host,
+		addr:     net.JoinHostPort(host, port),
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, mail *model.Mail) error {
+	from, err := netmail.ParseAddress(m.from)
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	to, err := netmail.ParseAddress(mail.To)
+	if err != nil {
+		return fmt.Errorf("invalid to address: %w", err)
+	}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support AUTH")
+		}
+		// PlainAuthはTLSでない接続ではlocalhost以外に認証情報を送らない
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMessage(m.from, mail, time.Now())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// buildMessage 日本語を含むため、件名はMIMEエンコードし、本文はBase64で送る
+func buildMessage(from string, mail *model.Mail, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", mail.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: base64\r\n")
+	b.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(mail.Body))
+	for len(encoded) > base64LineLength {
+		b.WriteString(encoded[:base64LineLength] + "\r\n")
+		encoded = encoded[base64LineLength:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return b.Bytes()
+}
@@ -0,0 +1,200 @@
+package mailerimpl
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/util/mailtemplate"
+)
+
+// sinkMessage smtpSinkが受け取ったメール
+type sinkMessage struct {
+	from string
+	to   []string
+	data []byte
+}
+
+// smtpSink 受け取ったメールを保持するだけのローカルのSMTPサーバー。STARTTLSとAUTHには対応しない
+type smtpSink struct {
+	listener net.Listener
+	messages chan *sinkMessage
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &smtpSink{listener: listener, messages: make(chan *sinkMessage, 10)}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	return sink
+}
+
+func (s *smtpSink) hostPort() (string, string) {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return host, port
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 localhost ESMTP sink")
+
+	message := &sinkMessage{}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 8BITMIME")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			// BODY=8BITMIMEなどのパラメーターは取り除く
+			message.from = strings.Trim(strings.Fields(line[len("MAIL FROM:"):])[0], "<>")
+			tp.PrintfLine("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			message.to = append(message.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			tp.PrintfLine("250 OK")
+		case command == "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			message.data = data
+			s.messages <- message
+			message = &sinkMessage{}
+			tp.PrintfLine("250 OK")
+		case command == "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (s *smtpSink) receive(t *testing.T) *sinkMessage {
+	t.Helper()
+	select {
+	case message := <-s.messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("smtp sink received no message")
+		return nil
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	sink := newSMTPSink(t)
+	host, port := sink.hostPort()
+	m := NewSMTPMailer(host, port, "", "", "Aquagent <no-reply@example.com>")
+
+	// 再設定のURLのトークンが改行やエンコードで壊れずに届くか確認する
+	url := "https://app.example.com/reset?token=" + strings.Repeat("Ab0-_", 9)
+	subject, body, err := mailtemplate.Render(mailtemplate.PasswordReset, "ja", &mailtemplate.PasswordResetData{
+		Username:         "みかい",
+		URL:              url,
+		ExpiresInMinutes: 60,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = m.Send(ctx, &model.Mail{To: "user@example.com", Subject: subject, Body: body})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	received := sink.receive(t)
+	if received.from != "no-reply@example.com" {
+		t.Errorf("MAIL FROM = %q, want no-reply@example.com", received.from)
+	}
+	if len(received.to) != 1 || received.to[0] != "user@example.com" {
+		t.Errorf("RCPT TO = %v, want [user@example.com]", received.to)
+	}
+
+	msg, err := netmail.ReadMessage(bufio.NewReader(strings.NewReader(string(received.data))))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	gotSubject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || gotSubject != subject {
+		t.Errorf("Subject = %q (%v), want %q", gotSubject, err, subject)
+	}
+	if got := msg.Header.Get("Content-Transfer-Encoding"); got != "base64" {
+		t.Errorf("Content-Transfer-Encoding = %q, want base64", got)
+	}
+
+	encoded, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ReadDotBytesで改行はLFに変換されている
+	for _, line := range strings.Split(strings.TrimRight(string(encoded), "\n"), "\n") {
+		if len(line) > base64LineLength {
+			t.Errorf("body line has %d characters, want at most %d", len(line), base64LineLength)
+		}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\n", ""))
+	if err != nil {
+		t.Fatalf("body is not base64: %v", err)
+	}
+	if string(decoded) != body {
+		t.Errorf("body = %q, want %q", decoded, body)
+	}
+	if !strings.Contains(string(decoded), url) {
+		t.Errorf("body does not contain the reset url %s", url)
+	}
+}
+
+func TestSMTPMailerRequiresAuth(t *testing.T) {
+	sink := newSMTPSink(t)
+	host, port := sink.hostPort()
+	// 認証情報を設定している場合に、AUTHに対応していないサーバーには送らない
+	m := NewSMTPMailer(host, port, "user", "password", "no-reply@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Send(ctx, &model.Mail{To: "user@example.com", Subject: "件名", Body: "本文"}); err == nil {
+		t.Fatalf("Send() error = nil, want AUTH is not supported")
+	}
+	select {
+	case <-sink.messages:
+		t.Fatalf("smtp sink received a message without auth")
+	default:
+	}
+}
+
+func TestSMTPMailerInvalidAddress(t *testing.T) {
+	m := NewSMTPMailer("127.0.0.1", "1", "", "", "no-reply@example.com")
+	if err := m.Send(context.Background(), &model.Mail{To: "not an address", Subject: "件名", Body: "本文"}); err == nil {
+		t.Fatalf("Send() error = nil, want invalid to address")
+	}
+}
@@ -0,0 +1,41 @@
+package notifierimpl
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+// NewPushHTTPClient プッシュサービスに送るためのクライアント
+// endpointはユーザーが登録するため、名前解決した結果が内部のアドレスの場合は接続しない
+func NewPushHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !util.IsPublicIP(ip) {
+				return fmt.Errorf("push endpoint resolved to a non-public address %s", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// リダイレクト先には送らず、失敗した応答として扱う
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
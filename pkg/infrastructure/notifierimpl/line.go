@@ -0,0 +1,36 @@
+package notifierimpl
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mikaijun/aquagent/pkg/domain/line"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/notifier"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+type lineNotifier struct {
+	repository repository.LineRepository
+	client     line.Client
+}
+
+// NewLineNotifier LINEと連携しているユーザーにプッシュメッセージで通知する
+func NewLineNotifier(lineRepo repository.LineRepository, client line.Client) notifier.Notifier {
+	return &lineNotifier{
+		repository: lineRepo,
+		client:     client,
+	}
+}
+
+func (n *lineNotifier) Notify(ctx context.Context, notification *model.Notification) error {
+	account, err := n.repository.GetAccountByUserID(ctx, notification.UserID)
+	if errors.Is(err, repository.ErrLineAccountNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return n.client.Push(ctx, account.LineUserID, notification.Title+"\n"+notification.Body)
+}
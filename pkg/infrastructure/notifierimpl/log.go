@@ -0,0 +1,21 @@
+package notifierimpl
+
+import (
+	"context"
+	"log"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/notifier"
+)
+
+type logNotifier struct{}
+
+// NewLogNotifier 通知をログに出力する。配信先が設定されていない環境で使う
+func NewLogNotifier() notifier.Notifier {
+	return &logNotifier{}
+}
+
+func (n *logNotifier) Notify(ctx context.Context, notification *model.Notification) error {
+	log.Printf("notify user %d (%s): %s %s", notification.UserID, notification.Kind, notification.Title, notification.Body)
+	return nil
+}
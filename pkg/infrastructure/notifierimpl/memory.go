@@ -0,0 +1,44 @@
+package notifierimpl
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/notifier"
+)
+
+// MemoryNotifier 通知を送らずにメモリに保持する。テストや動作確認で使う
+type MemoryNotifier struct {
+	mu            sync.Mutex
+	notifications []*model.Notification
+}
+
+var _ notifier.Notifier = (*MemoryNotifier)(nil)
+
+func NewMemoryNotifier() *MemoryNotifier {
+	return &MemoryNotifier{}
+}
+
+func (n *MemoryNotifier) Notify(ctx context.Context, notification *model.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+// Notifications これまでに受け取った通知を古い順に返す
+func (n *MemoryNotifier) Notifications() []*model.Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]*model.Notification(nil), n.notifications...)
+}
+
+func (n *MemoryNotifier) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifications = nil
+}
@@ -0,0 +1,28 @@
+package notifierimpl
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/notifier"
+)
+
+type multiNotifier struct {
+	notifiers []notifier.Notifier
+}
+
+// NewMultiNotifier すべてのnotifiersに配信する。1つが失敗しても残りには配信する
+func NewMultiNotifier(notifiers ...notifier.Notifier) notifier.Notifier {
+	return &multiNotifier{notifiers: notifiers}
+}
+
+func (n *multiNotifier) Notify(ctx context.Context, notification *model.Notification) error {
+	var errs []error
+	for _, nt := range n.notifiers {
+		if err := nt.Notify(ctx, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
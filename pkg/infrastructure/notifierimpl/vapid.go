@@ -0,0 +1,121 @@
+package notifierimpl
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// VAPIDKeys Web Pushの送信元を証明するP-256の鍵(RFC 8292)
+type VAPIDKeys struct {
+	// PublicKey 非圧縮形式の公開鍵をbase64url(パディングなし)にしたもの。クライアントのapplicationServerKeyに使う
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"`
+
+	signer *ecdsa.PrivateKey
+}
+
+// LoadVAPIDKeys 環境変数 VAPID_PUBLIC_KEY, VAPID_PRIVATE_KEY があればそれを使う
+// なければ VAPID_KEY_FILE から読み込み、ファイルがなければ鍵を生成して保存する
+// どちらも設定されていない場合はnilを返し、Web Pushは無効になる
+func LoadVAPIDKeys() (*VAPIDKeys, error) {
+	if public, private := os.Getenv("VAPID_PUBLIC_KEY"), os.Getenv("VAPID_PRIVATE_KEY"); public != "" || private != "" {
+		return parseVAPIDKeys(public, private)
+	}
+
+	path := os.Getenv("VAPID_KEY_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		keys, err := GenerateVAPIDKeys()
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(keys)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return nil, err
+		}
+		return keys, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &VAPIDKeys{}
+	if err := json.Unmarshal(data, stored); err != nil {
+		return nil, fmt.Errorf("invalid vapid key file: %w", err)
+	}
+	return parseVAPIDKeys(stored.PublicKey, stored.PrivateKey)
+}
+
+func GenerateVAPIDKeys() (*VAPIDKeys, error) {
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return parseVAPIDKeys(
+		base64.RawURLEncoding.EncodeToString(private.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(private.Bytes()),
+	)
+}
+
+func parseVAPIDKeys(public, private string) (*VAPIDKeys, error) {
+	privateBytes, err := base64.RawURLEncoding.DecodeString(private)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vapid private key: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(privateBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vapid private key: %w", err)
+	}
+
+	// 公開鍵は秘密鍵から導出し、設定値と食い違っていないか確認する
+	publicBytes := key.PublicKey().Bytes()
+	if public != base64.RawURLEncoding.EncodeToString(publicBytes) {
+		return nil, errors.New("vapid public key does not match private key")
+	}
+
+	return &VAPIDKeys{
+		PublicKey:  public,
+		PrivateKey: private,
+		signer: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(publicBytes[1:33]),
+				Y:     new(big.Int).SetBytes(publicBytes[33:65]),
+			},
+			D: new(big.Int).SetBytes(privateBytes),
+		},
+	}, nil
+}
+
+// authorization audienceはプッシュサービスのオリジン(scheme://host)
+func (k *VAPIDKeys) authorization(audience, subject string, expiresIn time.Duration) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Audience:  jwt.ClaimStrings{audience},
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+	})
+
+	signed, err := token.SignedString(k.signer)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, k.PublicKey), nil
+}
@@ -0,0 +1,206 @@
+package notifierimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/notifier"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+const (
+	// webPushTTL プッシュサービスが端末に届けられるまで保持する時間(秒)
+	webPushTTL = 60 * 60
+	// webPushRecordSize aes128gcmのレコードサイズ。通知は1レコードに収まる大きさにする
+	webPushRecordSize = 4096
+	vapidExpiresIn    = 12 * time.Hour
+)
+
+type webPushNotifier struct {
+	repository repository.PushSubscriptionRepository
+	keys       *VAPIDKeys
+	subject    string
+	client     *http.Client
+}
+
+// NewWebPushNotifier subjectはプッシュサービスからの連絡先(mailto:またはhttps:のURL)
+// clientを差し替えることで、ローカルのHTTPサーバーをプッシュサービスの代わりにできる
+func NewWebPushNotifier(
+	pushRepo repository.PushSubscriptionRepository,
+	keys *VAPIDKeys,
+	subject string,
+	client *http.Client,
+) notifier.Notifier {
+	return &webPushNotifier{
+		repository: pushRepo,
+		keys:       keys,
+		subject:    subject,
+		client:     client,
+	}
+}
+
+// Notify ユーザーが登録したすべての端末に送る。無効になった登録は削除する
+func (n *webPushNotifier) Notify(ctx context.Context, notification *model.Notification) error {
+	subscriptions, err := n.repository.GetSubscriptions(ctx, notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, subscription := range subscriptions {
+		if err := n.send(ctx, subscription, payload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *webPushNotifier) send(ctx context.Context, subscription *model.PushSubscription, payload []byte) error {
+	endpoint, err := url.Parse(subscription.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	body, err := encryptPushPayload(subscription, payload)
+	if err != nil {
+		return err
+	}
+
+	authorization, err := n.keys.authorization(endpoint.Scheme+"://"+endpoint.Host, n.subject, vapidExpiresIn)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(webPushTTL))
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		// 購読が解除されているため、以降は送らない
+		log.Printf("push subscription %d expired (status %d)", subscription.ID, resp.StatusCode)
+		return n.repository.DeleteSubscriptionByEndpoint(ctx, subscription.Endpoint)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("push service responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// encryptPushPayload RFC 8291に従ってpayloadを暗号化し、aes128gcm(RFC 8188)形式のbodyを返す
+func encryptPushPayload(subscription *model.PushSubscription, payload []byte) ([]byte, error) {
+	uaPublicBytes, err := base64.RawURLEncoding.DecodeString(subscription.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(subscription.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth: %w", err)
+	}
+
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh: %w", err)
+	}
+
+	// 送信ごとに使い捨ての鍵とsaltを使う
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublicBytes...)
+	keyInfo = append(keyInfo, asPublicBytes...)
+	ikm, err := hkdfExpand(ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	cek, err := hkdfExpand(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfExpand(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	// 最後のレコードであることを示す区切り文字(0x02)を付ける。パディングは付けない
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	if len(plaintext)+16 > webPushRecordSize {
+		return nil, errors.New("push payload is too large")
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// ヘッダー: salt(16) || rs(4) || idlen(1) || keyid(送信側の公開鍵)
+	header := make([]byte, 0, 16+4+1+len(asPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(asPublicBytes)))
+	header = append(header, asPublicBytes...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+func hkdfExpand(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,278 @@
+package notifierimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+type fakePushSubscriptionRepository struct {
+	repository.PushSubscriptionRepository
+	subscriptions []*model.PushSubscription
+}
+
+func (r *fakePushSubscriptionRepository) GetSubscriptions(ctx context.Context, userId int64) ([]*model.PushSubscription, error) {
+	var subscriptions []*model.PushSubscription = []*model.PushSubscription{}
+	for _, subscription := range r.subscriptions {
+		if subscription.UserID == userId {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakePushSubscriptionRepository) DeleteSubscriptionByEndpoint(ctx context.Context, endpoint string) error {
+	var subscriptions []*model.PushSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.Endpoint != endpoint {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	r.subscriptions = subscriptions
+	return nil
+}
+
+// userAgent ブラウザの代わりに購読の鍵を持ち、届いたbodyを復号する
+type userAgent struct {
+	private *ecdh.PrivateKey
+	auth    []byte
+}
+
+func newUserAgent(t *testing.T) *userAgent {
+	t.Helper()
+	private, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	if _, err := rand.Read(auth); err != nil {
+		t.Fatal(err)
+	}
+	return &userAgent{private: private, auth: auth}
+}
+
+func (ua *userAgent) subscription(userId int64, endpoint string) *model.PushSubscription {
+	return &model.PushSubscription{
+		UserID:   userId,
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(ua.private.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(ua.auth),
+	}
+}
+
+// decrypt RFC 8291の受信側の手順でaes128gcmのbodyを復号する
+func (ua *userAgent) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	if len(body) < 21 {
+		t.Fatalf("body is too short: %d bytes", len(body))
+	}
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != webPushRecordSize {
+		t.Fatalf("record size = %d, want %d", rs, webPushRecordSize)
+	}
+	idlen := int(body[20])
+	asPublicBytes := body[21 : 21+idlen]
+	ciphertext := body[21+idlen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		t.Fatalf("keyid is not a P-256 public key: %v", err)
+	}
+	ecdhSecret, err := ua.private.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expand := func(secret, salt []byte, info string, length int) []byte {
+		out := make([]byte, length)
+		if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	keyInfo := "WebPush: info\x00" + string(ua.private.PublicKey().Bytes()) + string(asPublicBytes)
+	ikm := expand(ecdhSecret, ua.auth, keyInfo, 32)
+	cek := expand(ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce := expand(ikm, salt, "Content-Encoding: nonce\x00", 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+
+	// 最後のレコードの区切り文字(0x02)の後ろはパディング
+	end := bytes.LastIndexByte(plaintext, 0x02)
+	if end < 0 {
+		t.Fatalf("plaintext has no last record delimiter")
+	}
+	return plaintext[:end]
+}
+
+func TestEncryptPushPayload(t *testing.T) {
+	ua := newUserAgent(t)
+	payload := []byte(`{"title":"水分補給","body":"コップ1杯の水を飲みましょう"}`)
+
+	body, err := encryptPushPayload(ua.subscription(1, "https://push.example.com/1"), payload)
+	if err != nil {
+		t.Fatalf("encryptPushPayload() error = %v", err)
+	}
+	if got := ua.decrypt(t, body); !bytes.Equal(got, payload) {
+		t.Fatalf("decrypted = %q, want %q", got, payload)
+	}
+
+	// 送信ごとに鍵とsaltを変える
+	again, err := encryptPushPayload(ua.subscription(1, "https://push.example.com/1"), payload)
+	if err != nil {
+		t.Fatalf("encryptPushPayload() error = %v", err)
+	}
+	if bytes.Equal(body[:16], again[:16]) || bytes.Equal(body[21:86], again[21:86]) {
+		t.Fatalf("salt or sender key was reused")
+	}
+}
+
+func TestEncryptPushPayloadTooLarge(t *testing.T) {
+	ua := newUserAgent(t)
+	payload := bytes.Repeat([]byte("a"), webPushRecordSize)
+
+	if _, err := encryptPushPayload(ua.subscription(1, "https://push.example.com/1"), payload); err == nil {
+		t.Fatalf("encryptPushPayload() error = nil, want too large")
+	}
+}
+
+func TestWebPushNotifierNotify(t *testing.T) {
+	keys, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		status      int
+		wantErr     bool
+		wantDeleted bool
+	}{
+		{name: "created", status: http.StatusCreated},
+		{name: "not found", status: http.StatusNotFound, wantDeleted: true},
+		{name: "gone", status: http.StatusGone, wantDeleted: true},
+		{name: "server error", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ua := newUserAgent(t)
+			var received *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			pushRepo := &fakePushSubscriptionRepository{subscriptions: []*model.PushSubscription{
+				ua.subscription(1, server.URL+"/push/1"),
+			}}
+			n := NewWebPushNotifier(pushRepo, keys, "mailto:admin@example.com", server.Client())
+
+			notification := &model.Notification{UserID: 1, Kind: "reminder", Title: "水分補給", Body: "水を飲みましょう"}
+			err := n.Notify(context.Background(), notification)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if deleted := len(pushRepo.subscriptions) == 0; deleted != tt.wantDeleted {
+				t.Fatalf("subscription deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+
+			if received == nil {
+				t.Fatalf("push service received no request")
+			}
+			if got := received.Header.Get("Content-Encoding"); got != "aes128gcm" {
+				t.Fatalf("Content-Encoding = %q, want aes128gcm", got)
+			}
+			got := &model.Notification{}
+			if err := json.Unmarshal(ua.decrypt(t, body), got); err != nil {
+				t.Fatalf("payload is not a notification: %v", err)
+			}
+			if *got != *notification {
+				t.Fatalf("payload = %+v, want %+v", got, notification)
+			}
+			verifyVAPID(t, received.Header.Get("Authorization"), keys, server.URL)
+		})
+	}
+}
+
+// verifyVAPID Authorizationヘッダーがkeysで署名され、audienceがプッシュサービスのオリジンになっているか確認する
+func verifyVAPID(t *testing.T, authorization string, keys *VAPIDKeys, audience string) {
+	t.Helper()
+	var token, key string
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, "vapid "), ", ") {
+		switch {
+		case strings.HasPrefix(part, "t="):
+			token = strings.TrimPrefix(part, "t=")
+		case strings.HasPrefix(part, "k="):
+			key = strings.TrimPrefix(part, "k=")
+		}
+	}
+	if key != keys.PublicKey {
+		t.Fatalf("k = %q, want %q", key, keys.PublicKey)
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return &ecdsa.PublicKey{Curve: keys.signer.Curve, X: keys.signer.X, Y: keys.signer.Y}, nil
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	if err != nil {
+		t.Fatalf("invalid vapid token: %v", err)
+	}
+	if !claims.VerifyAudience(audience, true) {
+		t.Fatalf("aud = %v, want %s", claims.Audience, audience)
+	}
+	if claims.ExpiresAt == nil || claims.ExpiresAt.After(time.Now().Add(24*time.Hour)) {
+		t.Fatalf("exp = %v, must be within 24 hours", claims.ExpiresAt)
+	}
+}
+
+func TestPushHTTPClientRefusesPrivateAddress(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	resp, err := NewPushHTTPClient(time.Second).Post(server.URL, "application/octet-stream", nil)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("request to %s succeeded, want refused", server.URL)
+	}
+	if requested {
+		t.Fatalf("request reached the loopback server")
+	}
+}
@@ -0,0 +1,89 @@
+package repositoryimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+type achievementRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewAchievementRepositoryImpl(db infrastructure.DBTX) repository.AchievementRepository {
+	return &achievementRepositoryImpl{db: db}
+}
+
+func (ri *achievementRepositoryImpl) GetAchievements(ctx context.Context, userId int64) ([]*model.UserAchievement, error) {
+	var achievements []*model.UserAchievement = []*model.UserAchievement{}
+	query := "SELECT id, user_id, badge_key, earned_at FROM user_achievements WHERE user_id = $1 ORDER BY earned_at"
+
+	rows, err := ri.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var earnedAt time.Time
+		achievement := &model.UserAchievement{}
+		err := rows.Scan(
+			&achievement.ID,
+			&achievement.UserID,
+			&achievement.BadgeKey,
+			&earnedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		achievement.EarnedAt = earnedAt.Format("2006-01-02 15:04:05")
+		achievements = append(achievements, achievement)
+	}
+	return achievements, rows.Err()
+}
+
+func (ri *achievementRepositoryImpl) CreateAchievement(ctx context.Context, achievement *model.UserAchievement) error {
+	query := "INSERT INTO user_achievements (user_id, badge_key) VALUES ($1, $2) ON CONFLICT (user_id, badge_key) DO NOTHING"
+	_, err := ri.db.ExecContext(ctx, query, achievement.UserID, achievement.BadgeKey)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ri *achievementRepositoryImpl) ScheduleEvaluation(ctx context.Context, userId int64) error {
+	query := `INSERT INTO achievement_evaluations (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET version = achievement_evaluations.version + 1`
+	_, err := ri.db.ExecContext(ctx, query, userId)
+	return err
+}
+
+func (ri *achievementRepositoryImpl) GetPendingEvaluations(ctx context.Context, limit int64) ([]*model.AchievementEvaluation, error) {
+	var evaluations []*model.AchievementEvaluation = []*model.AchievementEvaluation{}
+	query := "SELECT user_id, version FROM achievement_evaluations ORDER BY scheduled_at LIMIT $1"
+
+	rows, err := ri.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		evaluation := &model.AchievementEvaluation{}
+		if err := rows.Scan(&evaluation.UserID, &evaluation.Version); err != nil {
+			return nil, err
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	return evaluations, rows.Err()
+}
+
+func (ri *achievementRepositoryImpl) CompleteEvaluation(ctx context.Context, evaluation *model.AchievementEvaluation) error {
+	query := "DELETE FROM achievement_evaluations WHERE user_id = $1 AND version = $2"
+	_, err := ri.db.ExecContext(ctx, query, evaluation.UserID, evaluation.Version)
+	return err
+}
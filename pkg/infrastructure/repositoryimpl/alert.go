@@ -0,0 +1,208 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+const (
+	alertSettingsColumns = `user_id, enabled, max_hours_without_drink, daily_minimum, daily_minimum_deadline_hour,
+		active_start_hour, active_end_hour`
+	alertColumns = "id, user_id, kind, message, alert_date, created_at, acknowledged_at"
+)
+
+type alertRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewAlertRepositoryImpl(db infrastructure.DBTX) repository.AlertRepository {
+	return &alertRepositoryImpl{db: db}
+}
+
+func (ri *alertRepositoryImpl) GetSettings(ctx context.Context, userId int64) (*model.AlertSettings, error) {
+	query := "SELECT " + alertSettingsColumns + " FROM alert_settings WHERE user_id = $1"
+	settings, err := scanAlertSettings(ri.db.QueryRowContext(ctx, query, userId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.AlertSettings{}, repository.ErrAlertSettingsNotFound
+	}
+	if err != nil {
+		return &model.AlertSettings{}, err
+	}
+
+	return settings, nil
+}
+
+func (ri *alertRepositoryImpl) UpsertSettings(ctx context.Context, settings *model.AlertSettings) (*model.AlertSettings, error) {
+	query := `
+		INSERT INTO alert_settings (` + alertSettingsColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET
+			enabled = EXCLUDED.enabled,
+			max_hours_without_drink = EXCLUDED.max_hours_without_drink,
+			daily_minimum = EXCLUDED.daily_minimum,
+			daily_minimum_deadline_hour = EXCLUDED.daily_minimum_deadline_hour,
+			active_start_hour = EXCLUDED.active_start_hour,
+			active_end_hour = EXCLUDED.active_end_hour`
+	_, err := ri.db.ExecContext(
+		ctx,
+		query,
+		settings.UserID,
+		settings.Enabled,
+		settings.MaxHoursWithoutDrink,
+		settings.DailyMinimum,
+		settings.DailyMinimumDeadlineHour,
+		settings.ActiveStartHour,
+		settings.ActiveEndHour,
+	)
+	if err != nil {
+		return &model.AlertSettings{}, err
+	}
+
+	return settings, nil
+}
+
+func (ri *alertRepositoryImpl) GetEnabledSettings(ctx context.Context) ([]*model.AlertSettings, error) {
+	var settingsList []*model.AlertSettings = []*model.AlertSettings{}
+	query := "SELECT " + alertSettingsColumns + " FROM alert_settings WHERE enabled ORDER BY user_id"
+
+	rows, err := ri.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		settings, err := scanAlertSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		settingsList = append(settingsList, settings)
+	}
+	return settingsList, rows.Err()
+}
+
+func (ri *alertRepositoryImpl) CreateAlert(ctx context.Context, alert *model.Alert) (*model.Alert, error) {
+	query := "INSERT INTO alerts (user_id, kind, message, alert_date, created_at) VALUES ($1, $2, $3, $4, $5) returning id"
+	err := ri.db.QueryRowContext(
+		ctx,
+		query,
+		alert.UserID,
+		alert.Kind,
+		alert.Message,
+		alert.AlertDate,
+		alert.CreatedAt,
+	).Scan(&alert.ID)
+	if err != nil {
+		return &model.Alert{}, err
+	}
+
+	return alert, nil
+}
+
+func (ri *alertRepositoryImpl) GetAlerts(ctx context.Context, userId int64, onlyOpen bool) ([]*model.Alert, error) {
+	var alerts []*model.Alert = []*model.Alert{}
+	query := "SELECT " + alertColumns + " FROM alerts WHERE user_id = $1"
+	if onlyOpen {
+		query += " AND acknowledged_at IS NULL"
+	}
+	query += " ORDER BY created_at DESC"
+
+	rows, err := ri.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+func (ri *alertRepositoryImpl) GetAlert(ctx context.Context, alertId int64) (*model.Alert, error) {
+	query := "SELECT " + alertColumns + " FROM alerts WHERE id = $1"
+	alert, err := scanAlert(ri.db.QueryRowContext(ctx, query, alertId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.Alert{}, repository.ErrAlertNotFound
+	}
+	if err != nil {
+		return &model.Alert{}, err
+	}
+
+	return alert, nil
+}
+
+func (ri *alertRepositoryImpl) GetLatestAlert(ctx context.Context, userId int64, kind string) (*model.Alert, error) {
+	query := "SELECT " + alertColumns + " FROM alerts WHERE user_id = $1 AND kind = $2 ORDER BY created_at DESC LIMIT 1"
+	alert, err := scanAlert(ri.db.QueryRowContext(ctx, query, userId, kind))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.Alert{}, repository.ErrAlertNotFound
+	}
+	if err != nil {
+		return &model.Alert{}, err
+	}
+
+	return alert, nil
+}
+
+func (ri *alertRepositoryImpl) AcknowledgeAlert(ctx context.Context, alertId int64) error {
+	query := "UPDATE alerts SET acknowledged_at = $1 WHERE id = $2 AND acknowledged_at IS NULL"
+	_, err := ri.db.ExecContext(ctx, query, time.Now().UTC().Format("2006-01-02 15:04:05"), alertId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func scanAlertSettings(row rowScanner) (*model.AlertSettings, error) {
+	settings := &model.AlertSettings{}
+	err := row.Scan(
+		&settings.UserID,
+		&settings.Enabled,
+		&settings.MaxHoursWithoutDrink,
+		&settings.DailyMinimum,
+		&settings.DailyMinimumDeadlineHour,
+		&settings.ActiveStartHour,
+		&settings.ActiveEndHour,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func scanAlert(row rowScanner) (*model.Alert, error) {
+	var alertDate, createdAt time.Time
+	var acknowledgedAt sql.NullTime
+	alert := &model.Alert{}
+	err := row.Scan(
+		&alert.ID,
+		&alert.UserID,
+		&alert.Kind,
+		&alert.Message,
+		&alertDate,
+		&createdAt,
+		&acknowledgedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	alert.AlertDate = alertDate.Format("2006-01-02")
+	alert.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+	if acknowledgedAt.Valid {
+		formatted := acknowledgedAt.Time.Format("2006-01-02 15:04:05")
+		alert.AcknowledgedAt = &formatted
+	}
+	return alert, nil
+}
@@ -0,0 +1,109 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+const beverageColumns = "id, COALESCE(user_id, 0), COALESCE(code, ''), name, hydration_factor, caffeine_mg_per_100ml, alcohol_g_per_100ml, deleted_at IS NOT NULL"
+
+type beverageRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewBeverageRepositoryImpl(db infrastructure.DBTX) repository.BeverageRepository {
+	return &beverageRepositoryImpl{db: db}
+}
+
+func (ri *beverageRepositoryImpl) GetBeverages(ctx context.Context, userId int64) ([]*model.Beverage, error) {
+	var beverages []*model.Beverage = []*model.Beverage{}
+	query := "SELECT " + beverageColumns + " FROM beverages WHERE (user_id IS NULL OR user_id = $1) AND deleted_at IS NULL ORDER BY user_id NULLS FIRST, id"
+
+	rows, err := ri.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		beverage := &model.Beverage{}
+		err := rows.Scan(
+			&beverage.ID,
+			&beverage.UserID,
+			&beverage.Code,
+			&beverage.Name,
+			&beverage.HydrationFactor,
+			&beverage.CaffeineMgPer100ml,
+			&beverage.AlcoholGPer100ml,
+			&beverage.Deleted,
+		)
+		if err != nil {
+			return nil, err
+		}
+		beverages = append(beverages, beverage)
+	}
+	return beverages, rows.Err()
+}
+
+func (ri *beverageRepositoryImpl) GetBeverage(ctx context.Context, beverageId int64) (*model.Beverage, error) {
+	beverage := &model.Beverage{}
+	query := "SELECT " + beverageColumns + " FROM beverages WHERE id = $1"
+
+	err := ri.db.QueryRowContext(ctx, query, beverageId).Scan(
+		&beverage.ID,
+		&beverage.UserID,
+		&beverage.Code,
+		&beverage.Name,
+		&beverage.HydrationFactor,
+		&beverage.CaffeineMgPer100ml,
+		&beverage.AlcoholGPer100ml,
+		&beverage.Deleted,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.Beverage{}, repository.ErrBeverageNotFound
+	}
+	if err != nil {
+		return &model.Beverage{}, err
+	}
+
+	return beverage, nil
+}
+
+func (ri *beverageRepositoryImpl) CreateBeverage(ctx context.Context, beverage *model.Beverage) (*model.Beverage, error) {
+	query := `
+		INSERT INTO beverages (user_id, name, hydration_factor, caffeine_mg_per_100ml, alcohol_g_per_100ml)
+		VALUES ($1, $2, $3, $4, $5) returning id`
+	err := ri.db.QueryRowContext(
+		ctx,
+		query,
+		beverage.UserID,
+		beverage.Name,
+		beverage.HydrationFactor,
+		beverage.CaffeineMgPer100ml,
+		beverage.AlcoholGPer100ml,
+	).Scan(&beverage.ID)
+	if err != nil {
+		return &model.Beverage{}, err
+	}
+
+	return beverage, nil
+}
+
+func (ri *beverageRepositoryImpl) DeleteBeverage(ctx context.Context, beverageId int64, now time.Time) error {
+	return withTx(ctx, ri.db, func(tx infrastructure.DBTX) error {
+		query := "UPDATE beverages SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL"
+		if _, err := tx.ExecContext(ctx, query, beverageId, now.UTC().Format("2006-01-02 15:04:05")); err != nil {
+			return err
+		}
+		// 論理削除ではON DELETE SET NULLが働かないため、容器のプリセットから外す
+		_, err := tx.ExecContext(ctx, "UPDATE containers SET beverage_id = NULL WHERE beverage_id = $1", beverageId)
+		return err
+	})
+}
@@ -0,0 +1,76 @@
+package repositoryimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+type coachRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewCoachRepositoryImpl(db infrastructure.DBTX) repository.CoachRepository {
+	return &coachRepositoryImpl{db: db}
+}
+
+func (ri *coachRepositoryImpl) CreateMessage(ctx context.Context, message *model.CoachMessage) (*model.CoachMessage, error) {
+	query := `
+		INSERT INTO coach_messages (user_id, role, content, created_at) VALUES ($1, $2, $3, $4)
+		returning id`
+	err := ri.db.QueryRowContext(
+		ctx,
+		query,
+		message.UserID,
+		message.Role,
+		message.Content,
+		message.CreatedAt,
+	).Scan(&message.ID)
+	if err != nil {
+		return &model.CoachMessage{}, err
+	}
+
+	return message, nil
+}
+
+func (ri *coachRepositoryImpl) GetMessages(ctx context.Context, userId int64, limit int) ([]*model.CoachMessage, error) {
+	var messages []*model.CoachMessage = []*model.CoachMessage{}
+	query := `
+		SELECT id, user_id, role, content, created_at FROM (
+			SELECT * FROM coach_messages WHERE user_id = $1 ORDER BY id DESC LIMIT $2
+		) recent ORDER BY id`
+
+	rows, err := ri.db.QueryContext(ctx, query, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var createdAt time.Time
+		message := &model.CoachMessage{}
+		err := rows.Scan(
+			&message.ID,
+			&message.UserID,
+			&message.Role,
+			&message.Content,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		message.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+func (ri *coachRepositoryImpl) DeleteMessages(ctx context.Context, userId int64) error {
+	_, err := ri.db.ExecContext(ctx, "DELETE FROM coach_messages WHERE user_id = $1", userId)
+	return err
+}
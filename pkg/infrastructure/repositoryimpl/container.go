@@ -0,0 +1,125 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+const containerColumns = "id, user_id, name, volume, COALESCE(beverage_id, 0), usage_count"
+
+type containerRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewContainerRepositoryImpl(db infrastructure.DBTX) repository.ContainerRepository {
+	return &containerRepositoryImpl{db: db}
+}
+
+func (ri *containerRepositoryImpl) GetContainers(ctx context.Context, userId int64) ([]*model.Container, error) {
+	var containers []*model.Container = []*model.Container{}
+	query := "SELECT " + containerColumns + " FROM containers WHERE user_id = $1 ORDER BY usage_count DESC, id"
+
+	rows, err := ri.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		container := &model.Container{}
+		err := rows.Scan(
+			&container.ID,
+			&container.UserID,
+			&container.Name,
+			&container.Volume,
+			&container.BeverageID,
+			&container.UsageCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, container)
+	}
+	return containers, rows.Err()
+}
+
+func (ri *containerRepositoryImpl) GetContainer(ctx context.Context, containerId int64) (*model.Container, error) {
+	container := &model.Container{}
+	query := "SELECT " + containerColumns + " FROM containers WHERE id = $1"
+
+	err := ri.db.QueryRowContext(ctx, query, containerId).Scan(
+		&container.ID,
+		&container.UserID,
+		&container.Name,
+		&container.Volume,
+		&container.BeverageID,
+		&container.UsageCount,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.Container{}, repository.ErrContainerNotFound
+	}
+	if err != nil {
+		return &model.Container{}, err
+	}
+
+	return container, nil
+}
+
+func (ri *containerRepositoryImpl) CreateContainer(ctx context.Context, container *model.Container) (*model.Container, error) {
+	query := "INSERT INTO containers (user_id, name, volume, beverage_id) VALUES ($1, $2, $3, NULLIF($4, 0)) returning id"
+	err := ri.db.QueryRowContext(
+		ctx,
+		query,
+		container.UserID,
+		container.Name,
+		container.Volume,
+		container.BeverageID,
+	).Scan(&container.ID)
+	if err != nil {
+		return &model.Container{}, err
+	}
+
+	return container, nil
+}
+
+func (ri *containerRepositoryImpl) UpdateContainer(ctx context.Context, container *model.Container) (*model.Container, error) {
+	query := "UPDATE containers SET name = $1, volume = $2, beverage_id = NULLIF($3, 0) WHERE id = $4 AND user_id = $5"
+	_, err := ri.db.ExecContext(
+		ctx,
+		query,
+		container.Name,
+		container.Volume,
+		container.BeverageID,
+		container.ID,
+		container.UserID,
+	)
+	if err != nil {
+		return &model.Container{}, err
+	}
+
+	return container, nil
+}
+
+func (ri *containerRepositoryImpl) DeleteContainer(ctx context.Context, containerId int64) error {
+	query := "DELETE FROM containers WHERE id = $1"
+	_, err := ri.db.ExecContext(ctx, query, containerId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ri *containerRepositoryImpl) IncrementUsage(ctx context.Context, containerId int64) error {
+	query := "UPDATE containers SET usage_count = usage_count + 1 WHERE id = $1"
+	_, err := ri.db.ExecContext(ctx, query, containerId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
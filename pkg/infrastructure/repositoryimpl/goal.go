@@ -0,0 +1,61 @@
+package repositoryimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+type goalRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewGoalRepositoryImpl(db infrastructure.DBTX) repository.GoalRepository {
+	return &goalRepositoryImpl{db: db}
+}
+
+func (ri *goalRepositoryImpl) UpsertGoal(ctx context.Context, goal *model.Goal) (*model.Goal, error) {
+	query := `
+		INSERT INTO goals (user_id, volume, effective_date, source) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, effective_date) DO UPDATE SET volume = EXCLUDED.volume, source = EXCLUDED.source
+		returning id`
+	err := ri.db.QueryRowContext(ctx, query, goal.UserID, goal.Volume, goal.EffectiveDate, goal.Source).Scan(&goal.ID)
+	if err != nil {
+		return &model.Goal{}, err
+	}
+
+	return goal, nil
+}
+
+func (ri *goalRepositoryImpl) GetGoals(ctx context.Context, userId int64) ([]*model.Goal, error) {
+	var goals []*model.Goal = []*model.Goal{}
+	query := "SELECT id, user_id, volume, effective_date, source FROM goals WHERE user_id = $1 ORDER BY effective_date"
+
+	rows, err := ri.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var effectiveDate time.Time
+		goal := &model.Goal{}
+		err := rows.Scan(
+			&goal.ID,
+			&goal.UserID,
+			&goal.Volume,
+			&effectiveDate,
+			&goal.Source,
+		)
+		if err != nil {
+			return nil, err
+		}
+		goal.EffectiveDate = effectiveDate.Format("2006-01-02")
+		goals = append(goals, goal)
+	}
+	return goals, rows.Err()
+}
@@ -0,0 +1,101 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+type lineRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewLineRepositoryImpl(db infrastructure.DBTX) repository.LineRepository {
+	return &lineRepositoryImpl{db: db}
+}
+
+func (ri *lineRepositoryImpl) CreateLinkCode(ctx context.Context, code *model.LineLinkCode) (*model.LineLinkCode, error) {
+	// 1人のユーザーが有効なコードを複数持たないように、古いコードは消す
+	_, err := ri.db.ExecContext(ctx, "DELETE FROM line_link_codes WHERE user_id = $1", code.UserID)
+	if err != nil {
+		return &model.LineLinkCode{}, err
+	}
+
+	query := "INSERT INTO line_link_codes (code, user_id, expires_at) VALUES ($1, $2, $3)"
+	_, err = ri.db.ExecContext(ctx, query, code.Code, code.UserID, code.ExpiresAt)
+	if isUniqueViolation(err) {
+		return &model.LineLinkCode{}, repository.ErrLineLinkCodeConflict
+	}
+	if err != nil {
+		return &model.LineLinkCode{}, err
+	}
+
+	return code, nil
+}
+
+func (ri *lineRepositoryImpl) ConsumeLinkCode(ctx context.Context, code string, now time.Time) (int64, error) {
+	var userId int64
+	query := "DELETE FROM line_link_codes WHERE code = $1 AND expires_at > $2 returning user_id"
+	err := ri.db.QueryRowContext(ctx, query, code, now.UTC()).Scan(&userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, repository.ErrLineLinkCodeNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return userId, nil
+}
+
+func (ri *lineRepositoryImpl) LinkAccount(ctx context.Context, account *model.LineAccount) (*model.LineAccount, error) {
+	// 同じLINEアカウントが別のユーザーと連携していた場合は、その連携を解除する
+	_, err := ri.db.ExecContext(ctx, "DELETE FROM line_accounts WHERE line_user_id = $1 AND user_id <> $2", account.LineUserID, account.UserID)
+	if err != nil {
+		return &model.LineAccount{}, err
+	}
+
+	query := `
+		INSERT INTO line_accounts (user_id, line_user_id) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET line_user_id = EXCLUDED.line_user_id
+		returning id`
+	err = ri.db.QueryRowContext(ctx, query, account.UserID, account.LineUserID).Scan(&account.ID)
+	if err != nil {
+		return &model.LineAccount{}, err
+	}
+
+	return account, nil
+}
+
+func (ri *lineRepositoryImpl) GetAccountByUserID(ctx context.Context, userId int64) (*model.LineAccount, error) {
+	query := "SELECT id, user_id, line_user_id FROM line_accounts WHERE user_id = $1"
+	return scanLineAccount(ri.db.QueryRowContext(ctx, query, userId))
+}
+
+func (ri *lineRepositoryImpl) GetAccountByLineUserID(ctx context.Context, lineUserId string) (*model.LineAccount, error) {
+	query := "SELECT id, user_id, line_user_id FROM line_accounts WHERE line_user_id = $1"
+	return scanLineAccount(ri.db.QueryRowContext(ctx, query, lineUserId))
+}
+
+func (ri *lineRepositoryImpl) DeleteAccount(ctx context.Context, userId int64) error {
+	_, err := ri.db.ExecContext(ctx, "DELETE FROM line_accounts WHERE user_id = $1", userId)
+	return err
+}
+
+func scanLineAccount(row rowScanner) (*model.LineAccount, error) {
+	account := &model.LineAccount{}
+	err := row.Scan(&account.ID, &account.UserID, &account.LineUserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.LineAccount{}, repository.ErrLineAccountNotFound
+	}
+	if err != nil {
+		return &model.LineAccount{}, err
+	}
+
+	return account, nil
+}
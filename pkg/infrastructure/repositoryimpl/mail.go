@@ -0,0 +1,102 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+type mailRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewMailRepositoryImpl(db infrastructure.DBTX) repository.MailRepository {
+	return &mailRepositoryImpl{db: db}
+}
+
+func (ri *mailRepositoryImpl) CreateMail(ctx context.Context, mail *model.Mail) (*model.Mail, error) {
+	query := `
+		INSERT INTO mail_outbox (to_address, subject, body, status, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6) returning id`
+	err := ri.db.QueryRowContext(
+		ctx,
+		query,
+		mail.To,
+		mail.Subject,
+		mail.Body,
+		mail.Status,
+		mail.CreatedAt,
+		mail.NextAttemptAt,
+	).Scan(&mail.ID)
+	if err != nil {
+		return &model.Mail{}, err
+	}
+
+	return mail, nil
+}
+
+func (ri *mailRepositoryImpl) GetPendingMails(ctx context.Context, now time.Time, limit int64) ([]*model.Mail, error) {
+	var mails []*model.Mail = []*model.Mail{}
+	query := `
+		SELECT id, to_address, subject, body, status, attempts, last_error, created_at, next_attempt_at, sent_at
+		FROM mail_outbox
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY id
+		LIMIT $3`
+
+	rows, err := ri.db.QueryContext(ctx, query, model.MailStatusPending, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var createdAt, nextAttemptAt time.Time
+		var sentAt sql.NullTime
+		mail := &model.Mail{}
+		err := rows.Scan(
+			&mail.ID,
+			&mail.To,
+			&mail.Subject,
+			&mail.Body,
+			&mail.Status,
+			&mail.Attempts,
+			&mail.LastError,
+			&createdAt,
+			&nextAttemptAt,
+			&sentAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		mail.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		mail.NextAttemptAt = nextAttemptAt.Format("2006-01-02 15:04:05")
+		mail.SentAt = formatNullTime(sentAt)
+		mails = append(mails, mail)
+	}
+
+	return mails, rows.Err()
+}
+
+func (ri *mailRepositoryImpl) MarkMailSent(ctx context.Context, mailId int64, now time.Time) error {
+	query := "UPDATE mail_outbox SET status = $2, attempts = attempts + 1, last_error = '', sent_at = $3, body = '' WHERE id = $1"
+	_, err := ri.db.ExecContext(ctx, query, mailId, model.MailStatusSent, now.UTC())
+	return err
+}
+
+func (ri *mailRepositoryImpl) MarkMailRetry(ctx context.Context, mailId int64, lastError string, nextAttemptAt time.Time) error {
+	query := "UPDATE mail_outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1"
+	_, err := ri.db.ExecContext(ctx, query, mailId, lastError, nextAttemptAt.UTC())
+	return err
+}
+
+func (ri *mailRepositoryImpl) MarkMailFailed(ctx context.Context, mailId int64, lastError string) error {
+	query := "UPDATE mail_outbox SET status = $2, attempts = attempts + 1, last_error = $3, body = '' WHERE id = $1"
+	_, err := ri.db.ExecContext(ctx, query, mailId, model.MailStatusFailed, lastError)
+	return err
+}
@@ -0,0 +1,111 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+type passwordResetRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewPasswordResetRepositoryImpl(db infrastructure.DBTX) repository.PasswordResetRepository {
+	return &passwordResetRepositoryImpl{db: db}
+}
+
+func (ri *passwordResetRepositoryImpl) CreatePasswordReset(ctx context.Context, reset *model.PasswordReset) (*model.PasswordReset, error) {
+	query := "INSERT INTO password_resets (user_id, token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4) returning id"
+	err := ri.db.QueryRowContext(ctx, query, reset.UserID, reset.TokenHash, reset.CreatedAt, reset.ExpiresAt).Scan(&reset.ID)
+	if err != nil {
+		return &model.PasswordReset{}, err
+	}
+
+	return reset, nil
+}
+
+func (ri *passwordResetRepositoryImpl) GetPasswordResetByTokenHash(ctx context.Context, tokenHash string) (*model.PasswordReset, error) {
+	var createdAt, expiresAt time.Time
+	var usedAt sql.NullTime
+	reset := &model.PasswordReset{}
+	query := "SELECT id, user_id, token_hash, created_at, expires_at, used_at FROM password_resets WHERE token_hash = $1"
+	err := ri.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&reset.ID,
+		&reset.UserID,
+		&reset.TokenHash,
+		&createdAt,
+		&expiresAt,
+		&usedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.PasswordReset{}, repository.ErrPasswordResetNotFound
+	}
+	if err != nil {
+		return &model.PasswordReset{}, err
+	}
+
+	reset.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+	reset.ExpiresAt = expiresAt.Format("2006-01-02 15:04:05")
+	reset.UsedAt = formatNullTime(usedAt)
+	return reset, nil
+}
+
+func (ri *passwordResetRepositoryImpl) CountPasswordResetsSince(ctx context.Context, userId int64, since time.Time) (int64, error) {
+	var count int64
+	query := "SELECT COUNT(*) FROM password_resets WHERE user_id = $1 AND created_at >= $2"
+	err := ri.db.QueryRowContext(ctx, query, userId, since.UTC()).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (ri *passwordResetRepositoryImpl) ResetPassword(ctx context.Context, reset *model.PasswordReset, hashedPassword string, now time.Time) (bool, error) {
+	var used bool
+	err := withTx(ctx, ri.db, func(tx infrastructure.DBTX) error {
+		var err error
+		used, err = usePasswordReset(ctx, tx, reset.ID, now)
+		if err != nil || !used {
+			return err
+		}
+
+		if err := (&userRepositoryImpl{db: tx}).UpdatePassword(ctx, reset.UserID, hashedPassword); err != nil {
+			return err
+		}
+		// 同時に発行された他のリンクと、古いパスワードで発行したセッションとトークンを無効にする
+		query := "UPDATE password_resets SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL"
+		if _, err := tx.ExecContext(ctx, query, reset.UserID, now.UTC()); err != nil {
+			return err
+		}
+		if err := (&sessionRepositoryImpl{db: tx}).RevokeUserSessions(ctx, reset.UserID, now); err != nil {
+			return err
+		}
+		return (&tokenRepositoryImpl{db: tx}).RevokeUserTokens(ctx, reset.UserID, now)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return used, nil
+}
+
+func usePasswordReset(ctx context.Context, db infrastructure.DBTX, resetId int64, now time.Time) (bool, error) {
+	// 同時に同じトークンで再設定された場合に、片方だけが成功するようにする
+	result, err := db.ExecContext(ctx, "UPDATE password_resets SET used_at = $2 WHERE id = $1 AND used_at IS NULL", resetId, now.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
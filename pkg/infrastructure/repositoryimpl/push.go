@@ -0,0 +1,90 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+type pushSubscriptionRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewPushSubscriptionRepositoryImpl(db infrastructure.DBTX) repository.PushSubscriptionRepository {
+	return &pushSubscriptionRepositoryImpl{db: db}
+}
+
+func (ri *pushSubscriptionRepositoryImpl) CreateSubscription(ctx context.Context, subscription *model.PushSubscription) (*model.PushSubscription, error) {
+	query := `
+		INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (endpoint) DO UPDATE SET p256dh = EXCLUDED.p256dh, auth = EXCLUDED.auth
+		WHERE push_subscriptions.user_id = EXCLUDED.user_id
+		returning id`
+	err := ri.db.QueryRowContext(
+		ctx,
+		query,
+		subscription.UserID,
+		subscription.Endpoint,
+		subscription.P256dh,
+		subscription.Auth,
+	).Scan(&subscription.ID)
+	// 他のユーザーの行はWHEREで更新されず、idが返らない
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.PushSubscription{}, repository.ErrPushSubscriptionTaken
+	}
+	if err != nil {
+		return &model.PushSubscription{}, err
+	}
+
+	return subscription, nil
+}
+
+func (ri *pushSubscriptionRepositoryImpl) GetSubscriptions(ctx context.Context, userId int64) ([]*model.PushSubscription, error) {
+	var subscriptions []*model.PushSubscription = []*model.PushSubscription{}
+	query := "SELECT id, user_id, endpoint, p256dh, auth FROM push_subscriptions WHERE user_id = $1 ORDER BY id"
+
+	rows, err := ri.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		subscription := &model.PushSubscription{}
+		err := rows.Scan(
+			&subscription.ID,
+			&subscription.UserID,
+			&subscription.Endpoint,
+			&subscription.P256dh,
+			&subscription.Auth,
+		)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (ri *pushSubscriptionRepositoryImpl) DeleteSubscription(ctx context.Context, userId int64, endpoint string) error {
+	query := "DELETE FROM push_subscriptions WHERE user_id = $1 AND endpoint = $2"
+	_, err := ri.db.ExecContext(ctx, query, userId, endpoint)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ri *pushSubscriptionRepositoryImpl) DeleteSubscriptionByEndpoint(ctx context.Context, endpoint string) error {
+	query := "DELETE FROM push_subscriptions WHERE endpoint = $1"
+	_, err := ri.db.ExecContext(ctx, query, endpoint)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+const reminderColumns = `id, user_id, kind, interval_minutes, times, days, quiet_start, quiet_end,
+	skip_if_drank_within_minutes, enabled, last_triggered_at`
+
+type reminderRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewReminderRepositoryImpl(db infrastructure.DBTX) repository.ReminderRepository {
+	return &reminderRepositoryImpl{db: db}
+}
+
+func (ri *reminderRepositoryImpl) GetReminders(ctx context.Context, userId int64) ([]*model.Reminder, error) {
+	query := "SELECT " + reminderColumns + " FROM reminders WHERE user_id = $1 ORDER BY id"
+	return ri.queryReminders(ctx, query, userId)
+}
+
+func (ri *reminderRepositoryImpl) GetEnabledReminders(ctx context.Context) ([]*model.Reminder, error) {
+	query := "SELECT " + reminderColumns + " FROM reminders WHERE enabled ORDER BY id"
+	return ri.queryReminders(ctx, query)
+}
+
+func (ri *reminderRepositoryImpl) GetReminder(ctx context.Context, reminderId int64) (*model.Reminder, error) {
+	query := "SELECT " + reminderColumns + " FROM reminders WHERE id = $1"
+	reminder, err := scanReminder(ri.db.QueryRowContext(ctx, query, reminderId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.Reminder{}, repository.ErrReminderNotFound
+	}
+	if err != nil {
+		return &model.Reminder{}, err
+	}
+
+	return reminder, nil
+}
+
+func (ri *reminderRepositoryImpl) CreateReminder(ctx context.Context, reminder *model.Reminder) (*model.Reminder, error) {
+	query := `
+		INSERT INTO reminders (user_id, kind, interval_minutes, times, days, quiet_start, quiet_end,
+			skip_if_drank_within_minutes, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) returning id`
+	err := ri.db.QueryRowContext(
+		ctx,
+		query,
+		reminder.UserID,
+		reminder.Kind,
+		reminder.IntervalMinutes,
+		strings.Join(reminder.Times, ","),
+		joinInts(reminder.Days),
+		reminder.QuietStart,
+		reminder.QuietEnd,
+		reminder.SkipIfDrankWithinMinutes,
+		reminder.Enabled,
+	).Scan(&reminder.ID)
+	if err != nil {
+		return &model.Reminder{}, err
+	}
+
+	return reminder, nil
+}
+
+func (ri *reminderRepositoryImpl) UpdateReminder(ctx context.Context, reminder *model.Reminder) (*model.Reminder, error) {
+	query := `
+		UPDATE reminders SET kind = $1, interval_minutes = $2, times = $3, days = $4, quiet_start = $5,
+			quiet_end = $6, skip_if_drank_within_minutes = $7, enabled = $8
+		WHERE id = $9 AND user_id = $10`
+	_, err := ri.db.ExecContext(
+		ctx,
+		query,
+		reminder.Kind,
+		reminder.IntervalMinutes,
+		strings.Join(reminder.Times, ","),
+		joinInts(reminder.Days),
+		reminder.QuietStart,
+		reminder.QuietEnd,
+		reminder.SkipIfDrankWithinMinutes,
+		reminder.Enabled,
+		reminder.ID,
+		reminder.UserID,
+	)
+	if err != nil {
+		return &model.Reminder{}, err
+	}
+
+	return reminder, nil
+}
+
+func (ri *reminderRepositoryImpl) DeleteReminder(ctx context.Context, reminderId int64) error {
+	query := "DELETE FROM reminders WHERE id = $1"
+	_, err := ri.db.ExecContext(ctx, query, reminderId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ri *reminderRepositoryImpl) UpdateTriggeredAt(ctx context.Context, reminderId int64, triggeredAt time.Time) error {
+	query := "UPDATE reminders SET last_triggered_at = $1 WHERE id = $2"
+	_, err := ri.db.ExecContext(ctx, query, triggeredAt.UTC().Format("2006-01-02 15:04:05"), reminderId)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ri *reminderRepositoryImpl) queryReminders(ctx context.Context, query string, args ...interface{}) ([]*model.Reminder, error) {
+	var reminders []*model.Reminder = []*model.Reminder{}
+
+	rows, err := ri.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+func scanReminder(row rowScanner) (*model.Reminder, error) {
+	var times, days string
+	var lastTriggeredAt sql.NullTime
+	reminder := &model.Reminder{}
+	err := row.Scan(
+		&reminder.ID,
+		&reminder.UserID,
+		&reminder.Kind,
+		&reminder.IntervalMinutes,
+		&times,
+		&days,
+		&reminder.QuietStart,
+		&reminder.QuietEnd,
+		&reminder.SkipIfDrankWithinMinutes,
+		&reminder.Enabled,
+		&lastTriggeredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	reminder.Times = splitNonEmpty(times)
+	reminder.Days = []int64{}
+	for _, day := range splitNonEmpty(days) {
+		d, err := strconv.ParseInt(day, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		reminder.Days = append(reminder.Days, d)
+	}
+	if lastTriggeredAt.Valid {
+		formatted := lastTriggeredAt.Time.Format("2006-01-02 15:04:05")
+		reminder.LastTriggeredAt = &formatted
+	}
+	return reminder, nil
+}
+
+func splitNonEmpty(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
+
+func joinInts(values []int64) string {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		strs = append(strs, strconv.FormatInt(v, 10))
+	}
+	return strings.Join(strs, ",")
+}
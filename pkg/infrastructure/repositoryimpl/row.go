@@ -0,0 +1,28 @@
+package repositoryimpl
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// rowScanner *sql.Rowと*sql.Rowsのどちらからでも読み込めるようにする
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// formatNullTime NULLの場合はnilを返す
+func formatNullTime(t sql.NullTime) *string {
+	if !t.Valid {
+		return nil
+	}
+	formatted := t.Time.Format("2006-01-02 15:04:05")
+	return &formatted
+}
+
+// isUniqueViolation 一意制約に違反したエラーかどうか
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,153 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+const sessionColumns = `id, user_id, family_id, token_hash, user_agent, ip_address,
+	created_at, last_used_at, expires_at, rotated_at, revoked_at`
+
+type sessionRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewSessionRepositoryImpl(db infrastructure.DBTX) repository.SessionRepository {
+	return &sessionRepositoryImpl{db: db}
+}
+
+func (ri *sessionRepositoryImpl) CreateSession(ctx context.Context, session *model.Session) (*model.Session, error) {
+	query := `
+		INSERT INTO sessions (user_id, family_id, token_hash, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) returning id`
+	err := ri.db.QueryRowContext(
+		ctx,
+		query,
+		session.UserID,
+		session.FamilyID,
+		session.TokenHash,
+		session.UserAgent,
+		session.IPAddress,
+		session.CreatedAt,
+		session.LastUsedAt,
+		session.ExpiresAt,
+	).Scan(&session.ID)
+	if err != nil {
+		return &model.Session{}, err
+	}
+
+	return session, nil
+}
+
+func (ri *sessionRepositoryImpl) GetSession(ctx context.Context, sessionId int64) (*model.Session, error) {
+	query := "SELECT " + sessionColumns + " FROM sessions WHERE id = $1"
+	return ri.getSession(ctx, query, sessionId)
+}
+
+func (ri *sessionRepositoryImpl) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	query := "SELECT " + sessionColumns + " FROM sessions WHERE token_hash = $1"
+	return ri.getSession(ctx, query, tokenHash)
+}
+
+func (ri *sessionRepositoryImpl) getSession(ctx context.Context, query string, args ...interface{}) (*model.Session, error) {
+	session, err := scanSession(ri.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.Session{}, repository.ErrSessionNotFound
+	}
+	if err != nil {
+		return &model.Session{}, err
+	}
+
+	return session, nil
+}
+
+func (ri *sessionRepositoryImpl) GetActiveSessions(ctx context.Context, userId int64, now time.Time) ([]*model.Session, error) {
+	var sessions []*model.Session = []*model.Session{}
+	query := "SELECT " + sessionColumns + ` FROM sessions
+		WHERE user_id = $1 AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_used_at DESC`
+
+	rows, err := ri.db.QueryContext(ctx, query, userId, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (ri *sessionRepositoryImpl) RotateSession(ctx context.Context, sessionId int64, now time.Time) (bool, error) {
+	// 同時に同じトークンで交換された場合に、片方だけが成功するようにする
+	result, err := ri.db.ExecContext(ctx, "UPDATE sessions SET rotated_at = $2 WHERE id = $1 AND rotated_at IS NULL", sessionId, now.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (ri *sessionRepositoryImpl) RevokeFamily(ctx context.Context, familyId string, now time.Time) error {
+	query := "UPDATE sessions SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL"
+	_, err := ri.db.ExecContext(ctx, query, familyId, now.UTC())
+	return err
+}
+
+func (ri *sessionRepositoryImpl) RevokeUserSessions(ctx context.Context, userId int64, now time.Time) error {
+	query := "UPDATE sessions SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL"
+	_, err := ri.db.ExecContext(ctx, query, userId, now.UTC())
+	return err
+}
+
+func scanSession(row rowScanner) (*model.Session, error) {
+	var createdAt, lastUsedAt, expiresAt time.Time
+	var rotatedAt, revokedAt sql.NullTime
+	session := &model.Session{}
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.FamilyID,
+		&session.TokenHash,
+		&session.UserAgent,
+		&session.IPAddress,
+		&createdAt,
+		&lastUsedAt,
+		&expiresAt,
+		&rotatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	session.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+	session.LastUsedAt = lastUsedAt.Format("2006-01-02 15:04:05")
+	session.ExpiresAt = expiresAt.Format("2006-01-02 15:04:05")
+	if rotatedAt.Valid {
+		formatted := rotatedAt.Time.Format("2006-01-02 15:04:05")
+		session.RotatedAt = &formatted
+	}
+	if revokedAt.Valid {
+		formatted := revokedAt.Time.Format("2006-01-02 15:04:05")
+		session.RevokedAt = &formatted
+	}
+	return session, nil
+}
@@ -0,0 +1,135 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+const tokenColumns = "id, user_id, name, prefix, token_hash, scopes, created_at, last_used_at, expires_at, revoked_at"
+
+type tokenRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewTokenRepositoryImpl(db infrastructure.DBTX) repository.TokenRepository {
+	return &tokenRepositoryImpl{db: db}
+}
+
+func (ri *tokenRepositoryImpl) CreateToken(ctx context.Context, token *model.PersonalAccessToken) (*model.PersonalAccessToken, error) {
+	query := `
+		INSERT INTO personal_access_tokens (user_id, name, prefix, token_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) returning id`
+	err := ri.db.QueryRowContext(
+		ctx,
+		query,
+		token.UserID,
+		token.Name,
+		token.Prefix,
+		token.TokenHash,
+		strings.Join(token.Scopes, ","),
+		token.CreatedAt,
+		token.ExpiresAt,
+	).Scan(&token.ID)
+	if err != nil {
+		return &model.PersonalAccessToken{}, err
+	}
+
+	return token, nil
+}
+
+func (ri *tokenRepositoryImpl) GetTokens(ctx context.Context, userId int64) ([]*model.PersonalAccessToken, error) {
+	var tokens []*model.PersonalAccessToken = []*model.PersonalAccessToken{}
+	query := "SELECT " + tokenColumns + " FROM personal_access_tokens WHERE user_id = $1 AND revoked_at IS NULL ORDER BY id"
+
+	rows, err := ri.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (ri *tokenRepositoryImpl) GetToken(ctx context.Context, tokenId int64) (*model.PersonalAccessToken, error) {
+	query := "SELECT " + tokenColumns + " FROM personal_access_tokens WHERE id = $1"
+	return ri.getToken(ctx, query, tokenId)
+}
+
+func (ri *tokenRepositoryImpl) GetTokenByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	query := "SELECT " + tokenColumns + " FROM personal_access_tokens WHERE token_hash = $1"
+	return ri.getToken(ctx, query, tokenHash)
+}
+
+func (ri *tokenRepositoryImpl) getToken(ctx context.Context, query string, args ...interface{}) (*model.PersonalAccessToken, error) {
+	token, err := scanToken(ri.db.QueryRowContext(ctx, query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.PersonalAccessToken{}, repository.ErrTokenNotFound
+	}
+	if err != nil {
+		return &model.PersonalAccessToken{}, err
+	}
+
+	return token, nil
+}
+
+func (ri *tokenRepositoryImpl) RevokeToken(ctx context.Context, tokenId int64, now time.Time) error {
+	query := "UPDATE personal_access_tokens SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL"
+	_, err := ri.db.ExecContext(ctx, query, tokenId, now.UTC())
+	return err
+}
+
+func (ri *tokenRepositoryImpl) RevokeUserTokens(ctx context.Context, userId int64, now time.Time) error {
+	query := "UPDATE personal_access_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL"
+	_, err := ri.db.ExecContext(ctx, query, userId, now.UTC())
+	return err
+}
+
+func (ri *tokenRepositoryImpl) UpdateLastUsedAt(ctx context.Context, tokenId int64, now time.Time) error {
+	_, err := ri.db.ExecContext(ctx, "UPDATE personal_access_tokens SET last_used_at = $2 WHERE id = $1", tokenId, now.UTC())
+	return err
+}
+
+func scanToken(row rowScanner) (*model.PersonalAccessToken, error) {
+	var scopes string
+	var createdAt time.Time
+	var lastUsedAt, expiresAt, revokedAt sql.NullTime
+	token := &model.PersonalAccessToken{}
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.Prefix,
+		&token.TokenHash,
+		&scopes,
+		&createdAt,
+		&lastUsedAt,
+		&expiresAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Split(scopes, ",")
+	token.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+	token.LastUsedAt = formatNullTime(lastUsedAt)
+	token.ExpiresAt = formatNullTime(expiresAt)
+	token.RevokedAt = formatNullTime(revokedAt)
+	return token, nil
+}
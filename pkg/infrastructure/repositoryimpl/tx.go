@@ -0,0 +1,30 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+)
+
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// withTx fnの中の操作を1つのトランザクションで実行する。dbがすでにトランザクションの場合はそのまま使う
+func withTx(ctx context.Context, db infrastructure.DBTX, fn func(tx infrastructure.DBTX) error) error {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
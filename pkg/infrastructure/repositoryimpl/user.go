@@ -2,6 +2,8 @@ package repositoryimpl
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/mikaijun/aquagent/pkg/infrastructure"
 
@@ -9,6 +11,10 @@ import (
 	"github.com/mikaijun/aquagent/pkg/domain/repository"
 )
 
+// 未設定のプロフィールはゼロ値として読み込む
+const userColumns = `id, username, email, password, timezone,
+	COALESCE(weight_kg, 0), COALESCE(age, 0), COALESCE(sex, ''), COALESCE(activity_level, ''), caffeine_limit_mg, email_verified_at`
+
 type userRepositoryImpl struct {
 	db infrastructure.DBTX
 }
@@ -19,8 +25,8 @@ func NewUserRepositoryImpl(db infrastructure.DBTX) repository.UserRepository {
 
 func (ri *userRepositoryImpl) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
 	var lastInsertId int
-	query := "INSERT INTO users(username, email, password) VALUES ($1, $2, $3) returning id"
-	err := ri.db.QueryRowContext(ctx, query, user.Username, user.Email, user.Password).Scan(&lastInsertId)
+	query := "INSERT INTO users(username, email, password) VALUES ($1, $2, $3) returning id, timezone, caffeine_limit_mg"
+	err := ri.db.QueryRowContext(ctx, query, user.Username, user.Email, user.Password).Scan(&lastInsertId, &user.Timezone, &user.CaffeineLimitMg)
 	if err != nil {
 		return &model.User{}, err
 	}
@@ -31,8 +37,8 @@ func (ri *userRepositoryImpl) CreateUser(ctx context.Context, user *model.User)
 
 func (ri *userRepositoryImpl) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	u := model.User{}
-	query := "SELECT id, username, email, password FROM users WHERE email = $1"
-	err := ri.db.QueryRowContext(ctx, query, email).Scan(&u.ID, &u.Username, &u.Email, &u.Password)
+	query := "SELECT " + userColumns + " FROM users WHERE email = $1"
+	err := scanUser(ri.db.QueryRowContext(ctx, query, email), &u)
 	if err != nil {
 		return &model.User{}, nil
 	}
@@ -42,11 +48,70 @@ func (ri *userRepositoryImpl) GetUserByEmail(ctx context.Context, email string)
 
 func (ri *userRepositoryImpl) GetUserById(ctx context.Context, id int64) (*model.User, error) {
 	u := model.User{}
-	query := "SELECT id, username, email, password FROM users WHERE id = $1"
-	err := ri.db.QueryRowContext(ctx, query, id).Scan(&u.ID, &u.Username, &u.Email, &u.Password)
+	query := "SELECT " + userColumns + " FROM users WHERE id = $1"
+	err := scanUser(ri.db.QueryRowContext(ctx, query, id), &u)
 	if err != nil {
 		return &model.User{}, nil
 	}
 
 	return &u, nil
 }
+
+func (ri *userRepositoryImpl) UpdateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	query := `
+		UPDATE users SET username = $1, timezone = $2,
+			weight_kg = NULLIF($3, 0), age = NULLIF($4, 0), sex = NULLIF($5, ''), activity_level = NULLIF($6, ''),
+			caffeine_limit_mg = $7
+		WHERE id = $8`
+	_, err := ri.db.ExecContext(
+		ctx,
+		query,
+		user.Username,
+		user.Timezone,
+		user.WeightKg,
+		user.Age,
+		user.Sex,
+		user.ActivityLevel,
+		user.CaffeineLimitMg,
+		user.ID,
+	)
+	if err != nil {
+		return &model.User{}, err
+	}
+
+	return user, nil
+}
+
+func (ri *userRepositoryImpl) UpdatePassword(ctx context.Context, userId int64, hashedPassword string) error {
+	_, err := ri.db.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2", hashedPassword, userId)
+	return err
+}
+
+func (ri *userRepositoryImpl) VerifyEmail(ctx context.Context, userId int64, now time.Time) error {
+	query := "UPDATE users SET email_verified_at = $1 WHERE id = $2 AND email_verified_at IS NULL"
+	_, err := ri.db.ExecContext(ctx, query, now.UTC(), userId)
+	return err
+}
+
+func scanUser(row rowScanner, u *model.User) error {
+	var emailVerifiedAt sql.NullTime
+	err := row.Scan(
+		&u.ID,
+		&u.Username,
+		&u.Email,
+		&u.Password,
+		&u.Timezone,
+		&u.WeightKg,
+		&u.Age,
+		&u.Sex,
+		&u.ActivityLevel,
+		&u.CaffeineLimitMg,
+		&emailVerifiedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	u.EmailVerifiedAt = formatNullTime(emailVerifiedAt)
+	return nil
+}
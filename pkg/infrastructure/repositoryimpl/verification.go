@@ -0,0 +1,98 @@
+package repositoryimpl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/infrastructure"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+type emailVerificationRepositoryImpl struct {
+	db infrastructure.DBTX
+}
+
+func NewEmailVerificationRepositoryImpl(db infrastructure.DBTX) repository.EmailVerificationRepository {
+	return &emailVerificationRepositoryImpl{db: db}
+}
+
+func (ri *emailVerificationRepositoryImpl) CreateEmailVerification(ctx context.Context, verification *model.EmailVerification) (*model.EmailVerification, error) {
+	query := "INSERT INTO email_verifications (user_id, token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4) returning id"
+	err := ri.db.QueryRowContext(ctx, query, verification.UserID, verification.TokenHash, verification.CreatedAt, verification.ExpiresAt).Scan(&verification.ID)
+	if err != nil {
+		return &model.EmailVerification{}, err
+	}
+
+	return verification, nil
+}
+
+func (ri *emailVerificationRepositoryImpl) GetEmailVerificationByTokenHash(ctx context.Context, tokenHash string) (*model.EmailVerification, error) {
+	var createdAt, expiresAt time.Time
+	var usedAt sql.NullTime
+	verification := &model.EmailVerification{}
+	query := "SELECT id, user_id, token_hash, created_at, expires_at, used_at FROM email_verifications WHERE token_hash = $1"
+	err := ri.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&verification.ID,
+		&verification.UserID,
+		&verification.TokenHash,
+		&createdAt,
+		&expiresAt,
+		&usedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.EmailVerification{}, repository.ErrEmailVerificationNotFound
+	}
+	if err != nil {
+		return &model.EmailVerification{}, err
+	}
+
+	verification.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+	verification.ExpiresAt = expiresAt.Format("2006-01-02 15:04:05")
+	verification.UsedAt = formatNullTime(usedAt)
+	return verification, nil
+}
+
+func (ri *emailVerificationRepositoryImpl) VerifyEmail(ctx context.Context, verification *model.EmailVerification, now time.Time) (bool, error) {
+	var used bool
+	err := withTx(ctx, ri.db, func(tx infrastructure.DBTX) error {
+		var err error
+		used, err = useEmailVerification(ctx, tx, verification.ID, now)
+		if err != nil || !used {
+			return err
+		}
+		return (&userRepositoryImpl{db: tx}).VerifyEmail(ctx, verification.UserID, now)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return used, nil
+}
+
+func useEmailVerification(ctx context.Context, db infrastructure.DBTX, verificationId int64, now time.Time) (bool, error) {
+	result, err := db.ExecContext(ctx, "UPDATE email_verifications SET used_at = $2 WHERE id = $1 AND used_at IS NULL", verificationId, now.UTC())
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (ri *emailVerificationRepositoryImpl) CountEmailVerificationsSince(ctx context.Context, userId int64, since time.Time) (int64, error) {
+	var count int64
+	query := "SELECT COUNT(*) FROM email_verifications WHERE user_id = $1 AND created_at >= $2"
+	err := ri.db.QueryRowContext(ctx, query, userId, since.UTC()).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -2,6 +2,7 @@ package repositoryimpl
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"math/rand"
 	"time"
@@ -16,20 +17,24 @@ type waterRepositoryImpl struct {
 	db infrastructure.DBTX
 }
 
-var drankAt time.Time
-
 func NewWaterRepositoryImpl(db infrastructure.DBTX) repository.WaterRepository {
 	return &waterRepositoryImpl{db: db}
 }
 
 func (ri *waterRepositoryImpl) CreateWater(ctx context.Context, water *model.Water) (*model.Water, error) {
 	var lastInsertId int
-	query := "INSERT INTO waters (user_id, volume, drank_at) VALUES ($1, $2, $3) returning id"
+	query := `
+		INSERT INTO waters (user_id, volume, beverage_id, container_id, caffeine_mg, alcohol_g, drank_at)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), $5, $6, $7) returning id`
 	err := ri.db.QueryRowContext(
 		ctx,
 		query,
 		water.UserID,
 		water.Volume,
+		water.BeverageID,
+		water.ContainerID,
+		water.CaffeineMg,
+		water.AlcoholG,
 		water.DrankAt,
 	).Scan(&lastInsertId)
 	if err != nil {
@@ -40,6 +45,23 @@ func (ri *waterRepositoryImpl) CreateWater(ctx context.Context, water *model.Wat
 	return water, nil
 }
 
+func (ri *waterRepositoryImpl) CreateWaters(ctx context.Context, waters []*model.Water) ([]*model.Water, error) {
+	err := withTx(ctx, ri.db, func(tx infrastructure.DBTX) error {
+		txRepo := &waterRepositoryImpl{db: tx}
+		for _, water := range waters {
+			if _, err := txRepo.CreateWater(ctx, water); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return waters, nil
+}
+
 func (ri *waterRepositoryImpl) CreateRandomWaters(ctx context.Context) ([]*model.Water, error) {
 	query := "INSERT INTO waters (user_id, volume, drank_at) VALUES ($1, $2, $3) returning id"
 	now := time.Now()
@@ -78,7 +100,9 @@ func (ri *waterRepositoryImpl) CreateRandomWaters(ctx context.Context) ([]*model
 
 func (ri *waterRepositoryImpl) GetWaters(ctx context.Context, userId int64, filter map[string]interface{}) ([]*model.Water, error) {
 	var waters []*model.Water = []*model.Water{}
-	query := "SELECT id, user_id, volume, drank_at FROM waters WHERE user_id = $1"
+	query := `
+		SELECT id, user_id, volume, COALESCE(beverage_id, 0), COALESCE(container_id, 0), caffeine_mg, alcohol_g, drank_at
+		FROM waters WHERE user_id = $1`
 	args := []interface{}{userId}
 
 	// 指定した日付以降(その日も含む)
@@ -103,49 +127,201 @@ func (ri *waterRepositoryImpl) GetWaters(ctx context.Context, userId int64, filt
 	defer rows.Close()
 
 	for rows.Next() {
+		var drankAt time.Time
 		water := &model.Water{}
 		err := rows.Scan(
 			&water.ID,
 			&water.UserID,
 			&water.Volume,
+			&water.BeverageID,
+			&water.ContainerID,
+			&water.CaffeineMg,
+			&water.AlcoholG,
 			&drankAt,
 		)
-		water.DrankAt = drankAt.Format("2006-01-02 15:04:05")
 		if err != nil {
 			return nil, err
 		}
+		water.DrankAt = drankAt.Format("2006-01-02 15:04:05")
 		waters = append(waters, water)
 	}
 	return waters, nil
 }
 
 func (ri *waterRepositoryImpl) GetWater(ctx context.Context, waterId int64) (*model.Water, error) {
+	var drankAt time.Time
 	water := &model.Water{}
-	query := "SELECT id, user_id, volume, drank_at FROM waters WHERE id = $1"
+	query := `
+		SELECT id, user_id, volume, COALESCE(beverage_id, 0), COALESCE(container_id, 0), caffeine_mg, alcohol_g, drank_at
+		FROM waters WHERE id = $1`
 
 	err := ri.db.QueryRowContext(ctx, query, waterId).Scan(
 		&water.ID,
 		&water.UserID,
 		&water.Volume,
-		&water.DrankAt,
+		&water.BeverageID,
+		&water.ContainerID,
+		&water.CaffeineMg,
+		&water.AlcoholG,
+		&drankAt,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.Water{}, repository.ErrWaterNotFound
+	}
+	if err != nil {
+		return &model.Water{}, err
+	}
+
+	water.DrankAt = drankAt.Format("2006-01-02 15:04:05")
+	return water, nil
+}
 
-	if water.ID == 0 {
-		return &model.Water{}, errors.New("water not found")
+func (ri *waterRepositoryImpl) UpdateWater(ctx context.Context, water *model.Water) (*model.Water, error) {
+	query := `
+		UPDATE waters SET volume = $1, beverage_id = NULLIF($2, 0), caffeine_mg = $3, alcohol_g = $4, drank_at = $5
+		WHERE id = $6 AND user_id = $7`
+	result, err := ri.db.ExecContext(
+		ctx,
+		query,
+		water.Volume,
+		water.BeverageID,
+		water.CaffeineMg,
+		water.AlcoholG,
+		water.DrankAt,
+		water.ID,
+		water.UserID,
+	)
+	if err != nil {
+		return &model.Water{}, err
 	}
 
+	affected, err := result.RowsAffected()
 	if err != nil {
 		return &model.Water{}, err
 	}
+	if affected == 0 {
+		return &model.Water{}, repository.ErrWaterNotFound
+	}
 
 	return water, nil
 }
 
-func (ri *waterRepositoryImpl) DeleteWater(ctx context.Context, waterId int64) error {
-	query := "DELETE FROM waters WHERE id = $1"
-	_, err := ri.db.ExecContext(ctx, query, waterId)
+func (ri *waterRepositoryImpl) DeleteWater(ctx context.Context, userId, waterId int64) error {
+	query := "DELETE FROM waters WHERE id = $1 AND user_id = $2"
+	result, err := ri.db.ExecContext(ctx, query, waterId, userId)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if affected == 0 {
+		return repository.ErrWaterNotFound
+	}
+
 	return nil
 }
+
+func (ri *waterRepositoryImpl) CountWaters(ctx context.Context, userId int64) (int64, error) {
+	var count int64
+	query := "SELECT COUNT(*) FROM waters WHERE user_id = $1"
+	err := ri.db.QueryRowContext(ctx, query, userId).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (ri *waterRepositoryImpl) GetLastDrankAt(ctx context.Context, userId int64, timezone string) (time.Time, error) {
+	var lastDrankAt sql.NullTime
+	// drank_atはユーザーのタイムゾーンの時刻のため、タイムゾーン付きの時刻に変換する
+	query := "SELECT MAX(drank_at) AT TIME ZONE $2 FROM waters WHERE user_id = $1"
+	err := ri.db.QueryRowContext(ctx, query, userId, timezone).Scan(&lastDrankAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lastDrankAt.Time, nil
+}
+
+func (ri *waterRepositoryImpl) GetDailyTotals(ctx context.Context, userId int64, start, end string) ([]*model.DailyTotal, error) {
+	var totals []*model.DailyTotal = []*model.DailyTotal{}
+	// drank_atはユーザーのタイムゾーンの時刻で保存しているため、そのまま日付で区切る
+	// 飲み物が未指定の記録は水(係数1)として扱う
+	// カフェインとアルコールは記録ごとの値があればそれを、なければ飲み物の100mlあたりの値から計算する
+	query := `
+		SELECT local_date, SUM(volume), ROUND(SUM(volume * hydration_factor))::bigint,
+			SUM(caffeine_mg), SUM(alcohol_g),
+			COUNT(*), MIN(drank_at), MAX(drank_at)
+		FROM (
+			SELECT w.volume, COALESCE(b.hydration_factor, 1) AS hydration_factor,
+				COALESCE(w.caffeine_mg, w.volume * COALESCE(b.caffeine_mg_per_100ml, 0) / 100) AS caffeine_mg,
+				COALESCE(w.alcohol_g, w.volume * COALESCE(b.alcohol_g_per_100ml, 0) / 100) AS alcohol_g,
+				w.drank_at,
+				DATE(w.drank_at) AS local_date
+			FROM waters AS w
+			LEFT JOIN beverages AS b ON b.id = w.beverage_id
+			WHERE w.user_id = $1
+		) AS w
+		WHERE local_date BETWEEN $2 AND $3
+		GROUP BY local_date
+		ORDER BY local_date`
+
+	rows, err := ri.db.QueryContext(ctx, query, userId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var date, first, last time.Time
+		total := &model.DailyTotal{}
+		err := rows.Scan(
+			&date,
+			&total.TotalVolume,
+			&total.EffectiveVolume,
+			&total.CaffeineMg,
+			&total.AlcoholG,
+			&total.Count,
+			&first,
+			&last,
+		)
+		if err != nil {
+			return nil, err
+		}
+		total.Date = date.Format("2006-01-02")
+		total.FirstDrankAt = first.Format("2006-01-02 15:04:05")
+		total.LastDrankAt = last.Format("2006-01-02 15:04:05")
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+func (ri *waterRepositoryImpl) GetLocalDrinks(ctx context.Context, userId int64, start, end string) ([]*model.LocalDrink, error) {
+	var drinks []*model.LocalDrink = []*model.LocalDrink{}
+	query := `
+		SELECT volume, drank_at
+		FROM waters
+		WHERE user_id = $1 AND DATE(drank_at) BETWEEN $2 AND $3
+		ORDER BY drank_at`
+
+	rows, err := ri.db.QueryContext(ctx, query, userId, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		drink := &model.LocalDrink{}
+		err := rows.Scan(
+			&drink.Volume,
+			&drink.DrankAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		drinks = append(drinks, drink)
+	}
+	return drinks, rows.Err()
+}
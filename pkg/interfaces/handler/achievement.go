@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type AchievementHandler interface {
+	HandleSearch(c *gin.Context)
+}
+
+type achievementHandler struct {
+	useCase usecase.AchievementUseCase
+}
+
+func NewAchievementHandler(achievementUseCase usecase.AchievementUseCase) AchievementHandler {
+	return &achievementHandler{
+		useCase: achievementUseCase,
+	}
+}
+
+func (h *achievementHandler) HandleSearch(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	achievements, err := h.useCase.Search(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, achievements)
+}
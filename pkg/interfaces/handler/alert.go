@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type AlertHandler interface {
+	HandleSearch(c *gin.Context)
+	HandleAcknowledge(c *gin.Context)
+	HandleFetchSettings(c *gin.Context)
+	HandleUpdateSettings(c *gin.Context)
+}
+
+type alertHandler struct {
+	useCase usecase.AlertUseCase
+}
+
+func NewAlertHandler(alertUseCase usecase.AlertUseCase) AlertHandler {
+	return &alertHandler{
+		useCase: alertUseCase,
+	}
+}
+
+func (h *alertHandler) HandleSearch(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// 未確認のアラートだけを取得する場合は ?open=true を指定する
+	alerts, err := h.useCase.Search(c.Request.Context(), userId, c.Query("open") == "true")
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+func (h *alertHandler) HandleAcknowledge(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.useCase.Acknowledge(c.Request.Context(), userId, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "alert acknowledge successful"})
+}
+
+func (h *alertHandler) HandleFetchSettings(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	settings, err := h.useCase.FetchSettings(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *alertHandler) HandleUpdateSettings(c *gin.Context) {
+	type (
+		request struct {
+			Enabled                  *bool `json:"enabled"`
+			MaxHoursWithoutDrink     int64 `json:"max_hours_without_drink" binding:"required"`
+			DailyMinimum             int64 `json:"daily_minimum"`
+			DailyMinimumDeadlineHour int64 `json:"daily_minimum_deadline_hour"`
+			ActiveStartHour          int64 `json:"active_start_hour"`
+			ActiveEndHour            int64 `json:"active_end_hour" binding:"required"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	settings, err := h.useCase.UpdateSettings(c.Request.Context(), &model.AlertSettings{
+		UserID:                   userId,
+		MaxHoursWithoutDrink:     requestBody.MaxHoursWithoutDrink,
+		DailyMinimum:             requestBody.DailyMinimum,
+		DailyMinimumDeadlineHour: requestBody.DailyMinimumDeadlineHour,
+		ActiveStartHour:          requestBody.ActiveStartHour,
+		ActiveEndHour:            requestBody.ActiveEndHour,
+	}, requestBody.Enabled)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
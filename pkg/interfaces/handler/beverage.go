@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type BeverageHandler interface {
+	HandleSearch(c *gin.Context)
+	HandleCreate(c *gin.Context)
+	HandleDelete(c *gin.Context)
+}
+
+type beverageHandler struct {
+	useCase usecase.BeverageUseCase
+}
+
+func NewBeverageHandler(beverageUseCase usecase.BeverageUseCase) BeverageHandler {
+	return &beverageHandler{
+		useCase: beverageUseCase,
+	}
+}
+
+func (h *beverageHandler) HandleSearch(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	beverages, err := h.useCase.Search(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, beverages)
+}
+
+func (h *beverageHandler) HandleCreate(c *gin.Context) {
+	type (
+		request struct {
+			Name               string   `json:"name" binding:"required"`
+			HydrationFactor    *float64 `json:"hydration_factor" binding:"required"`
+			CaffeineMgPer100ml float64  `json:"caffeine_mg_per_100ml"`
+			AlcoholGPer100ml   float64  `json:"alcohol_g_per_100ml"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	beverage, err := h.useCase.Create(c.Request.Context(), &model.Beverage{
+		UserID:             userId,
+		Name:               requestBody.Name,
+		HydrationFactor:    *requestBody.HydrationFactor,
+		CaffeineMgPer100ml: requestBody.CaffeineMgPer100ml,
+		AlcoholGPer100ml:   requestBody.AlcoholGPer100ml,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, beverage)
+}
+
+func (h *beverageHandler) HandleDelete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.useCase.Delete(c.Request.Context(), userId, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "beverage delete successful"})
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type CoachHandler interface {
+	HandleSend(c *gin.Context)
+	HandleHistory(c *gin.Context)
+	HandleClear(c *gin.Context)
+}
+
+type coachHandler struct {
+	useCase usecase.CoachUseCase
+}
+
+func NewCoachHandler(coachUseCase usecase.CoachUseCase) CoachHandler {
+	return &coachHandler{
+		useCase: coachUseCase,
+	}
+}
+
+func (h *coachHandler) HandleSend(c *gin.Context) {
+	type (
+		request struct {
+			Content string `json:"content" binding:"required"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	message, err := h.useCase.Send(c.Request.Context(), userId, requestBody.Content)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, message)
+}
+
+func (h *coachHandler) HandleHistory(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	messages, err := h.useCase.History(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, messages)
+}
+
+func (h *coachHandler) HandleClear(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.useCase.Clear(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "coach messages delete successful"})
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type ContainerHandler interface {
+	HandleSearch(c *gin.Context)
+	HandleCreate(c *gin.Context)
+	HandleUpdate(c *gin.Context)
+	HandleDelete(c *gin.Context)
+}
+
+type containerHandler struct {
+	useCase usecase.ContainerUseCase
+}
+
+func NewContainerHandler(containerUseCase usecase.ContainerUseCase) ContainerHandler {
+	return &containerHandler{
+		useCase: containerUseCase,
+	}
+}
+
+func (h *containerHandler) HandleSearch(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	containers, err := h.useCase.Search(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, containers)
+}
+
+func (h *containerHandler) HandleCreate(c *gin.Context) {
+	type (
+		request struct {
+			Name       string `json:"name" binding:"required"`
+			Volume     int64  `json:"volume" binding:"required"`
+			BeverageID int64  `json:"beverage_id"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	container, err := h.useCase.Create(c.Request.Context(), &model.Container{
+		UserID:     userId,
+		Name:       requestBody.Name,
+		Volume:     requestBody.Volume,
+		BeverageID: requestBody.BeverageID,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, container)
+}
+
+func (h *containerHandler) HandleUpdate(c *gin.Context) {
+	type (
+		request struct {
+			Name       string `json:"name"`
+			Volume     int64  `json:"volume"`
+			BeverageID int64  `json:"beverage_id"`
+		}
+	)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	container, err := h.useCase.Update(c.Request.Context(), &model.Container{
+		ID:         id,
+		UserID:     userId,
+		Name:       requestBody.Name,
+		Volume:     requestBody.Volume,
+		BeverageID: requestBody.BeverageID,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, container)
+}
+
+func (h *containerHandler) HandleDelete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.useCase.Delete(c.Request.Context(), userId, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "container delete successful"})
+}
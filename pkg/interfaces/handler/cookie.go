@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+const (
+	accessTokenCookie  = "jwt"
+	refreshTokenCookie = "refresh_token"
+)
+
+// setAuthCookies ブラウザにはトークンをHttpOnlyのCookieで渡す
+func setAuthCookies(c *gin.Context, pair *model.TokenPair) {
+	domain := os.Getenv("DOMAIN")
+
+	c.SetCookie(accessTokenCookie, pair.AccessToken, int(pair.ExpiresIn), "/", domain, false, true)
+	c.SetCookie(refreshTokenCookie, pair.RefreshToken, int(pair.RefreshExpiresIn), "/", domain, false, true)
+}
+
+func clearAuthCookies(c *gin.Context) {
+	domain := os.Getenv("DOMAIN")
+
+	c.SetCookie(accessTokenCookie, "", -1, "/", domain, false, true)
+	c.SetCookie(refreshTokenCookie, "", -1, "/", domain, false, true)
+}
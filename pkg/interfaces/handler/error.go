@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+// handleError usecaseが返すエラーの型に応じてステータスコードを切り替える
+func handleError(c *gin.Context, err error) {
+	switch e := err.(type) {
+	case *util.InternalServerError:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": e.Err.Error()})
+	case *util.BadRequestError:
+		c.JSON(http.StatusBadRequest, gin.H{"error": e.Err.Error()})
+	case *util.UnauthorizedError:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": e.Err.Error()})
+	case *util.ForbiddenError:
+		c.JSON(http.StatusForbidden, gin.H{"error": e.Err.Error()})
+	case *util.NotFoundError:
+		c.JSON(http.StatusNotFound, gin.H{"error": e.Err.Error()})
+	case *util.TooManyRequestsError:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": e.Err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
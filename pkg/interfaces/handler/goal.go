@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type GoalHandler interface {
+	HandleFetch(c *gin.Context)
+	HandleSet(c *gin.Context)
+	HandleRecommend(c *gin.Context)
+}
+
+type goalHandler struct {
+	useCase usecase.GoalUseCase
+}
+
+func NewGoalHandler(goalUseCase usecase.GoalUseCase) GoalHandler {
+	return &goalHandler{
+		useCase: goalUseCase,
+	}
+}
+
+func (h *goalHandler) HandleFetch(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	settings, err := h.useCase.Fetch(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *goalHandler) HandleSet(c *gin.Context) {
+	type (
+		request struct {
+			Volume        int64  `json:"volume" binding:"required"`
+			EffectiveDate string `json:"effective_date"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	goal, err := h.useCase.Set(c.Request.Context(), userId, requestBody.Volume, requestBody.EffectiveDate)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, goal)
+}
+
+func (h *goalHandler) HandleRecommend(c *gin.Context) {
+	var exerciseMinutes int64
+	if value := c.Query("exercise_minutes"); value != "" {
+		var err error
+		exerciseMinutes, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	recommendation, err := h.useCase.Recommend(c.Request.Context(), userId, exerciseMinutes)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, recommendation)
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type JWKSHandler interface {
+	HandleJWKS(c *gin.Context)
+}
+
+type jwksHandler struct{}
+
+func NewJWKSHandler() JWKSHandler {
+	return &jwksHandler{}
+}
+
+// HandleJWKS 他のサービスがトークンを検証するための公開鍵
+func (h *jwksHandler) HandleJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, util.JWKS())
+}
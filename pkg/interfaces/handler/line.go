@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type LineHandler interface {
+	HandleWebhook(c *gin.Context)
+	HandleIssueLinkCode(c *gin.Context)
+	HandleUnlink(c *gin.Context)
+}
+
+type lineHandler struct {
+	useCase usecase.LineUseCase
+}
+
+func NewLineHandler(lineUseCase usecase.LineUseCase) LineHandler {
+	return &lineHandler{
+		useCase: lineUseCase,
+	}
+}
+
+func (h *lineHandler) HandleWebhook(c *gin.Context) {
+	type (
+		event struct {
+			Type       string `json:"type"`
+			ReplyToken string `json:"replyToken"`
+			Source     struct {
+				UserID string `json:"userId"`
+			} `json:"source"`
+			Message struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"message"`
+		}
+		request struct {
+			Events []event `json:"events"`
+		}
+	)
+
+	// 署名は受け取ったbodyそのものに対して検証する必要があるため、バインドする前に読む
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.useCase.VerifySignature(body, c.GetHeader("X-Line-Signature")) {
+		handleError(c, &util.ForbiddenError{Err: errors.New("invalid line signature")})
+		return
+	}
+
+	requestBody := new(request)
+	if err := json.Unmarshal(body, requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var events []*model.LineEvent
+	for _, e := range requestBody.Events {
+		event := &model.LineEvent{
+			Type:       e.Type,
+			ReplyToken: e.ReplyToken,
+			LineUserID: e.Source.UserID,
+		}
+		if e.Message.Type == "text" {
+			event.Text = e.Message.Text
+		}
+		events = append(events, event)
+	}
+
+	err = h.useCase.HandleEvents(c.Request.Context(), events)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+func (h *lineHandler) HandleIssueLinkCode(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	code, err := h.useCase.IssueLinkCode(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, code)
+}
+
+func (h *lineHandler) HandleUnlink(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.useCase.Unlink(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "line unlink successful"})
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+)
+
+type PasswordHandler interface {
+	HandleForgot(c *gin.Context)
+	HandleReset(c *gin.Context)
+}
+
+type passwordHandler struct {
+	useCase usecase.PasswordUseCase
+}
+
+func NewPasswordHandler(passwordUseCase usecase.PasswordUseCase) PasswordHandler {
+	return &passwordHandler{
+		useCase: passwordUseCase,
+	}
+}
+
+// HandleForgot メールの言語はlocale、なければAccept-Languageで決める
+func (h *passwordHandler) HandleForgot(c *gin.Context) {
+	type (
+		request struct {
+			Email  string `json:"email" binding:"required,email"`
+			Locale string `json:"locale"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	locale := requestBody.Locale
+	if locale == "" {
+		locale = c.GetHeader("Accept-Language")
+	}
+
+	err := h.useCase.Forgot(c.Request.Context(), requestBody.Email, locale)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if the email is registered, a reset link has been sent"})
+}
+
+func (h *passwordHandler) HandleReset(c *gin.Context) {
+	type (
+		request struct {
+			Token    string `json:"token" binding:"required"`
+			Password string `json:"password" binding:"required,min=8"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.useCase.Reset(c.Request.Context(), requestBody.Token, requestBody.Password)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset successful"})
+}
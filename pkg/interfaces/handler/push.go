@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type PushHandler interface {
+	HandleSubscribe(c *gin.Context)
+	HandleUnsubscribe(c *gin.Context)
+	HandlePublicKey(c *gin.Context)
+}
+
+type pushHandler struct {
+	useCase usecase.PushUseCase
+}
+
+func NewPushHandler(pushUseCase usecase.PushUseCase) PushHandler {
+	return &pushHandler{
+		useCase: pushUseCase,
+	}
+}
+
+func (h *pushHandler) HandleSubscribe(c *gin.Context) {
+	// ブラウザのPushSubscription.toJSON()の形式
+	type (
+		request struct {
+			Endpoint string `json:"endpoint" binding:"required"`
+			Keys     struct {
+				P256dh string `json:"p256dh" binding:"required"`
+				Auth   string `json:"auth" binding:"required"`
+			} `json:"keys" binding:"required"`
+		}
+	)
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	subscription, err := h.useCase.Subscribe(c.Request.Context(), &model.PushSubscription{
+		UserID:   userId,
+		Endpoint: requestBody.Endpoint,
+		P256dh:   requestBody.Keys.P256dh,
+		Auth:     requestBody.Keys.Auth,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, subscription)
+}
+
+func (h *pushHandler) HandleUnsubscribe(c *gin.Context) {
+	type (
+		request struct {
+			Endpoint string `json:"endpoint" binding:"required"`
+		}
+	)
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.useCase.Unsubscribe(c.Request.Context(), userId, requestBody.Endpoint)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "push subscription delete successful"})
+}
+
+func (h *pushHandler) HandlePublicKey(c *gin.Context) {
+	publicKey, err := h.useCase.PublicKey(c.Request.Context())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"public_key": publicKey})
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type ReminderHandler interface {
+	HandleSearch(c *gin.Context)
+	HandleCreate(c *gin.Context)
+	HandleUpdate(c *gin.Context)
+	HandleDelete(c *gin.Context)
+}
+
+type reminderHandler struct {
+	useCase usecase.ReminderUseCase
+}
+
+func NewReminderHandler(reminderUseCase usecase.ReminderUseCase) ReminderHandler {
+	return &reminderHandler{
+		useCase: reminderUseCase,
+	}
+}
+
+type reminderRequest struct {
+	Kind                     string   `json:"kind" binding:"required"`
+	IntervalMinutes          int64    `json:"interval_minutes"`
+	Times                    []string `json:"times"`
+	Days                     []int64  `json:"days"`
+	QuietStart               string   `json:"quiet_start"`
+	QuietEnd                 string   `json:"quiet_end"`
+	SkipIfDrankWithinMinutes int64    `json:"skip_if_drank_within_minutes"`
+	Enabled                  *bool    `json:"enabled"`
+}
+
+// toReminder enabledが未指定の場合は有効にする
+func (r *reminderRequest) toReminder(userId int64) *model.Reminder {
+	enabled := true
+	if r.Enabled != nil {
+		enabled = *r.Enabled
+	}
+	times, days := r.Times, r.Days
+	if times == nil {
+		times = []string{}
+	}
+	if days == nil {
+		days = []int64{}
+	}
+	return &model.Reminder{
+		UserID:                   userId,
+		Kind:                     r.Kind,
+		IntervalMinutes:          r.IntervalMinutes,
+		Times:                    times,
+		Days:                     days,
+		QuietStart:               r.QuietStart,
+		QuietEnd:                 r.QuietEnd,
+		SkipIfDrankWithinMinutes: r.SkipIfDrankWithinMinutes,
+		Enabled:                  enabled,
+	}
+}
+
+func (h *reminderHandler) HandleSearch(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	reminders, err := h.useCase.Search(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reminders)
+}
+
+func (h *reminderHandler) HandleCreate(c *gin.Context) {
+	requestBody := new(reminderRequest)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	reminder, err := h.useCase.Create(c.Request.Context(), requestBody.toReminder(userId))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reminder)
+}
+
+func (h *reminderHandler) HandleUpdate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	requestBody := new(reminderRequest)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	reminder := requestBody.toReminder(userId)
+	reminder.ID = id
+
+	reminder, err = h.useCase.Update(c.Request.Context(), reminder)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reminder)
+}
+
+func (h *reminderHandler) HandleDelete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.useCase.Delete(c.Request.Context(), userId, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "reminder delete successful"})
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type SessionHandler interface {
+	HandleRefresh(c *gin.Context)
+	HandleSearch(c *gin.Context)
+	HandleDelete(c *gin.Context)
+}
+
+type sessionHandler struct {
+	useCase usecase.SessionUseCase
+}
+
+func NewSessionHandler(sessionUseCase usecase.SessionUseCase) SessionHandler {
+	return &sessionHandler{
+		useCase: sessionUseCase,
+	}
+}
+
+// HandleRefresh リフレッシュトークンはCookieかbodyで受け取る
+// bodyで受け取った場合は、新しいトークンもbodyで返す
+func (h *sessionHandler) HandleRefresh(c *gin.Context) {
+	type (
+		request struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+	)
+
+	requestBody := new(request)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	refreshToken := requestBody.RefreshToken
+	if refreshToken == "" {
+		refreshToken, _ = c.Cookie(refreshTokenCookie)
+	}
+	if refreshToken == "" {
+		handleError(c, &util.UnauthorizedError{Err: errors.New("no refresh token")})
+		return
+	}
+
+	pair, err := h.useCase.Refresh(c.Request.Context(), refreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		var unauthorized *util.UnauthorizedError
+		if errors.As(err, &unauthorized) {
+			clearAuthCookies(c)
+		}
+		handleError(c, err)
+		return
+	}
+
+	if requestBody.RefreshToken != "" {
+		c.JSON(http.StatusOK, pair)
+		return
+	}
+
+	setAuthCookies(c, pair)
+	c.JSON(http.StatusOK, gin.H{"message": "refresh successful", "expires_in": pair.ExpiresIn})
+}
+
+func (h *sessionHandler) HandleSearch(c *gin.Context) {
+	principal, err := util.FindPrincipal(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	sessions, err := h.useCase.Search(c.Request.Context(), principal.UserID, principal.SessionID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *sessionHandler) HandleDelete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.useCase.Delete(c.Request.Context(), userId, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session delete successful"})
+}
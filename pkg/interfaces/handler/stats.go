@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type StatsHandler interface {
+	HandleStreaks(c *gin.Context)
+	HandlePattern(c *gin.Context)
+}
+
+type statsHandler struct {
+	useCase usecase.StatsUseCase
+}
+
+func NewStatsHandler(statsUseCase usecase.StatsUseCase) StatsHandler {
+	return &statsHandler{
+		useCase: statsUseCase,
+	}
+}
+
+func (h *statsHandler) HandleStreaks(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	stats, err := h.useCase.Streaks(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *statsHandler) HandlePattern(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	pattern, err := h.useCase.Pattern(c.Request.Context(), userId, days)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pattern)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type TokenHandler interface {
+	HandleSearch(c *gin.Context)
+	HandleCreate(c *gin.Context)
+	HandleRevoke(c *gin.Context)
+}
+
+type tokenHandler struct {
+	useCase usecase.TokenUseCase
+}
+
+func NewTokenHandler(tokenUseCase usecase.TokenUseCase) TokenHandler {
+	return &tokenHandler{
+		useCase: tokenUseCase,
+	}
+}
+
+func (h *tokenHandler) HandleSearch(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	tokens, err := h.useCase.Search(c.Request.Context(), userId)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// HandleCreate トークンそのものはこのレスポンスでしか返さない
+func (h *tokenHandler) HandleCreate(c *gin.Context) {
+	type (
+		request struct {
+			Name          string   `json:"name" binding:"required"`
+			Scopes        []string `json:"scopes" binding:"required"`
+			ExpiresInDays int64    `json:"expires_in_days"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	token, err := h.useCase.Create(c.Request.Context(), userId, requestBody.Name, requestBody.Scopes, requestBody.ExpiresInDays)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, token)
+}
+
+func (h *tokenHandler) HandleRevoke(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.useCase.Revoke(c.Request.Context(), userId, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "token revoke successful"})
+}
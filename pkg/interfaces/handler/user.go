@@ -2,10 +2,9 @@ package handler
 
 import (
 	"net/http"
-	"os"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
 	"github.com/mikaijun/aquagent/pkg/usecase"
 	"github.com/mikaijun/aquagent/pkg/util"
 )
@@ -15,6 +14,7 @@ type UserHandler interface {
 	HandleLogin(c *gin.Context)
 	HandleLogout(c *gin.Context)
 	HandleFetchUser(c *gin.Context)
+	HandleUpdateUser(c *gin.Context)
 }
 
 type userHandler struct {
@@ -33,6 +33,8 @@ func (h *userHandler) HandleSignup(c *gin.Context) {
 			Username string `json:"username" binding:"required"`
 			Email    string `json:"email" binding:"required,email"`
 			Password string `json:"password" binding:"required,min=8"`
+			// Locale 確認メールの言語。なければAccept-Languageで決める
+			Locale string `json:"locale"`
 		}
 		response struct {
 			ID       int64  `json:"id"`
@@ -48,19 +50,15 @@ func (h *userHandler) HandleSignup(c *gin.Context) {
 		return
 	}
 
-	user, err := h.useCase.Signup(c.Request.Context(), requestBody.Username, requestBody.Email, requestBody.Password)
+	locale := requestBody.Locale
+	if locale == "" {
+		locale = c.GetHeader("Accept-Language")
+	}
+
+	user, err := h.useCase.Signup(c.Request.Context(), requestBody.Username, requestBody.Email, requestBody.Password, locale)
 	if err != nil {
-		switch e := err.(type) {
-		case *util.InternalServerError:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": e.Err.Error()})
-			return
-		case *util.BadRequestError:
-			c.JSON(http.StatusBadRequest, gin.H{"error": e.Err.Error()})
-			return
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		handleError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, &response{
@@ -75,10 +73,13 @@ func (h *userHandler) HandleLogin(c *gin.Context) {
 		request struct {
 			Email    string `json:"email" binding:"required,email"`
 			Password string `json:"password" binding:"required"`
+			// ReturnTokens Cookieを使えないクライアントはtrueにして、トークンをbodyで受け取る
+			ReturnTokens bool `json:"return_tokens"`
 		}
 		response struct {
-			ID       int64  `json:"id"`
-			Username string `json:"username"`
+			ID       int64            `json:"id"`
+			Username string           `json:"username"`
+			Tokens   *model.TokenPair `json:"tokens,omitempty"`
 		}
 	)
 
@@ -89,27 +90,23 @@ func (h *userHandler) HandleLogin(c *gin.Context) {
 		return
 	}
 
-	signedString, user, err := h.useCase.Login(c.Request.Context(), requestBody.Email, requestBody.Password)
+	pair, user, err := h.useCase.Login(c.Request.Context(), requestBody.Email, requestBody.Password, c.Request.UserAgent(), c.ClientIP())
 
 	if err != nil {
-		switch e := err.(type) {
-		case *util.InternalServerError:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": e.Err.Error()})
-			return
-		case *util.BadRequestError:
-			c.JSON(http.StatusBadRequest, gin.H{"error": e.Err.Error()})
-			return
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+		handleError(c, err)
+		return
 	}
 
-	convertedUserId := strconv.FormatInt(user.ID, 10)
-	domain := os.Getenv("DOMAIN")
+	if requestBody.ReturnTokens {
+		c.JSON(http.StatusOK, &response{
+			ID:       user.ID,
+			Username: user.Username,
+			Tokens:   pair,
+		})
+		return
+	}
 
-	c.SetCookie("jwt", signedString, 60*60*24, "/", domain, false, true)
-	c.SetCookie("userId", convertedUserId, 60*60*24, "/", domain, false, true)
+	setAuthCookies(c, pair)
 
 	c.JSON(http.StatusOK, &response{
 		ID:       user.ID,
@@ -117,47 +114,144 @@ func (h *userHandler) HandleLogin(c *gin.Context) {
 	})
 }
 
+// HandleLogout リフレッシュトークンはCookieか、POSTの場合はbodyで受け取る
 func (h *userHandler) HandleLogout(c *gin.Context) {
-	c.SetCookie("jwt", "", -1, "", "", false, true)
-	c.SetCookie("userId", "", -1, "", "", false, true)
+	type (
+		request struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+	)
+
+	requestBody := new(request)
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	refreshToken := requestBody.RefreshToken
+	if refreshToken == "" {
+		refreshToken, _ = c.Cookie(refreshTokenCookie)
+	}
+
+	err := h.useCase.Logout(c.Request.Context(), refreshToken)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	clearAuthCookies(c)
 	c.JSON(http.StatusOK, gin.H{"message": "logout successful"})
 }
 
 func (h *userHandler) HandleFetchUser(c *gin.Context) {
 	type (
 		response struct {
-			ID       int64  `json:"id"`
-			Username string `json:"username"`
-			Email    string `json:"email"`
+			ID              int64   `json:"id"`
+			Username        string  `json:"username"`
+			Email           string  `json:"email"`
+			Timezone        string  `json:"timezone"`
+			WeightKg        float64 `json:"weight_kg"`
+			Age             int64   `json:"age"`
+			Sex             string  `json:"sex"`
+			ActivityLevel   string  `json:"activity_level"`
+			CaffeineLimitMg int64   `json:"caffeine_limit_mg"`
+			EmailVerified   bool    `json:"email_verified"`
 		}
 	)
 
-	userId, err := util.FindUserIdByCookie(c)
+	userId, err := util.FindUserId(c)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
 
 	user, err := h.useCase.Fetch(c.Request.Context(), userId)
 
 	if err != nil {
-		switch e := err.(type) {
-		case *util.InternalServerError:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": e.Err.Error()})
-			return
-		case *util.BadRequestError:
-			c.JSON(http.StatusBadRequest, gin.H{"error": e.Err.Error()})
-			return
-		default:
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &response{
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		Timezone:        user.Timezone,
+		WeightKg:        user.WeightKg,
+		Age:             user.Age,
+		Sex:             user.Sex,
+		ActivityLevel:   user.ActivityLevel,
+		CaffeineLimitMg: user.CaffeineLimitMg,
+		EmailVerified:   user.EmailVerifiedAt != nil,
+	})
+}
+
+func (h *userHandler) HandleUpdateUser(c *gin.Context) {
+	type (
+		request struct {
+			Username        string  `json:"username"`
+			Timezone        string  `json:"timezone"`
+			WeightKg        float64 `json:"weight_kg"`
+			Age             int64   `json:"age"`
+			Sex             string  `json:"sex"`
+			ActivityLevel   string  `json:"activity_level"`
+			CaffeineLimitMg int64   `json:"caffeine_limit_mg"`
 		}
+		response struct {
+			ID              int64   `json:"id"`
+			Username        string  `json:"username"`
+			Email           string  `json:"email"`
+			Timezone        string  `json:"timezone"`
+			WeightKg        float64 `json:"weight_kg"`
+			Age             int64   `json:"age"`
+			Sex             string  `json:"sex"`
+			ActivityLevel   string  `json:"activity_level"`
+			CaffeineLimitMg int64   `json:"caffeine_limit_mg"`
+			EmailVerified   bool    `json:"email_verified"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	user, err := h.useCase.Update(c.Request.Context(), &model.User{
+		ID:              userId,
+		Username:        requestBody.Username,
+		Timezone:        requestBody.Timezone,
+		WeightKg:        requestBody.WeightKg,
+		Age:             requestBody.Age,
+		Sex:             requestBody.Sex,
+		ActivityLevel:   requestBody.ActivityLevel,
+		CaffeineLimitMg: requestBody.CaffeineLimitMg,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, &response{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		Timezone:        user.Timezone,
+		WeightKg:        user.WeightKg,
+		Age:             user.Age,
+		Sex:             user.Sex,
+		ActivityLevel:   user.ActivityLevel,
+		CaffeineLimitMg: user.CaffeineLimitMg,
+		EmailVerified:   user.EmailVerifiedAt != nil,
 	})
 }
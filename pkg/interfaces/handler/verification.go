@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type VerificationHandler interface {
+	HandleVerify(c *gin.Context)
+	HandleResend(c *gin.Context)
+	HandleSend(c *gin.Context)
+}
+
+type verificationHandler struct {
+	useCase usecase.VerificationUseCase
+}
+
+func NewVerificationHandler(verificationUseCase usecase.VerificationUseCase) VerificationHandler {
+	return &verificationHandler{
+		useCase: verificationUseCase,
+	}
+}
+
+// HandleVerify メールのリンクから開くため、トークンはクエリで受け取る
+func (h *verificationHandler) HandleVerify(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	err := h.useCase.Verify(c.Request.Context(), token)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verification successful"})
+}
+
+// HandleResend 確認が必須の場合はログインできないため、メールアドレスで再送する
+func (h *verificationHandler) HandleResend(c *gin.Context) {
+	type (
+		request struct {
+			Email  string `json:"email" binding:"required,email"`
+			Locale string `json:"locale"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	locale := requestBody.Locale
+	if locale == "" {
+		locale = c.GetHeader("Accept-Language")
+	}
+
+	err := h.useCase.Resend(c.Request.Context(), requestBody.Email, locale)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if the email is registered and not verified, a verification link has been sent"})
+}
+
+// HandleSend ログイン中のユーザーに確認メールを送り直す
+func (h *verificationHandler) HandleSend(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.useCase.Send(c.Request.Context(), userId, c.GetHeader("Accept-Language"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
@@ -13,8 +13,13 @@ import (
 type WaterHandler interface {
 	HandleSearch(c *gin.Context)
 	HandleCreate(c *gin.Context)
+	HandleParse(c *gin.Context)
 	HandleCreateRandom(c *gin.Context)
+	HandleUpdate(c *gin.Context)
 	HandleDelete(c *gin.Context)
+	HandleDailySummary(c *gin.Context)
+	HandleWeeklyChart(c *gin.Context)
+	HandleMonthlyCalendar(c *gin.Context)
 }
 
 type waterHandler struct {
@@ -28,9 +33,9 @@ func NewWaterHandler(waterUseCase usecase.WaterUseCase) WaterHandler {
 }
 
 func (h *waterHandler) HandleSearch(c *gin.Context) {
-	userId, err := util.FindUserIdByCookie(c)
+	userId, err := util.FindUserId(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
 
@@ -57,8 +62,12 @@ func (h *waterHandler) HandleSearch(c *gin.Context) {
 func (h *waterHandler) HandleCreate(c *gin.Context) {
 	type (
 		request struct {
-			Volume  int64  `json:"volume" binding:"required"`
-			DrankAt string `json:"drank_at" binding:"required"`
+			Volume      int64    `json:"volume" binding:"required_without=ContainerID"`
+			BeverageID  int64    `json:"beverage_id"`
+			ContainerID int64    `json:"container_id"`
+			CaffeineMg  *float64 `json:"caffeine_mg"`
+			AlcoholG    *float64 `json:"alcohol_g"`
+			DrankAt     string   `json:"drank_at" binding:"required"`
 		}
 	)
 
@@ -69,26 +78,60 @@ func (h *waterHandler) HandleCreate(c *gin.Context) {
 		return
 	}
 
-	userId, err := util.FindUserIdByCookie(c)
+	userId, err := util.FindUserId(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
 
 	water := &model.Water{
-		Volume:  requestBody.Volume,
-		UserID:  userId,
-		DrankAt: requestBody.DrankAt,
+		Volume:      requestBody.Volume,
+		UserID:      userId,
+		BeverageID:  requestBody.BeverageID,
+		ContainerID: requestBody.ContainerID,
+		CaffeineMg:  requestBody.CaffeineMg,
+		AlcoholG:    requestBody.AlcoholG,
+		DrankAt:     requestBody.DrankAt,
 	}
 
 	water, err = h.useCase.Create(c.Request.Context(), water)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, water)
 }
 
+func (h *waterHandler) HandleParse(c *gin.Context) {
+	type (
+		request struct {
+			Text string `json:"text" binding:"required"`
+			// Create trueの場合は解析結果をそのまま記録する
+			Create bool `json:"create"`
+		}
+	)
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	waters, err := h.useCase.Parse(c.Request.Context(), userId, requestBody.Text, requestBody.Create)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, waters)
+}
+
 func (h *waterHandler) HandleCreateRandom(c *gin.Context) {
 	waters, err := h.useCase.CreateRandomWaters(c.Request.Context())
 	if err != nil {
@@ -99,6 +142,54 @@ func (h *waterHandler) HandleCreateRandom(c *gin.Context) {
 
 }
 
+func (h *waterHandler) HandleUpdate(c *gin.Context) {
+	type (
+		request struct {
+			Volume     int64    `json:"volume"`
+			BeverageID int64    `json:"beverage_id"`
+			CaffeineMg *float64 `json:"caffeine_mg"`
+			AlcoholG   *float64 `json:"alcohol_g"`
+			DrankAt    string   `json:"drank_at"`
+		}
+	)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	requestBody := new(request)
+
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	water := &model.Water{
+		ID:         id,
+		UserID:     userId,
+		Volume:     requestBody.Volume,
+		BeverageID: requestBody.BeverageID,
+		CaffeineMg: requestBody.CaffeineMg,
+		AlcoholG:   requestBody.AlcoholG,
+		DrankAt:    requestBody.DrankAt,
+	}
+
+	water, err = h.useCase.Update(c.Request.Context(), water)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, water)
+}
+
 func (h *waterHandler) HandleDelete(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -106,18 +197,75 @@ func (h *waterHandler) HandleDelete(c *gin.Context) {
 		return
 	}
 
-	_, err = util.FindUserIdByCookie(c)
+	userId, err := util.FindUserId(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
 
-	err = h.useCase.Delete(c.Request.Context(), id)
+	err = h.useCase.Delete(c.Request.Context(), userId, id)
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "water delete successful"})
 }
+
+func (h *waterHandler) HandleDailySummary(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	summaries, err := h.useCase.DailySummary(c.Request.Context(), userId, c.Query("start"), c.Query("end"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, summaries)
+}
+
+func (h *waterHandler) HandleWeeklyChart(c *gin.Context) {
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	chart, err := h.useCase.WeeklyChart(c.Request.Context(), userId, c.Query("start"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, chart)
+}
+
+func (h *waterHandler) HandleMonthlyCalendar(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	month, err := strconv.Atoi(c.Param("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := util.FindUserId(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	calendar, err := h.useCase.MonthlyCalendar(c.Request.Context(), userId, year, month)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, calendar)
+}
@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runPeriodically intervalごとにjobを実行する。ctxがキャンセルされるまで戻らない
+func runPeriodically(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context, now time.Time) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := job(ctx, now); err != nil {
+				log.Printf("%s failed: %v", name, err)
+			}
+		}
+	}
+}
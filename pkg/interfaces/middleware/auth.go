@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+// Auth JWTを検証し、クレームのユーザーをprincipalとしてリクエストに設定する
+// トークンはAuthorizationヘッダーのBearer、なければCookieから読む。認証できない場合はすべて401を返す
+// パーソナルアクセストークンの場合は、ルートに必要なスコープがなければ403を返す
+func Auth(tokenUseCase usecase.TokenUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		signedToken, err := findToken(c)
+		if err != nil {
+			unauthorized(c, err)
+			return
+		}
+
+		if strings.HasPrefix(signedToken, usecase.PersonalAccessTokenPrefix) {
+			authenticateToken(c, tokenUseCase, signedToken)
+			return
+		}
+
+		claims, err := util.ValidateToken(signedToken)
+		if err != nil {
+			unauthorized(c, err)
+			return
+		}
+
+		userId, err := strconv.ParseInt(claims.ID, 10, 64)
+		if err != nil || userId <= 0 {
+			unauthorized(c, errors.New("token has no valid user id"))
+			return
+		}
+
+		util.SetPrincipal(c, &util.Principal{
+			UserID:    userId,
+			Username:  claims.Username,
+			SessionID: claims.SessionID,
+		})
+
+		c.Next()
+	}
+}
+
+func authenticateToken(c *gin.Context, tokenUseCase usecase.TokenUseCase, token string) {
+	principal, err := tokenUseCase.Authenticate(c.Request.Context(), token)
+	if err != nil {
+		var unauthorizedErr *util.UnauthorizedError
+		if errors.As(err, &unauthorizedErr) {
+			unauthorized(c, err)
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	scope := requiredScope(c.Request.Method, c.FullPath())
+	if scope == "" || !principal.HasScope(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token does not have the required scope"})
+		return
+	}
+
+	util.SetPrincipal(c, principal)
+	c.Next()
+}
+
+// findToken Authorizationヘッダーがある場合はCookieを見ない
+func findToken(c *gin.Context) (string, error) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return "", errors.New("authorization header must be a bearer token")
+		}
+		return strings.TrimSpace(token), nil
+	}
+
+	signedToken, err := c.Cookie("jwt")
+	if err != nil || signedToken == "" {
+		return "", errors.New("no token set in authorization header or cookie")
+	}
+	return signedToken, nil
+}
+
+func unauthorized(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
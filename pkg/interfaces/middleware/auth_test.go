@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+const testKid = "test"
+
+// testKey テストの間だけ署名に使う鍵。TestMainでJWT_KEY_DIRに書き出して読み込む
+var testKey ed25519.PrivateKey
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	dir, err := os.MkdirTemp("", "keys")
+	if err != nil {
+		panic(err)
+	}
+	_, testKey, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(testKey)
+	if err != nil {
+		panic(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, testKid+".pem"), data, 0o600); err != nil {
+		panic(err)
+	}
+	os.Setenv("JWT_KEY_DIR", dir)
+	os.Setenv("JWT_ACTIVE_KID", testKid)
+	os.Setenv("JWT_SECRET_KEY", "secret")
+	if err := util.InitKeyRing(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestRouter Authを通ったリクエストのprincipalを返すルーター
+func newTestRouter(tokenUseCase usecase.TokenUseCase) *gin.Engine {
+	r := gin.New()
+	whoami := func(c *gin.Context) {
+		principal, err := util.FindPrincipal(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, principal)
+	}
+	group := r.Group("/v1").Use(Auth(tokenUseCase))
+	group.GET("/waters", whoami)
+	group.POST("/waters", whoami)
+	group.GET("/users", whoami)
+	return r
+}
+
+// request headerとcookiesを付けてGET /v1/watersを呼び出す
+func request(t *testing.T, r *gin.Engine, authorization string, cookies ...*http.Cookie) (int, *util.Principal) {
+	t.Helper()
+	return requestRoute(t, r, http.MethodGet, "/v1/waters", authorization, cookies...)
+}
+
+func requestRoute(t *testing.T, r *gin.Engine, method, path, authorization string, cookies ...*http.Cookie) (int, *util.Principal) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body struct {
+		util.Principal
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusOK {
+		if body.Error == "" {
+			t.Errorf("status %d has no error message", w.Code)
+		}
+		return w.Code, nil
+	}
+	return w.Code, &body.Principal
+}
+
+// signToken methodとkeyで署名し、kidが空でなければヘッダーに付ける
+func signToken(t *testing.T, claims *util.MyJWTClaims, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func testClaims(id string, expiresAt time.Time) *util.MyJWTClaims {
+	return &util.MyJWTClaims{
+		ID:               id,
+		Username:         "mikai",
+		SessionID:        "session",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(expiresAt)},
+	}
+}
+
+func validToken(t *testing.T) string {
+	t.Helper()
+	signed, err := util.GenerateSignedString(1, "mikai", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestAuthAcceptsJWT(t *testing.T) {
+	r := newTestRouter(nil)
+
+	status, principal := request(t, r, "", &http.Cookie{Name: "jwt", Value: validToken(t)})
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	if principal.UserID != 1 || principal.Username != "mikai" || principal.SessionID != "session" || principal.IsToken() {
+		t.Errorf("principal = %+v, want user 1 from the claims", principal)
+	}
+}
+
+func TestAuthRejectsInvalidJWT(t *testing.T) {
+	r := newTestRouter(nil)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	future := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "malformed", token: "not.a.jwt"},
+		{name: "expired", token: signToken(t, testClaims("1", time.Now().Add(-time.Minute)), jwt.SigningMethodEdDSA, testKid, testKey)},
+		{name: "unknown kid", token: signToken(t, testClaims("1", future), jwt.SigningMethodEdDSA, "other", otherKey)},
+		{name: "no kid", token: signToken(t, testClaims("1", future), jwt.SigningMethodEdDSA, "", testKey)},
+		{name: "signed by another key with the kid", token: signToken(t, testClaims("1", future), jwt.SigningMethodEdDSA, testKid, otherKey)},
+		// 鍵が読み込まれている場合はJWT_SECRET_KEYによる署名を受け付けない
+		{name: "hs256", token: signToken(t, testClaims("1", future), jwt.SigningMethodHS256, testKid, []byte("secret"))},
+		{name: "no user id", token: signToken(t, testClaims("", future), jwt.SigningMethodEdDSA, testKid, testKey)},
+		{name: "invalid user id", token: signToken(t, testClaims("0", future), jwt.SigningMethodEdDSA, testKid, testKey)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, _ := request(t, r, "", &http.Cookie{Name: "jwt", Value: tt.token}); status != http.StatusUnauthorized {
+				t.Errorf("status = %d, want 401", status)
+			}
+		})
+	}
+}
+
+func TestAuthRequiresToken(t *testing.T) {
+	r := newTestRouter(nil)
+
+	if status, _ := request(t, r, ""); status != http.StatusUnauthorized {
+		t.Errorf("status without token = %d, want 401", status)
+	}
+	if status, _ := request(t, r, "", &http.Cookie{Name: "jwt", Value: ""}); status != http.StatusUnauthorized {
+		t.Errorf("status with empty cookie = %d, want 401", status)
+	}
+}
+
+// 以前ユーザーを判別していたuserIdのCookieは使わない
+func TestAuthIgnoresUserIdCookie(t *testing.T) {
+	r := newTestRouter(nil)
+
+	if status, _ := request(t, r, "", &http.Cookie{Name: "userId", Value: "1"}); status != http.StatusUnauthorized {
+		t.Errorf("status with only the userId cookie = %d, want 401", status)
+	}
+
+	status, principal := request(t, r, "", &http.Cookie{Name: "jwt", Value: validToken(t)}, &http.Cookie{Name: "userId", Value: "2"})
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, want 200", status)
+	}
+	if principal.UserID != 1 {
+		t.Errorf("UserID = %d, want 1 from the claims", principal.UserID)
+	}
+}
+
+func TestAuthBearerHeader(t *testing.T) {
+	r := newTestRouter(nil)
+	token := validToken(t)
+
+	tests := []struct {
+		name          string
+		authorization string
+		cookie        string
+		want          int
+	}{
+		{name: "bearer", authorization: "Bearer " + token, want: http.StatusOK},
+		{name: "scheme is case insensitive", authorization: "bearer " + token, want: http.StatusOK},
+		{name: "surrounding spaces", authorization: "Bearer   " + token + " ", want: http.StatusOK},
+		// ヘッダーがある場合はCookieを見ない
+		{name: "header takes precedence over cookie", authorization: "Bearer " + token, cookie: "invalid", want: http.StatusOK},
+		{name: "invalid header does not fall back to cookie", authorization: "Bearer invalid", cookie: token, want: http.StatusUnauthorized},
+		{name: "wrong scheme", authorization: "Basic " + token, cookie: token, want: http.StatusUnauthorized},
+		{name: "token without scheme", authorization: token, cookie: token, want: http.StatusUnauthorized},
+		{name: "empty token", authorization: "Bearer  ", cookie: token, want: http.StatusUnauthorized},
+		{name: "scheme only", authorization: "Bearer", cookie: token, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cookies []*http.Cookie
+			if tt.cookie != "" {
+				cookies = append(cookies, &http.Cookie{Name: "jwt", Value: tt.cookie})
+			}
+			status, principal := request(t, r, tt.authorization, cookies...)
+			if status != tt.want {
+				t.Fatalf("status = %d, want %d", status, tt.want)
+			}
+			if status == http.StatusOK && principal.UserID != 1 {
+				t.Errorf("UserID = %d, want 1", principal.UserID)
+			}
+		})
+	}
+}
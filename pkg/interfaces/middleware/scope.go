@@ -0,0 +1,27 @@
+package middleware
+
+import "github.com/mikaijun/aquagent/pkg/domain/model"
+
+// routeScopes パーソナルアクセストークンで呼び出せるルートと必要なスコープ
+// ここにないルートはログインしたユーザーだけが呼び出せる
+var routeScopes = map[string]string{
+	"GET /v1/waters":                       model.ScopeWatersRead,
+	"GET /v1/waters/summary/daily":         model.ScopeWatersRead,
+	"GET /v1/waters/chart/week":            model.ScopeWatersRead,
+	"GET /v1/waters/calendar/:year/:month": model.ScopeWatersRead,
+	"GET /v1/beverages":                    model.ScopeWatersRead,
+	"GET /v1/containers":                   model.ScopeWatersRead,
+	"POST /v1/waters":                      model.ScopeWatersWrite,
+	"POST /v1/waters/parse":                model.ScopeWatersWrite,
+	"PATCH /v1/waters/:id":                 model.ScopeWatersWrite,
+	"DELETE /v1/waters/:id":                model.ScopeWatersWrite,
+	"GET /v1/goals":                        model.ScopeStatsRead,
+	"GET /v1/stats/streaks":                model.ScopeStatsRead,
+	"GET /v1/stats/pattern":                model.ScopeStatsRead,
+	"GET /v1/achievements":                 model.ScopeStatsRead,
+}
+
+// requiredScope 登録されていないルートは空文字を返す
+func requiredScope(method, path string) string {
+	return routeScopes[method+" "+path]
+}
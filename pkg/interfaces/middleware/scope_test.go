@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+// fakeTokenUseCase tokensに登録したパーソナルアクセストークンだけを認証する
+type fakeTokenUseCase struct {
+	usecase.TokenUseCase
+	tokens map[string]*util.Principal
+	err    error
+}
+
+func (uc *fakeTokenUseCase) Authenticate(c context.Context, token string) (*util.Principal, error) {
+	if uc.err != nil {
+		return nil, uc.err
+	}
+	principal, ok := uc.tokens[token]
+	if !ok {
+		return nil, &util.UnauthorizedError{Err: errors.New("token is invalid")}
+	}
+	return principal, nil
+}
+
+func TestRouteScopes(t *testing.T) {
+	known := map[string]bool{}
+	for _, scope := range model.Scopes {
+		known[scope] = true
+	}
+	for route, scope := range routeScopes {
+		if !known[scope] {
+			t.Errorf("route %q requires unknown scope %q", route, scope)
+		}
+	}
+
+	if got := requiredScope(http.MethodGet, "/v1/waters"); got != model.ScopeWatersRead {
+		t.Errorf("requiredScope(GET /v1/waters) = %q, want %q", got, model.ScopeWatersRead)
+	}
+	if got := requiredScope(http.MethodPost, "/v1/waters"); got != model.ScopeWatersWrite {
+		t.Errorf("requiredScope(POST /v1/waters) = %q, want %q", got, model.ScopeWatersWrite)
+	}
+	// トークンの管理やアカウントの操作はログインしたユーザーだけができる
+	for _, route := range [][2]string{{http.MethodGet, "/v1/users"}, {http.MethodPost, "/v1/tokens"}, {http.MethodDelete, "/v1/sessions/:id"}} {
+		if got := requiredScope(route[0], route[1]); got != "" {
+			t.Errorf("requiredScope(%s %s) = %q, want none", route[0], route[1], got)
+		}
+	}
+}
+
+func TestAuthPersonalAccessToken(t *testing.T) {
+	readToken := usecase.PersonalAccessTokenPrefix + "read"
+	tokenUseCase := &fakeTokenUseCase{tokens: map[string]*util.Principal{
+		readToken: {UserID: 1, TokenID: 10, Scopes: []string{model.ScopeWatersRead}},
+	}}
+	r := newTestRouter(tokenUseCase)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{name: "with the scope", method: http.MethodGet, path: "/v1/waters", token: readToken, want: http.StatusOK},
+		{name: "without the scope", method: http.MethodPost, path: "/v1/waters", token: readToken, want: http.StatusForbidden},
+		{name: "route without scopes", method: http.MethodGet, path: "/v1/users", token: readToken, want: http.StatusForbidden},
+		{name: "unknown token", method: http.MethodGet, path: "/v1/waters", token: usecase.PersonalAccessTokenPrefix + "unknown", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, principal := requestRoute(t, r, tt.method, tt.path, "Bearer "+tt.token)
+			if status != tt.want {
+				t.Fatalf("status = %d, want %d", status, tt.want)
+			}
+			if status == http.StatusOK && (principal.UserID != 1 || principal.TokenID != 10) {
+				t.Errorf("principal = %+v, want token 10 of user 1", principal)
+			}
+		})
+	}
+
+	// ログインしたユーザーはスコープに関係なく呼び出せる
+	if status, _ := requestRoute(t, r, http.MethodGet, "/v1/users", "Bearer "+validToken(t)); status != http.StatusOK {
+		t.Errorf("status with a login token = %d, want 200", status)
+	}
+}
+
+func TestAuthPersonalAccessTokenError(t *testing.T) {
+	r := newTestRouter(&fakeTokenUseCase{err: errors.New("connection reset")})
+
+	if status, _ := request(t, r, "Bearer "+usecase.PersonalAccessTokenPrefix+"read"); status != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", status)
+	}
+}
@@ -1,40 +1,215 @@
 package interfaces
 
 import (
+	"context"
 	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mikaijun/aquagent/pkg/domain/coach"
+	"github.com/mikaijun/aquagent/pkg/domain/line"
+	"github.com/mikaijun/aquagent/pkg/domain/mailer"
+	"github.com/mikaijun/aquagent/pkg/domain/notifier"
 	"github.com/mikaijun/aquagent/pkg/infrastructure"
+	"github.com/mikaijun/aquagent/pkg/infrastructure/coachimpl"
+	"github.com/mikaijun/aquagent/pkg/infrastructure/lineimpl"
+	"github.com/mikaijun/aquagent/pkg/infrastructure/mailerimpl"
+	"github.com/mikaijun/aquagent/pkg/infrastructure/notifierimpl"
 	"github.com/mikaijun/aquagent/pkg/infrastructure/repositoryimpl"
 	"github.com/mikaijun/aquagent/pkg/interfaces/handler"
+	"github.com/mikaijun/aquagent/pkg/interfaces/middleware"
 	"github.com/mikaijun/aquagent/pkg/usecase"
+	"github.com/mikaijun/aquagent/pkg/util"
 )
 
 var r *gin.Engine
 
+const (
+	// alertEvaluationInterval 脱水リスクを判定する間隔
+	alertEvaluationInterval = 5 * time.Minute
+	// reminderDispatchInterval 通知すべきリマインダーを確認する間隔
+	reminderDispatchInterval = time.Minute
+	// webPushTimeout プッシュサービスへのリクエストのタイムアウト
+	webPushTimeout = 10 * time.Second
+	// lineAPITimeout LINE Messaging APIへのリクエストのタイムアウト
+	lineAPITimeout = 10 * time.Second
+	// coachAPITimeout LLMの応答を待つ時間
+	coachAPITimeout = 25 * time.Second
+	// mailDeliveryInterval outboxのメールを送る間隔
+	mailDeliveryInterval = 30 * time.Second
+	// achievementEvaluationInterval 記録を操作したユーザーのバッジを判定する間隔
+	achievementEvaluationInterval = 10 * time.Second
+)
+
 func Serve(addr string) {
+	if err := util.InitKeyRing(); err != nil {
+		log.Fatalf("Load jwt keys failed. %+v", err)
+	}
+
 	userRepoImpl := repositoryimpl.NewUserRepositoryImpl(infrastructure.Conn)
 	waterRepoImpl := repositoryimpl.NewWaterRepositoryImpl(infrastructure.Conn)
-	userUseCase := usecase.NewUserUseCase(userRepoImpl)
-	waterUseCase := usecase.NewWaterUseCase(waterRepoImpl)
+	goalRepoImpl := repositoryimpl.NewGoalRepositoryImpl(infrastructure.Conn)
+	beverageRepoImpl := repositoryimpl.NewBeverageRepositoryImpl(infrastructure.Conn)
+	containerRepoImpl := repositoryimpl.NewContainerRepositoryImpl(infrastructure.Conn)
+	achievementRepoImpl := repositoryimpl.NewAchievementRepositoryImpl(infrastructure.Conn)
+	alertRepoImpl := repositoryimpl.NewAlertRepositoryImpl(infrastructure.Conn)
+	reminderRepoImpl := repositoryimpl.NewReminderRepositoryImpl(infrastructure.Conn)
+	pushRepoImpl := repositoryimpl.NewPushSubscriptionRepositoryImpl(infrastructure.Conn)
+	notifiers := []notifier.Notifier{notifierimpl.NewLogNotifier()}
+	vapidKeys, err := notifierimpl.LoadVAPIDKeys()
+	if err != nil {
+		log.Fatalf("Load vapid keys failed. %+v", err)
+	}
+	var vapidPublicKey string
+	if vapidKeys != nil {
+		vapidPublicKey = vapidKeys.PublicKey
+		notifiers = append(notifiers, notifierimpl.NewWebPushNotifier(
+			pushRepoImpl,
+			vapidKeys,
+			os.Getenv("VAPID_SUBJECT"),
+			notifierimpl.NewPushHTTPClient(webPushTimeout),
+		))
+	}
+	lineRepoImpl := repositoryimpl.NewLineRepositoryImpl(infrastructure.Conn)
+	// アクセストークンが設定されていない環境ではLINEに送らない
+	var lineClient line.Client = lineimpl.NewLogClient()
+	if accessToken := os.Getenv("LINE_CHANNEL_ACCESS_TOKEN"); accessToken != "" {
+		baseURL := os.Getenv("LINE_API_BASE_URL")
+		if baseURL == "" {
+			baseURL = lineimpl.DefaultBaseURL
+		}
+		lineClient = lineimpl.NewHTTPClient(baseURL, accessToken, &http.Client{Timeout: lineAPITimeout})
+		notifiers = append(notifiers, notifierimpl.NewLineNotifier(lineRepoImpl, lineClient))
+	}
+	notifierImpl := notifierimpl.NewMultiNotifier(notifiers...)
+	sessionRepoImpl := repositoryimpl.NewSessionRepositoryImpl(infrastructure.Conn)
+	tokenRepoImpl := repositoryimpl.NewTokenRepositoryImpl(infrastructure.Conn)
+	passwordResetRepoImpl := repositoryimpl.NewPasswordResetRepositoryImpl(infrastructure.Conn)
+	verificationRepoImpl := repositoryimpl.NewEmailVerificationRepositoryImpl(infrastructure.Conn)
+	mailRepoImpl := repositoryimpl.NewMailRepositoryImpl(infrastructure.Conn)
+	// SMTPサーバーが設定されていない環境ではメールをログに出力する
+	var mailerImpl mailer.Mailer = mailerimpl.NewLogMailer()
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		mailerImpl = mailerimpl.NewSMTPMailer(host, os.Getenv("SMTP_PORT"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+	}
+	sessionUseCase := usecase.NewSessionUseCase(sessionRepoImpl, userRepoImpl)
+	tokenUseCase := usecase.NewTokenUseCase(tokenRepoImpl)
+	mailUseCase := usecase.NewMailUseCase(mailRepoImpl, mailerImpl)
+	passwordUseCase := usecase.NewPasswordUseCase(userRepoImpl, passwordResetRepoImpl, mailUseCase, os.Getenv("PASSWORD_RESET_URL"))
+	verificationUseCase := usecase.NewVerificationUseCase(verificationRepoImpl, userRepoImpl, mailUseCase, os.Getenv("EMAIL_VERIFICATION_URL"))
+	// 未設定や不正な値の場合は確認しなくてもログインできる
+	requireEmailVerification, _ := strconv.ParseBool(os.Getenv("REQUIRE_EMAIL_VERIFICATION"))
+	userUseCase := usecase.NewUserUseCase(userRepoImpl, sessionRepoImpl, goalRepoImpl, verificationUseCase, requireEmailVerification)
+	achievementUseCase := usecase.NewAchievementUseCase(achievementRepoImpl, waterRepoImpl, userRepoImpl, goalRepoImpl)
+	waterUseCase := usecase.NewWaterUseCase(waterRepoImpl, userRepoImpl, goalRepoImpl, beverageRepoImpl, containerRepoImpl, achievementUseCase)
+	goalUseCase := usecase.NewGoalUseCase(goalRepoImpl, userRepoImpl)
+	beverageUseCase := usecase.NewBeverageUseCase(beverageRepoImpl)
+	containerUseCase := usecase.NewContainerUseCase(containerRepoImpl, beverageRepoImpl)
+	statsUseCase := usecase.NewStatsUseCase(waterRepoImpl, userRepoImpl, goalRepoImpl)
+	alertUseCase := usecase.NewAlertUseCase(alertRepoImpl, waterRepoImpl, userRepoImpl, notifierImpl)
+	reminderUseCase := usecase.NewReminderUseCase(reminderRepoImpl, waterRepoImpl, userRepoImpl, notifierImpl)
+	pushUseCase := usecase.NewPushUseCase(pushRepoImpl, vapidPublicKey)
+	lineUseCase := usecase.NewLineUseCase(lineRepoImpl, beverageRepoImpl, waterUseCase, lineClient, os.Getenv("LINE_CHANNEL_SECRET"))
+	coachRepoImpl := repositoryimpl.NewCoachRepositoryImpl(infrastructure.Conn)
+	// LLMのAPIが設定されていない環境では規則に従って助言する
+	var coachImpl coach.Coach = coachimpl.NewRuleBasedCoach()
+	if baseURL := os.Getenv("COACH_API_BASE_URL"); baseURL != "" {
+		openAICoach := coachimpl.NewOpenAICoach(baseURL, os.Getenv("COACH_API_KEY"), os.Getenv("COACH_MODEL"), &http.Client{Timeout: coachAPITimeout})
+		coachImpl = coachimpl.NewFallbackCoach(openAICoach, coachImpl)
+	}
+	coachUseCase := usecase.NewCoachUseCase(coachRepoImpl, waterRepoImpl, userRepoImpl, waterUseCase, statsUseCase, coachImpl)
 	userHandler := handler.NewUserHandler(userUseCase)
 	waterHandler := handler.NewWaterHandler(waterUseCase)
+	goalHandler := handler.NewGoalHandler(goalUseCase)
+	beverageHandler := handler.NewBeverageHandler(beverageUseCase)
+	containerHandler := handler.NewContainerHandler(containerUseCase)
+	statsHandler := handler.NewStatsHandler(statsUseCase)
+	achievementHandler := handler.NewAchievementHandler(achievementUseCase)
+	alertHandler := handler.NewAlertHandler(alertUseCase)
+	reminderHandler := handler.NewReminderHandler(reminderUseCase)
+	pushHandler := handler.NewPushHandler(pushUseCase)
+	lineHandler := handler.NewLineHandler(lineUseCase)
+	coachHandler := handler.NewCoachHandler(coachUseCase)
+	sessionHandler := handler.NewSessionHandler(sessionUseCase)
+	tokenHandler := handler.NewTokenHandler(tokenUseCase)
+	passwordHandler := handler.NewPasswordHandler(passwordUseCase)
+	verificationHandler := handler.NewVerificationHandler(verificationUseCase)
+	jwksHandler := handler.NewJWKSHandler()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runPeriodically(ctx, "alert evaluation", alertEvaluationInterval, alertUseCase.EvaluateAll)
+	go runPeriodically(ctx, "reminder dispatch", reminderDispatchInterval, reminderUseCase.DispatchDue)
+	go runPeriodically(ctx, "mail delivery", mailDeliveryInterval, mailUseCase.DeliverPending)
+	go runPeriodically(ctx, "achievement evaluation", achievementEvaluationInterval, achievementUseCase.EvaluatePending)
 
 	r = gin.Default()
 
 	r.POST("/signup", userHandler.HandleSignup)
 	r.POST("/login", userHandler.HandleLogin)
 	r.GET("/logout", userHandler.HandleLogout)
+	r.POST("/logout", userHandler.HandleLogout)
+	r.POST("/auth/refresh", sessionHandler.HandleRefresh)
+	r.POST("/password/forgot", passwordHandler.HandleForgot)
+	r.POST("/password/reset", passwordHandler.HandleReset)
+	r.GET("/verify-email", verificationHandler.HandleVerify)
+	r.POST("/verify-email/resend", verificationHandler.HandleResend)
+	r.GET("/.well-known/jwks.json", jwksHandler.HandleJWKS)
 	r.GET("/random", waterHandler.HandleCreateRandom)
+	r.GET("/push/vapid-public-key", pushHandler.HandlePublicKey)
+	r.POST("/line/webhook", lineHandler.HandleWebhook)
 	// NOTE: Render.comのスリープ対策のため、定期的にアクセスするエンドポイントを追加
 	r.GET("/cron")
 
-	group := r.Group("/v1").Use(Middleware())
+	group := r.Group("/v1").Use(middleware.Auth(tokenUseCase))
 
 	group.GET("/users", userHandler.HandleFetchUser)
+	group.PATCH("/users", userHandler.HandleUpdateUser)
+	group.POST("/users/verification", verificationHandler.HandleSend)
+	group.GET("/sessions", sessionHandler.HandleSearch)
+	group.DELETE("/sessions/:id", sessionHandler.HandleDelete)
+	group.GET("/tokens", tokenHandler.HandleSearch)
+	group.POST("/tokens", tokenHandler.HandleCreate)
+	group.DELETE("/tokens/:id", tokenHandler.HandleRevoke)
 	group.GET("/waters", waterHandler.HandleSearch)
+	group.GET("/waters/summary/daily", waterHandler.HandleDailySummary)
+	group.GET("/waters/chart/week", waterHandler.HandleWeeklyChart)
+	group.GET("/waters/calendar/:year/:month", waterHandler.HandleMonthlyCalendar)
 	group.POST("/waters", waterHandler.HandleCreate)
+	group.POST("/waters/parse", waterHandler.HandleParse)
+	group.PATCH("/waters/:id", waterHandler.HandleUpdate)
 	group.DELETE("/waters/:id", waterHandler.HandleDelete)
+	group.GET("/beverages", beverageHandler.HandleSearch)
+	group.POST("/beverages", beverageHandler.HandleCreate)
+	group.DELETE("/beverages/:id", beverageHandler.HandleDelete)
+	group.GET("/containers", containerHandler.HandleSearch)
+	group.POST("/containers", containerHandler.HandleCreate)
+	group.PUT("/containers/:id", containerHandler.HandleUpdate)
+	group.DELETE("/containers/:id", containerHandler.HandleDelete)
+	group.GET("/goals", goalHandler.HandleFetch)
+	group.PUT("/goals", goalHandler.HandleSet)
+	group.GET("/goals/recommendation", goalHandler.HandleRecommend)
+	group.GET("/stats/streaks", statsHandler.HandleStreaks)
+	group.GET("/stats/pattern", statsHandler.HandlePattern)
+	group.GET("/achievements", achievementHandler.HandleSearch)
+	group.GET("/alerts", alertHandler.HandleSearch)
+	group.POST("/alerts/:id/ack", alertHandler.HandleAcknowledge)
+	group.GET("/alerts/settings", alertHandler.HandleFetchSettings)
+	group.PUT("/alerts/settings", alertHandler.HandleUpdateSettings)
+	group.GET("/reminders", reminderHandler.HandleSearch)
+	group.POST("/reminders", reminderHandler.HandleCreate)
+	group.PUT("/reminders/:id", reminderHandler.HandleUpdate)
+	group.DELETE("/reminders/:id", reminderHandler.HandleDelete)
+	group.POST("/push/subscriptions", pushHandler.HandleSubscribe)
+	group.DELETE("/push/subscriptions", pushHandler.HandleUnsubscribe)
+	group.POST("/line/link-code", lineHandler.HandleIssueLinkCode)
+	group.DELETE("/line/link", lineHandler.HandleUnlink)
+	group.GET("/coach/messages", coachHandler.HandleHistory)
+	group.POST("/coach/messages", coachHandler.HandleSend)
+	group.DELETE("/coach/messages", coachHandler.HandleClear)
 
 	log.Println("Server running...")
 	if err := r.Run(addr); err != nil {
@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+// achievementBatchSize 1回のジョブで判定するユーザーの最大数
+const achievementBatchSize = 100
+
+type achievementMetric int
+
+const (
+	// metricTotalLogs これまでの記録の件数
+	metricTotalLogs achievementMetric = iota
+	// metricMaxDailyVolume 1日に水分補給として数える量(ml)の最大値。目標の判定と同じ量を使う
+	metricMaxDailyVolume
+	// metricGoalMetDays 目標を達成した日数
+	metricGoalMetDays
+	// metricLongestStreak 目標を連続で達成した最長日数
+	metricLongestStreak
+)
+
+// badgeRule metricの値がTarget以上になったときにバッジを獲得する
+type badgeRule struct {
+	Key         string
+	Name        string
+	Description string
+	Metric      achievementMetric
+	Target      int64
+}
+
+// badgeRules バッジを追加する場合はここに定義する。Keyは保存に使うため変更しないこと
+var badgeRules = []badgeRule{
+	{Key: "first_log", Name: "はじめの一杯", Description: "はじめて水分摂取を記録した", Metric: metricTotalLogs, Target: 1},
+	{Key: "logs_100", Name: "記録の達人", Description: "100回記録した", Metric: metricTotalLogs, Target: 100},
+	{Key: "logs_1000", Name: "記録マスター", Description: "1000回記録した", Metric: metricTotalLogs, Target: 1000},
+	{Key: "first_goal", Name: "目標達成", Description: "はじめて1日の目標を達成した", Metric: metricGoalMetDays, Target: 1},
+	{Key: "first_2l_day", Name: "2リットルの日", Description: "1日に2L以上飲んだ", Metric: metricMaxDailyVolume, Target: 2000},
+	{Key: "first_3l_day", Name: "3リットルの日", Description: "1日に3L以上飲んだ", Metric: metricMaxDailyVolume, Target: 3000},
+	{Key: "streak_7", Name: "1週間継続", Description: "7日連続で目標を達成した", Metric: metricLongestStreak, Target: 7},
+	{Key: "streak_30", Name: "1ヶ月継続", Description: "30日連続で目標を達成した", Metric: metricLongestStreak, Target: 30},
+	{Key: "streak_100", Name: "100日継続", Description: "100日連続で目標を達成した", Metric: metricLongestStreak, Target: 100},
+}
+
+type AchievementUseCase interface {
+	Search(c context.Context, userId int64) ([]*model.Achievement, error)
+	// Evaluate 条件を満たした未獲得のバッジを記録し、新たに獲得したバッジを返す
+	// 一度獲得したバッジは記録を削除しても取り消さない
+	Evaluate(c context.Context, userId int64) ([]*model.Achievement, error)
+	// Schedule 全期間の記録を読むためリクエストの中では判定せず、EvaluatePendingでまとめて判定する
+	// 判定待ちはDBに保存するため、再起動しても失われない
+	Schedule(c context.Context, userId int64) error
+	// EvaluatePending Scheduleされたユーザーのバッジを判定する。定期実行のジョブから呼ぶ
+	// 判定に失敗したユーザーは判定待ちのまま残し、次の実行で判定し直す
+	EvaluatePending(c context.Context, now time.Time) error
+}
+
+type achievementUseCase struct {
+	repository      repository.AchievementRepository
+	waterRepository repository.WaterRepository
+	userRepository  repository.UserRepository
+	goalRepository  repository.GoalRepository
+	timeout         time.Duration
+}
+
+func NewAchievementUseCase(
+	achievementRepo repository.AchievementRepository,
+	waterRepo repository.WaterRepository,
+	userRepo repository.UserRepository,
+	goalRepo repository.GoalRepository,
+) AchievementUseCase {
+	return &achievementUseCase{
+		repository:      achievementRepo,
+		waterRepository: waterRepo,
+		userRepository:  userRepo,
+		goalRepository:  goalRepo,
+		timeout:         time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *achievementUseCase) Search(c context.Context, userId int64) ([]*model.Achievement, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	metrics, err := uc.metrics(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	earned, err := uc.earned(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	achievements := make([]*model.Achievement, 0, len(badgeRules))
+	for _, rule := range badgeRules {
+		achievement := newAchievement(rule, metrics[rule.Metric])
+		if earnedAt, ok := earned[rule.Key]; ok {
+			achievement.Earned = true
+			achievement.EarnedAt = &earnedAt
+			achievement.Progress = rule.Target
+		}
+		achievements = append(achievements, achievement)
+	}
+
+	return achievements, nil
+}
+
+func (uc *achievementUseCase) Evaluate(c context.Context, userId int64) ([]*model.Achievement, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	metrics, err := uc.metrics(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	earned, err := uc.earned(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var newlyEarned []*model.Achievement
+	for _, rule := range badgeRules {
+		if _, ok := earned[rule.Key]; ok || metrics[rule.Metric] < rule.Target {
+			continue
+		}
+
+		err := uc.repository.CreateAchievement(ctx, &model.UserAchievement{
+			UserID:   userId,
+			BadgeKey: rule.Key,
+		})
+		if err != nil {
+			return nil, &util.InternalServerError{Err: err}
+		}
+
+		achievement := newAchievement(rule, metrics[rule.Metric])
+		achievement.Earned = true
+		newlyEarned = append(newlyEarned, achievement)
+	}
+
+	return newlyEarned, nil
+}
+
+func (uc *achievementUseCase) Schedule(c context.Context, userId int64) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	// 続けて記録しても判定待ちは1ユーザー1件にまとまる
+	if err := uc.repository.ScheduleEvaluation(ctx, userId); err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+	return nil
+}
+
+func (uc *achievementUseCase) EvaluatePending(c context.Context, now time.Time) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	evaluations, err := uc.repository.GetPendingEvaluations(ctx, achievementBatchSize)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	// 1人の判定に失敗しても他のユーザーの判定は続ける
+	for _, evaluation := range evaluations {
+		if _, err := uc.Evaluate(c, evaluation.UserID); err != nil {
+			log.Printf("failed to evaluate achievements for user %d: %v", evaluation.UserID, err)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(c, uc.timeout)
+		err := uc.repository.CompleteEvaluation(ctx, evaluation)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// earned バッジのKeyをキーにした獲得日時を返す
+func (uc *achievementUseCase) earned(ctx context.Context, userId int64) (map[string]string, error) {
+	achievements, err := uc.repository.GetAchievements(ctx, userId)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	earned := make(map[string]string, len(achievements))
+	for _, achievement := range achievements {
+		earned[achievement.BadgeKey] = achievement.EarnedAt
+	}
+	return earned, nil
+}
+
+func (uc *achievementUseCase) metrics(ctx context.Context, userId int64) (map[achievementMetric]int64, error) {
+	user, loc, err := userLocation(ctx, uc.userRepository, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := uc.waterRepository.CountWaters(ctx, userId)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	results, totals, err := goalResults(ctx, uc.waterRepository, uc.goalRepository, user, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := map[achievementMetric]int64{
+		metricTotalLogs:     count,
+		metricLongestStreak: computeStreaks(results, today(loc)).LongestStreak,
+	}
+	for _, total := range totals {
+		if total.EffectiveVolume > metrics[metricMaxDailyVolume] {
+			metrics[metricMaxDailyVolume] = total.EffectiveVolume
+		}
+	}
+	for _, met := range results {
+		if met {
+			metrics[metricGoalMetDays]++
+		}
+	}
+
+	return metrics, nil
+}
+
+func newAchievement(rule badgeRule, value int64) *model.Achievement {
+	progress := value
+	if progress > rule.Target {
+		progress = rule.Target
+	}
+	return &model.Achievement{
+		Key:         rule.Key,
+		Name:        rule.Name,
+		Description: rule.Description,
+		Progress:    progress,
+		Target:      rule.Target,
+	}
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+func earnedKeys(repo *fakeAchievementRepository) map[string]int {
+	keys := map[string]int{}
+	for _, achievement := range repo.achievements {
+		keys[achievement.BadgeKey]++
+	}
+	return keys
+}
+
+func TestAchievementUseCaseMaxDailyVolumeUsesEffectiveVolume(t *testing.T) {
+	const beer = 3
+	// 2.5L飲んでいても、アルコールの分を差し引くと2Lに届かない
+	waterRepo := newFakeWaterRepository(
+		&model.Water{UserID: 1, Volume: 1500, DrankAt: "2024-06-01 08:00:00"},
+		&model.Water{UserID: 1, Volume: 1000, DrankAt: "2024-06-01 20:00:00", BeverageID: beer},
+	)
+	waterRepo.factors = map[int64]float64{beer: -0.5}
+	achievementRepo := &fakeAchievementRepository{}
+	uc := NewAchievementUseCase(achievementRepo, waterRepo, newFakeUserRepository(&model.User{ID: 1}), &fakeGoalRepository{})
+
+	if _, err := uc.Evaluate(context.Background(), 1); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if earnedKeys(achievementRepo)["first_2l_day"] != 0 {
+		t.Fatalf("first_2l_day was earned with 1000ml of effective volume")
+	}
+
+	waterRepo.CreateWater(context.Background(), &model.Water{UserID: 1, Volume: 1000, DrankAt: "2024-06-01 21:00:00"})
+	if _, err := uc.Evaluate(context.Background(), 1); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if earnedKeys(achievementRepo)["first_2l_day"] != 1 {
+		t.Fatalf("first_2l_day was not earned with 2000ml of effective volume")
+	}
+}
+
+func TestAchievementUseCaseEvaluatePending(t *testing.T) {
+	waterRepo := newFakeWaterRepository(
+		&model.Water{UserID: 1, Volume: 200, DrankAt: "2024-06-01 08:00:00"},
+		&model.Water{UserID: 2, Volume: 200, DrankAt: "2024-06-01 08:00:00"},
+	)
+	achievementRepo := &fakeAchievementRepository{}
+	userRepo := newFakeUserRepository(&model.User{ID: 1}, &model.User{ID: 2})
+	uc := NewAchievementUseCase(achievementRepo, waterRepo, userRepo, &fakeGoalRepository{})
+
+	// Scheduleしただけでは判定しない
+	for i := 0; i < 2; i++ {
+		if err := uc.Schedule(context.Background(), 1); err != nil {
+			t.Fatalf("Schedule() error = %v", err)
+		}
+	}
+	if len(achievementRepo.achievements) != 0 {
+		t.Fatalf("achievements were evaluated before EvaluatePending")
+	}
+	if len(achievementRepo.evaluations) != 1 {
+		t.Fatalf("evaluations = %v, want 1 pending user", achievementRepo.evaluations)
+	}
+
+	// 判定待ちはリポジトリに残るため、再起動して作り直したユースケースでも判定できる
+	uc = NewAchievementUseCase(achievementRepo, waterRepo, userRepo, &fakeGoalRepository{})
+	if err := uc.EvaluatePending(context.Background(), time.Now()); err != nil {
+		t.Fatalf("EvaluatePending() error = %v", err)
+	}
+	if len(achievementRepo.achievements) != 1 || achievementRepo.achievements[0].UserID != 1 {
+		t.Fatalf("achievements = %+v, want first_log of user 1 only", achievementRepo.achievements)
+	}
+	if len(achievementRepo.evaluations) != 0 {
+		t.Fatalf("evaluations = %v, want none after evaluation", achievementRepo.evaluations)
+	}
+
+	// 判定したユーザーは次の実行では対象外になる
+	if err := uc.EvaluatePending(context.Background(), time.Now()); err != nil {
+		t.Fatalf("EvaluatePending() error = %v", err)
+	}
+	if len(achievementRepo.achievements) != 1 {
+		t.Fatalf("achievements = %+v, want 1", achievementRepo.achievements)
+	}
+}
+
+func TestAchievementUseCaseEvaluatePendingKeepsRescheduled(t *testing.T) {
+	waterRepo := newFakeWaterRepository(&model.Water{UserID: 1, Volume: 200, DrankAt: "2024-06-01 08:00:00"})
+	achievementRepo := &fakeAchievementRepository{}
+	uc := NewAchievementUseCase(achievementRepo, waterRepo, newFakeUserRepository(&model.User{ID: 1}), &fakeGoalRepository{})
+
+	if err := uc.Schedule(context.Background(), 1); err != nil {
+		t.Fatalf("Schedule() error = %v", err)
+	}
+	// 判定の途中で記録が追加され、再び判定を依頼された
+	achievementRepo.onCreate = func() {
+		achievementRepo.onCreate = nil
+		if err := uc.Schedule(context.Background(), 1); err != nil {
+			t.Fatalf("Schedule() error = %v", err)
+		}
+	}
+
+	if err := uc.EvaluatePending(context.Background(), time.Now()); err != nil {
+		t.Fatalf("EvaluatePending() error = %v", err)
+	}
+	if _, ok := achievementRepo.evaluations[1]; !ok {
+		t.Fatalf("evaluation scheduled during EvaluatePending was dropped")
+	}
+}
+
+func TestAchievementUseCaseEvaluatePendingKeepsFailed(t *testing.T) {
+	waterRepo := newFakeWaterRepository(&model.Water{UserID: 1, Volume: 200, DrankAt: "2024-06-01 08:00:00"})
+	achievementRepo := &fakeAchievementRepository{}
+	// ユーザー2は見つからず判定に失敗する
+	uc := NewAchievementUseCase(achievementRepo, waterRepo, newFakeUserRepository(&model.User{ID: 1}), &fakeGoalRepository{})
+
+	for _, userId := range []int64{1, 2} {
+		if err := uc.Schedule(context.Background(), userId); err != nil {
+			t.Fatalf("Schedule() error = %v", err)
+		}
+	}
+
+	if err := uc.EvaluatePending(context.Background(), time.Now()); err != nil {
+		t.Fatalf("EvaluatePending() error = %v", err)
+	}
+	if len(achievementRepo.achievements) != 1 || achievementRepo.achievements[0].UserID != 1 {
+		t.Fatalf("achievements = %+v, want first_log of user 1 only", achievementRepo.achievements)
+	}
+	if _, ok := achievementRepo.evaluations[1]; ok {
+		t.Fatalf("evaluation of user 1 was not completed")
+	}
+	if _, ok := achievementRepo.evaluations[2]; !ok {
+		t.Fatalf("failed evaluation of user 2 was dropped")
+	}
+}
+
+func TestWaterUseCaseSchedulesAchievements(t *testing.T) {
+	repo := newFakeWaterRepository()
+	achievementUseCase := &fakeAchievementUseCase{}
+	uc := newTestWaterUseCase(repo, &model.User{ID: 1})
+	uc.achievementUseCase = achievementUseCase
+
+	water, err := uc.Create(context.Background(), &model.Water{UserID: 1, Volume: 200, DrankAt: "2024-06-01 08:00:00"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := uc.Delete(context.Background(), 1, water.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if len(achievementUseCase.scheduled) != 2 {
+		t.Fatalf("scheduled = %v, want 2 evaluations", achievementUseCase.scheduled)
+	}
+}
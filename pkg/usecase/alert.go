@@ -0,0 +1,242 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/notifier"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type AlertUseCase interface {
+	Search(c context.Context, userId int64, onlyOpen bool) ([]*model.Alert, error)
+	Acknowledge(c context.Context, userId, id int64) error
+	FetchSettings(c context.Context, userId int64) (*model.AlertSettings, error)
+	// UpdateSettings enabledがnilの場合は保存済みの値(未保存なら初期値)を保つ
+	UpdateSettings(c context.Context, settings *model.AlertSettings, enabled *bool) (*model.AlertSettings, error)
+	// EvaluateAll 判定が有効なすべてのユーザーについて、now時点の脱水リスクを判定してアラートを作成する
+	EvaluateAll(c context.Context, now time.Time) error
+}
+
+type alertUseCase struct {
+	repository      repository.AlertRepository
+	waterRepository repository.WaterRepository
+	userRepository  repository.UserRepository
+	notifier        notifier.Notifier
+	timeout         time.Duration
+}
+
+func NewAlertUseCase(alertRepo repository.AlertRepository, waterRepo repository.WaterRepository, userRepo repository.UserRepository, n notifier.Notifier) AlertUseCase {
+	return &alertUseCase{
+		repository:      alertRepo,
+		waterRepository: waterRepo,
+		userRepository:  userRepo,
+		notifier:        n,
+		timeout:         time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *alertUseCase) Search(c context.Context, userId int64, onlyOpen bool) ([]*model.Alert, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	alerts, err := uc.repository.GetAlerts(ctx, userId, onlyOpen)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return alerts, nil
+}
+
+func (uc *alertUseCase) Acknowledge(c context.Context, userId, id int64) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	alert, err := uc.repository.GetAlert(ctx, id)
+	if errors.Is(err, repository.ErrAlertNotFound) {
+		return &util.NotFoundError{Err: err}
+	}
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	if alert.UserID != userId {
+		return &util.ForbiddenError{Err: errors.New("alert belongs to another user")}
+	}
+
+	err = uc.repository.AcknowledgeAlert(ctx, id)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return nil
+}
+
+func (uc *alertUseCase) FetchSettings(c context.Context, userId int64) (*model.AlertSettings, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	settings, err := uc.repository.GetSettings(ctx, userId)
+	if errors.Is(err, repository.ErrAlertSettingsNotFound) {
+		return model.DefaultAlertSettings(userId), nil
+	}
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return settings, nil
+}
+
+func (uc *alertUseCase) UpdateSettings(c context.Context, settings *model.AlertSettings, enabled *bool) (*model.AlertSettings, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if err := validateAlertSettings(settings); err != nil {
+		return nil, &util.BadRequestError{Err: err}
+	}
+
+	if enabled != nil {
+		settings.Enabled = *enabled
+	} else {
+		current, err := uc.repository.GetSettings(ctx, settings.UserID)
+		if errors.Is(err, repository.ErrAlertSettingsNotFound) {
+			current = model.DefaultAlertSettings(settings.UserID)
+		} else if err != nil {
+			return nil, &util.InternalServerError{Err: err}
+		}
+		settings.Enabled = current.Enabled
+	}
+
+	settings, err := uc.repository.UpsertSettings(ctx, settings)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return settings, nil
+}
+
+func (uc *alertUseCase) EvaluateAll(c context.Context, now time.Time) error {
+	settingsList, err := uc.repository.GetEnabledSettings(c)
+	if err != nil {
+		return err
+	}
+
+	// 1人の判定に失敗しても他のユーザーの判定は続ける
+	for _, settings := range settingsList {
+		if err := uc.evaluate(c, settings, now); err != nil {
+			log.Printf("failed to evaluate alerts for user %d: %v", settings.UserID, err)
+		}
+	}
+	return nil
+}
+
+func (uc *alertUseCase) evaluate(c context.Context, settings *model.AlertSettings, now time.Time) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	_, loc, err := userLocation(ctx, uc.userRepository, settings.UserID)
+	if err != nil {
+		return err
+	}
+
+	local := now.In(loc)
+	hour := int64(local.Hour())
+	if hour < settings.ActiveStartHour || hour >= settings.ActiveEndHour {
+		return nil
+	}
+	date := local.Format(dateLayout)
+
+	lastDrankAt, err := uc.waterRepository.GetLastDrankAt(ctx, settings.UserID, loc.String())
+	if err != nil {
+		return err
+	}
+
+	// 一度も記録していないユーザーは判定できないため対象外にする
+	if !lastDrankAt.IsZero() && now.Sub(lastDrankAt) >= time.Duration(settings.MaxHoursWithoutDrink)*time.Hour {
+		// 同じ「飲んでいない期間」について繰り返しアラートを作らない
+		latest, err := uc.repository.GetLatestAlert(ctx, settings.UserID, model.AlertKindInactivity)
+		if err != nil && !errors.Is(err, repository.ErrAlertNotFound) {
+			return err
+		}
+		if errors.Is(err, repository.ErrAlertNotFound) || latest.CreatedAt < lastDrankAt.UTC().Format(drankAtLayout) {
+			message := fmt.Sprintf("%d時間以上水分を摂取していません", int64(now.Sub(lastDrankAt).Hours()))
+			if err := uc.createAlert(ctx, settings.UserID, model.AlertKindInactivity, message, date, now); err != nil {
+				return err
+			}
+		}
+	}
+
+	if hour >= settings.DailyMinimumDeadlineHour {
+		// 1日の最低量のアラートは1日1回まで
+		latest, err := uc.repository.GetLatestAlert(ctx, settings.UserID, model.AlertKindLowDailyTotal)
+		if err != nil && !errors.Is(err, repository.ErrAlertNotFound) {
+			return err
+		}
+		if errors.Is(err, repository.ErrAlertNotFound) || latest.AlertDate != date {
+			totals, err := uc.waterRepository.GetDailyTotals(ctx, settings.UserID, date, date)
+			if err != nil {
+				return err
+			}
+			var total int64
+			if len(totals) > 0 {
+				total = totals[0].EffectiveVolume
+			}
+			if total < settings.DailyMinimum {
+				message := fmt.Sprintf("%d時の時点で今日の摂取量が%dmlです(目安: %dml)", settings.DailyMinimumDeadlineHour, total, settings.DailyMinimum)
+				if err := uc.createAlert(ctx, settings.UserID, model.AlertKindLowDailyTotal, message, date, now); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (uc *alertUseCase) createAlert(ctx context.Context, userId int64, kind, message, date string, now time.Time) error {
+	_, err := uc.repository.CreateAlert(ctx, &model.Alert{
+		UserID:    userId,
+		Kind:      kind,
+		Message:   message,
+		AlertDate: date,
+		CreatedAt: now.UTC().Format(drankAtLayout),
+	})
+	if err != nil {
+		return err
+	}
+
+	// アラートは記録済みのため、通知に失敗しても判定は失敗扱いにしない
+	err = uc.notifier.Notify(ctx, &model.Notification{
+		UserID: userId,
+		Kind:   model.NotificationKindAlert,
+		Title:  "水分補給のお知らせ",
+		Body:   message,
+	})
+	if err != nil {
+		log.Printf("failed to notify alert for user %d: %v", userId, err)
+	}
+	return nil
+}
+
+func validateAlertSettings(settings *model.AlertSettings) error {
+	if settings.MaxHoursWithoutDrink < 1 || settings.MaxHoursWithoutDrink > 24 {
+		return errors.New("max_hours_without_drink must be between 1 and 24")
+	}
+	if settings.DailyMinimum < 0 || settings.DailyMinimum > maxGoalVolume {
+		return fmt.Errorf("daily_minimum must be between 0 and %d", maxGoalVolume)
+	}
+	for _, hour := range []int64{settings.DailyMinimumDeadlineHour, settings.ActiveStartHour, settings.ActiveEndHour} {
+		if hour < 0 || hour > 24 {
+			return errors.New("hours must be between 0 and 24")
+		}
+	}
+	if settings.ActiveStartHour >= settings.ActiveEndHour {
+		return errors.New("active_start_hour must be before active_end_hour")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func TestAlertUseCaseUpdateSettingsEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		current *model.AlertSettings
+		enabled *bool
+		want    bool
+	}{
+		{name: "omitted keeps enabled", current: &model.AlertSettings{UserID: 1, Enabled: true}, want: true},
+		{name: "omitted keeps disabled", current: &model.AlertSettings{UserID: 1, Enabled: false}, want: false},
+		{name: "omitted uses default when not saved", want: model.DefaultAlertSettings(1).Enabled},
+		{name: "disable", current: &model.AlertSettings{UserID: 1, Enabled: true}, enabled: boolPtr(false), want: false},
+		{name: "enable", enabled: boolPtr(true), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alertRepo := &fakeAlertRepository{settings: map[int64]*model.AlertSettings{}}
+			if tt.current != nil {
+				alertRepo.settings[1] = tt.current
+			}
+			uc := NewAlertUseCase(alertRepo, newFakeWaterRepository(), newFakeUserRepository(), nil)
+
+			settings := model.DefaultAlertSettings(1)
+			settings.MaxHoursWithoutDrink = 3
+			got, err := uc.UpdateSettings(context.Background(), settings, tt.enabled)
+			if err != nil {
+				t.Fatalf("UpdateSettings() error = %v", err)
+			}
+			if got.Enabled != tt.want || alertRepo.settings[1].Enabled != tt.want {
+				t.Fatalf("Enabled = %v (saved %v), want %v", got.Enabled, alertRepo.settings[1].Enabled, tt.want)
+			}
+			if alertRepo.settings[1].MaxHoursWithoutDrink != 3 {
+				t.Fatalf("MaxHoursWithoutDrink = %d, want 3", alertRepo.settings[1].MaxHoursWithoutDrink)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+const (
+	minHydrationFactor = -1.0
+	maxHydrationFactor = 2.0
+	// maxCaffeineMgPer100ml, maxAlcoholGPer100ml DBの列(numeric(6,1), numeric(5,1))に収まる範囲
+	maxCaffeineMgPer100ml = 1000
+	maxAlcoholGPer100ml   = 100
+)
+
+type BeverageUseCase interface {
+	Search(c context.Context, userId int64) ([]*model.Beverage, error)
+	Create(c context.Context, beverage *model.Beverage) (*model.Beverage, error)
+	Delete(c context.Context, userId, id int64) error
+}
+
+type beverageUseCase struct {
+	repository repository.BeverageRepository
+	timeout    time.Duration
+}
+
+func NewBeverageUseCase(beverageRepo repository.BeverageRepository) BeverageUseCase {
+	return &beverageUseCase{
+		repository: beverageRepo,
+		timeout:    time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *beverageUseCase) Search(c context.Context, userId int64) ([]*model.Beverage, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	beverages, err := uc.repository.GetBeverages(ctx, userId)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return beverages, nil
+}
+
+func (uc *beverageUseCase) Create(c context.Context, beverage *model.Beverage) (*model.Beverage, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if beverage.HydrationFactor < minHydrationFactor || beverage.HydrationFactor > maxHydrationFactor {
+		return nil, &util.BadRequestError{Err: errors.New("hydration_factor must be between -1 and 2")}
+	}
+	if beverage.CaffeineMgPer100ml < 0 || beverage.AlcoholGPer100ml < 0 {
+		return nil, &util.BadRequestError{Err: errors.New("caffeine and alcohol must not be negative")}
+	}
+	if beverage.CaffeineMgPer100ml > maxCaffeineMgPer100ml || beverage.AlcoholGPer100ml > maxAlcoholGPer100ml {
+		return nil, &util.BadRequestError{Err: errors.New("caffeine or alcohol is too large")}
+	}
+
+	beverage, err := uc.repository.CreateBeverage(ctx, beverage)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return beverage, nil
+}
+
+// Delete 共通の飲み物と他のユーザーが登録した飲み物は削除できない
+func (uc *beverageUseCase) Delete(c context.Context, userId, id int64) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	beverage, err := uc.repository.GetBeverage(ctx, id)
+	if errors.Is(err, repository.ErrBeverageNotFound) {
+		return &util.NotFoundError{Err: err}
+	}
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	if beverage.Deleted {
+		return &util.NotFoundError{Err: repository.ErrBeverageNotFound}
+	}
+	if beverage.UserID != userId {
+		return &util.ForbiddenError{Err: errors.New("beverage cannot be deleted")}
+	}
+
+	err = uc.repository.DeleteBeverage(ctx, id, time.Now())
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return nil
+}
+
+// checkBeverage 他のユーザーが登録した飲み物は使わせない。0は未指定として扱う
+func checkBeverage(ctx context.Context, beverageRepo repository.BeverageRepository, userId, beverageId int64) error {
+	if beverageId == 0 {
+		return nil
+	}
+
+	beverage, err := beverageRepo.GetBeverage(ctx, beverageId)
+	if errors.Is(err, repository.ErrBeverageNotFound) {
+		return &util.BadRequestError{Err: err}
+	}
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	if !beverage.IsAvailableTo(userId) {
+		return &util.BadRequestError{Err: repository.ErrBeverageNotFound}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+func TestBeverageUseCaseDeleteKeepsPastRecords(t *testing.T) {
+	beverageRepo := &fakeBeverageRepository{beverages: []*model.Beverage{
+		{ID: 10, UserID: 1, Name: "コーヒー", HydrationFactor: 0.8},
+	}}
+	uc := NewBeverageUseCase(beverageRepo)
+
+	if err := uc.Delete(context.Background(), 1, 10); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	// 過去の記録からは削除した飲み物を引き続き参照できる
+	beverage, err := beverageRepo.GetBeverage(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("GetBeverage() error = %v", err)
+	}
+	if beverage.HydrationFactor != 0.8 {
+		t.Fatalf("HydrationFactor = %v, want 0.8", beverage.HydrationFactor)
+	}
+
+	// 新しい記録には使えない
+	var badRequest *util.BadRequestError
+	if err := checkBeverage(context.Background(), beverageRepo, 1, 10); !errors.As(err, &badRequest) {
+		t.Fatalf("checkBeverage() error = %v, want BadRequestError", err)
+	}
+
+	// 2回目の削除は見つからない扱い
+	var notFound *util.NotFoundError
+	if err := uc.Delete(context.Background(), 1, 10); !errors.As(err, &notFound) {
+		t.Fatalf("Delete() error = %v, want NotFoundError", err)
+	}
+}
+
+func TestBeverageUseCaseDeleteOthers(t *testing.T) {
+	beverageRepo := &fakeBeverageRepository{beverages: []*model.Beverage{
+		{ID: 1, Code: "water", Name: "水", HydrationFactor: 1},
+		{ID: 10, UserID: 2, Name: "コーヒー", HydrationFactor: 0.8},
+	}}
+	uc := NewBeverageUseCase(beverageRepo)
+
+	for _, id := range []int64{1, 10} {
+		var forbidden *util.ForbiddenError
+		if err := uc.Delete(context.Background(), 1, id); !errors.As(err, &forbidden) {
+			t.Fatalf("Delete(%d) error = %v, want ForbiddenError", id, err)
+		}
+	}
+	for _, beverage := range beverageRepo.beverages {
+		if beverage.Deleted {
+			t.Fatalf("beverage %d was deleted", beverage.ID)
+		}
+	}
+}
+
+func TestBeverageUseCaseDeleteThenLogWithContainer(t *testing.T) {
+	containerRepo := &fakeContainerRepository{containers: []*model.Container{
+		{ID: 5, UserID: 1, Name: "マグカップ", Volume: 300, BeverageID: 10},
+	}}
+	beverageRepo := &fakeBeverageRepository{
+		beverages:  []*model.Beverage{{ID: 10, UserID: 1, Name: "コーヒー", HydrationFactor: 0.8}},
+		containers: containerRepo,
+	}
+	waterUseCase := NewWaterUseCase(
+		newFakeWaterRepository(),
+		newFakeUserRepository(&model.User{ID: 1}),
+		&fakeGoalRepository{},
+		beverageRepo,
+		containerRepo,
+		&fakeAchievementUseCase{},
+	)
+
+	if err := NewBeverageUseCase(beverageRepo).Delete(context.Background(), 1, 10); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	// プリセットの飲み物を削除した容器でも記録できる
+	water, err := waterUseCase.Create(context.Background(), &model.Water{UserID: 1, ContainerID: 5, DrankAt: "2024-06-01 10:00:00"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if water.Volume != 300 || water.BeverageID != 0 {
+		t.Fatalf("Create() = volume %d beverage %d, want volume 300 without beverage", water.Volume, water.BeverageID)
+	}
+}
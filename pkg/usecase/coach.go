@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mikaijun/aquagent/pkg/domain/coach"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+const (
+	// coachHistoryLimit コーチに渡す会話の履歴の件数
+	coachHistoryLimit = 20
+	// maxCoachMessageLength 1回のメッセージで受け付ける最大文字数
+	maxCoachMessageLength = 1000
+	// coachRecentDays, coachPatternDays コーチに渡す日ごとの集計と飲み方の傾向の日数
+	coachRecentDays  = 7
+	coachPatternDays = 28
+)
+
+type CoachUseCase interface {
+	// Send ユーザーのメッセージに対するコーチの返信を作り、両方を履歴に保存する
+	Send(c context.Context, userId int64, content string) (*model.CoachMessage, error)
+	History(c context.Context, userId int64) ([]*model.CoachMessage, error)
+	Clear(c context.Context, userId int64) error
+}
+
+type coachUseCase struct {
+	repository      repository.CoachRepository
+	waterRepository repository.WaterRepository
+	userRepository  repository.UserRepository
+	waterUseCase    WaterUseCase
+	statsUseCase    StatsUseCase
+	coach           coach.Coach
+	timeout         time.Duration
+}
+
+func NewCoachUseCase(
+	coachRepo repository.CoachRepository,
+	waterRepo repository.WaterRepository,
+	userRepo repository.UserRepository,
+	waterUseCase WaterUseCase,
+	statsUseCase StatsUseCase,
+	c coach.Coach,
+) CoachUseCase {
+	return &coachUseCase{
+		repository:      coachRepo,
+		waterRepository: waterRepo,
+		userRepository:  userRepo,
+		waterUseCase:    waterUseCase,
+		statsUseCase:    statsUseCase,
+		coach:           c,
+		// LLMの応答を待つため、他のusecaseより長くする
+		timeout: time.Duration(30) * time.Second,
+	}
+}
+
+func (uc *coachUseCase) Send(c context.Context, userId int64, content string) (*model.CoachMessage, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, &util.BadRequestError{Err: errors.New("content must not be empty")}
+	}
+	if utf8.RuneCountInString(content) > maxCoachMessageLength {
+		return nil, &util.BadRequestError{Err: fmt.Errorf("content must be at most %d characters", maxCoachMessageLength)}
+	}
+
+	coachContext, err := uc.coachContext(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := uc.repository.GetMessages(ctx, userId, coachHistoryLimit-1)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	message := &model.CoachMessage{
+		UserID:    userId,
+		Role:      model.CoachRoleUser,
+		Content:   content,
+		CreatedAt: time.Now().UTC().Format(drankAtLayout),
+	}
+	reply, err := uc.coach.Reply(ctx, coachContext, append(history, message))
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	// 返信を作れなかった場合に質問だけが残らないように、返信ができてから保存する
+	if _, err := uc.repository.CreateMessage(ctx, message); err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+	answer, err := uc.repository.CreateMessage(ctx, &model.CoachMessage{
+		UserID:    userId,
+		Role:      model.CoachRoleAssistant,
+		Content:   reply,
+		CreatedAt: time.Now().UTC().Format(drankAtLayout),
+	})
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return answer, nil
+}
+
+func (uc *coachUseCase) History(c context.Context, userId int64) ([]*model.CoachMessage, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	messages, err := uc.repository.GetMessages(ctx, userId, coachHistoryLimit)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return messages, nil
+}
+
+func (uc *coachUseCase) Clear(c context.Context, userId int64) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	err := uc.repository.DeleteMessages(ctx, userId)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return nil
+}
+
+// coachContext 直近の記録、目標、連続記録、飲み方の傾向をまとめる
+func (uc *coachUseCase) coachContext(ctx context.Context, userId int64) (*model.CoachContext, error) {
+	user, loc, err := userLocation(ctx, uc.userRepository, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	start := today(loc).AddDate(0, 0, -(coachRecentDays - 1)).Format(dateLayout)
+	recent, err := uc.waterUseCase.DailySummary(ctx, userId, start, "")
+	if err != nil {
+		return nil, err
+	}
+
+	streaks, err := uc.statsUseCase.Streaks(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern, err := uc.statsUseCase.Pattern(ctx, userId, coachPatternDays)
+	if err != nil {
+		return nil, err
+	}
+
+	lastDrankAt, err := uc.waterRepository.GetLastDrankAt(ctx, userId, loc.String())
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	now := time.Now()
+	coachContext := &model.CoachContext{
+		Now:        now.In(loc).Format("2006-01-02 15:04"),
+		Timezone:   user.Timezone,
+		RecentDays: recent,
+		Streaks:    streaks,
+		Pattern:    pattern,
+	}
+	if len(recent) > 0 {
+		coachContext.Today = recent[len(recent)-1]
+		coachContext.Goal = coachContext.Today.Goal
+	}
+	if !lastDrankAt.IsZero() {
+		minutes := int64(now.Sub(lastDrankAt).Minutes())
+		coachContext.MinutesSinceLastDrink = &minutes
+	}
+
+	return coachContext, nil
+}
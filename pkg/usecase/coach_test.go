@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+func newTestCoachUseCase(c *fakeCoach, coachRepo *fakeCoachRepository, waters ...*model.Water) CoachUseCase {
+	waterRepo := newFakeWaterRepository(waters...)
+	userRepo := newFakeUserRepository(&model.User{ID: 1, Timezone: "Asia/Tokyo"})
+	goalRepo := &fakeGoalRepository{goals: []*model.Goal{{UserID: 1, Volume: 2000, EffectiveDate: "2000-01-01"}}}
+	waterUseCase := NewWaterUseCase(waterRepo, userRepo, goalRepo, &fakeBeverageRepository{}, &fakeContainerRepository{}, &fakeAchievementUseCase{})
+	statsUseCase := NewStatsUseCase(waterRepo, userRepo, goalRepo)
+	return NewCoachUseCase(coachRepo, waterRepo, userRepo, waterUseCase, statsUseCase, c)
+}
+
+func TestCoachUseCaseSend(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	drankAt := time.Now().In(tokyo).Add(-45 * time.Minute).Format(drankAtLayout)
+	c := &fakeCoach{reply: "あと1700mlです。"}
+	coachRepo := &fakeCoachRepository{messages: []*model.CoachMessage{
+		{ID: 1, UserID: 1, Role: model.CoachRoleUser, Content: "おはよう"},
+		{ID: 2, UserID: 1, Role: model.CoachRoleAssistant, Content: "おはようございます"},
+		{ID: 3, UserID: 2, Role: model.CoachRoleUser, Content: "他のユーザーの履歴"},
+	}}
+	uc := newTestCoachUseCase(c, coachRepo, &model.Water{UserID: 1, Volume: 300, DrankAt: drankAt})
+
+	answer, err := uc.Send(context.Background(), 1, "  あとどれくらい?  ")
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if answer.Role != model.CoachRoleAssistant || answer.Content != "あと1700mlです。" {
+		t.Errorf("Send() = %+v, want the coach reply", answer)
+	}
+
+	// 自分の履歴の最後に今回のメッセージを付けて渡す
+	if len(c.history) != 3 || c.history[2].Content != "あとどれくらい?" || c.history[2].Role != model.CoachRoleUser {
+		t.Fatalf("history = %v, want 2 saved messages and the trimmed new message", c.history)
+	}
+	if c.history[0].Content != "おはよう" {
+		t.Errorf("history[0] = %q, want the oldest message first", c.history[0].Content)
+	}
+
+	coachContext := c.context
+	if coachContext.Timezone != "Asia/Tokyo" || coachContext.Goal != 2000 {
+		t.Errorf("context = timezone %q goal %d, want Asia/Tokyo 2000", coachContext.Timezone, coachContext.Goal)
+	}
+	if len(coachContext.RecentDays) != coachRecentDays {
+		t.Errorf("RecentDays has %d days, want %d", len(coachContext.RecentDays), coachRecentDays)
+	}
+	var total int64
+	for _, day := range coachContext.RecentDays {
+		total += day.TotalVolume
+	}
+	if total != 300 {
+		t.Errorf("RecentDays total = %d, want 300", total)
+	}
+	if coachContext.Today != coachContext.RecentDays[len(coachContext.RecentDays)-1] {
+		t.Errorf("Today is not the last of RecentDays")
+	}
+	if minutes := coachContext.MinutesSinceLastDrink; minutes == nil || *minutes < 44 || *minutes > 46 {
+		t.Errorf("MinutesSinceLastDrink = %v, want about 45", minutes)
+	}
+	if coachContext.Streaks == nil || coachContext.Pattern == nil {
+		t.Errorf("context = %+v, want streaks and pattern", coachContext)
+	}
+
+	// 質問と返信の両方を保存する
+	if len(coachRepo.messages) != 5 {
+		t.Fatalf("saved %d messages, want 5", len(coachRepo.messages))
+	}
+	if saved := coachRepo.messages[3]; saved.Role != model.CoachRoleUser || saved.Content != "あとどれくらい?" {
+		t.Errorf("saved question = %+v", saved)
+	}
+}
+
+func TestCoachUseCaseSendWithoutRecords(t *testing.T) {
+	c := &fakeCoach{reply: "まずは1杯から"}
+	uc := newTestCoachUseCase(c, &fakeCoachRepository{})
+
+	if _, err := uc.Send(context.Background(), 1, "はじめまして"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if c.context.MinutesSinceLastDrink != nil {
+		t.Errorf("MinutesSinceLastDrink = %d, want nil", *c.context.MinutesSinceLastDrink)
+	}
+}
+
+func TestCoachUseCaseSendErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		coach   *fakeCoach
+		check   func(error) bool
+	}{
+		{
+			name:    "empty",
+			content: "   ",
+			coach:   &fakeCoach{reply: "返信"},
+			check:   func(err error) bool { var e *util.BadRequestError; return errors.As(err, &e) },
+		},
+		{
+			name:    "too long",
+			content: strings.Repeat("水", maxCoachMessageLength+1),
+			coach:   &fakeCoach{reply: "返信"},
+			check:   func(err error) bool { var e *util.BadRequestError; return errors.As(err, &e) },
+		},
+		{
+			// 返信を作れなかった場合は質問も保存しない
+			name:    "coach fails",
+			content: "こんにちは",
+			coach:   &fakeCoach{err: errors.New("unavailable")},
+			check:   func(err error) bool { var e *util.InternalServerError; return errors.As(err, &e) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coachRepo := &fakeCoachRepository{}
+			uc := newTestCoachUseCase(tt.coach, coachRepo)
+
+			if _, err := uc.Send(context.Background(), 1, tt.content); !tt.check(err) {
+				t.Errorf("Send() error = %v", err)
+			}
+			if len(coachRepo.messages) != 0 {
+				t.Errorf("saved %d messages, want 0", len(coachRepo.messages))
+			}
+		})
+	}
+}
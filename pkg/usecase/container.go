@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type ContainerUseCase interface {
+	Search(c context.Context, userId int64) ([]*model.Container, error)
+	Create(c context.Context, container *model.Container) (*model.Container, error)
+	Update(c context.Context, container *model.Container) (*model.Container, error)
+	Delete(c context.Context, userId, id int64) error
+}
+
+type containerUseCase struct {
+	repository         repository.ContainerRepository
+	beverageRepository repository.BeverageRepository
+	timeout            time.Duration
+}
+
+func NewContainerUseCase(containerRepo repository.ContainerRepository, beverageRepo repository.BeverageRepository) ContainerUseCase {
+	return &containerUseCase{
+		repository:         containerRepo,
+		beverageRepository: beverageRepo,
+		timeout:            time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *containerUseCase) Search(c context.Context, userId int64) ([]*model.Container, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	containers, err := uc.repository.GetContainers(ctx, userId)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return containers, nil
+}
+
+func (uc *containerUseCase) Create(c context.Context, container *model.Container) (*model.Container, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if err := validateContainer(container); err != nil {
+		return nil, &util.BadRequestError{Err: err}
+	}
+
+	if err := checkBeverage(ctx, uc.beverageRepository, container.UserID, container.BeverageID); err != nil {
+		return nil, err
+	}
+
+	container, err := uc.repository.CreateContainer(ctx, container)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return container, nil
+}
+
+// Update Name、Volume、BeverageIDのうち、ゼロ値でない項目だけを更新する
+func (uc *containerUseCase) Update(c context.Context, container *model.Container) (*model.Container, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	current, err := findOwnContainer(ctx, uc.repository, container.UserID, container.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if container.Name != "" {
+		current.Name = container.Name
+	}
+	if container.Volume != 0 {
+		current.Volume = container.Volume
+	}
+	if container.BeverageID != 0 {
+		if err := checkBeverage(ctx, uc.beverageRepository, container.UserID, container.BeverageID); err != nil {
+			return nil, err
+		}
+		current.BeverageID = container.BeverageID
+	}
+
+	if err := validateContainer(current); err != nil {
+		return nil, &util.BadRequestError{Err: err}
+	}
+
+	updated, err := uc.repository.UpdateContainer(ctx, current)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return updated, nil
+}
+
+func (uc *containerUseCase) Delete(c context.Context, userId, id int64) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	_, err := findOwnContainer(ctx, uc.repository, userId, id)
+	if err != nil {
+		return err
+	}
+
+	err = uc.repository.DeleteContainer(ctx, id)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return nil
+}
+
+// findOwnContainer 他のユーザーの容器は操作させない
+func findOwnContainer(ctx context.Context, containerRepo repository.ContainerRepository, userId, id int64) (*model.Container, error) {
+	container, err := containerRepo.GetContainer(ctx, id)
+	if errors.Is(err, repository.ErrContainerNotFound) {
+		return nil, &util.NotFoundError{Err: err}
+	}
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	if container.UserID != userId {
+		return nil, &util.ForbiddenError{Err: errors.New("container belongs to another user")}
+	}
+
+	return container, nil
+}
+
+func validateContainer(container *model.Container) error {
+	if container.Name == "" {
+		return errors.New("name is required")
+	}
+	if container.Volume <= 0 || container.Volume > maxWaterVolume {
+		return fmt.Errorf("volume must be between 1 and %d", maxWaterVolume)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// maxSummaryDays 1回の集計で扱える最大日数
+	maxSummaryDays = 366
+)
+
+// userLocation ユーザーと、そのユーザーに設定されたタイムゾーンを返す
+func userLocation(ctx context.Context, userRepo repository.UserRepository, userId int64) (*model.User, *time.Location, error) {
+	user, err := userRepo.GetUserById(ctx, userId)
+	if err != nil {
+		return nil, nil, &util.InternalServerError{Err: err}
+	}
+	if user.ID == 0 {
+		return nil, nil, &util.BadRequestError{Err: errors.New("user is not exist")}
+	}
+
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return nil, nil, &util.InternalServerError{Err: err}
+	}
+
+	return user, loc, nil
+}
+
+// today locにおける今日の日付を、時刻を持たないUTCの日付として返す
+func today(loc *time.Location) time.Time {
+	y, m, d := time.Now().In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// parseDate 日付はDSTの影響を受けないようにUTCの0時として扱う
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, &util.BadRequestError{Err: fmt.Errorf("date must be formatted as %q", dateLayout)}
+	}
+	return date, nil
+}
+
+// dateRange startからendまで(両端を含む)の日付を返す
+func dateRange(start, end time.Time) ([]time.Time, error) {
+	if end.Before(start) {
+		return nil, &util.BadRequestError{Err: errors.New("end must not be before start")}
+	}
+
+	days := int(end.Sub(start).Hours()/24) + 1
+	if days > maxSummaryDays {
+		return nil, &util.BadRequestError{Err: fmt.Errorf("range must be within %d days", maxSummaryDays)}
+	}
+
+	dates := make([]time.Time, 0, days)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d)
+	}
+	return dates, nil
+}
@@ -0,0 +1,670 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+// テスト用のリポジトリ。使わないメソッドは埋め込んだインターフェースに任せるため、呼ぶとpanicする
+
+type fakeWaterRepository struct {
+	repository.WaterRepository
+	waters map[int64]*model.Water
+	nextID int64
+	// factors 飲み物ごとの係数。未指定の飲み物は水として扱う
+	factors map[int64]float64
+}
+
+func newFakeWaterRepository(waters ...*model.Water) *fakeWaterRepository {
+	repo := &fakeWaterRepository{waters: map[int64]*model.Water{}}
+	for _, water := range waters {
+		repo.CreateWater(context.Background(), water)
+	}
+	return repo
+}
+
+func (r *fakeWaterRepository) CreateWater(ctx context.Context, water *model.Water) (*model.Water, error) {
+	r.nextID++
+	saved := *water
+	saved.ID = r.nextID
+	r.waters[saved.ID] = &saved
+	copied := saved
+	return &copied, nil
+}
+
+func (r *fakeWaterRepository) CreateWaters(ctx context.Context, waters []*model.Water) ([]*model.Water, error) {
+	created := make([]*model.Water, 0, len(waters))
+	for _, water := range waters {
+		water, _ := r.CreateWater(ctx, water)
+		created = append(created, water)
+	}
+	return created, nil
+}
+
+func (r *fakeWaterRepository) GetWater(ctx context.Context, waterId int64) (*model.Water, error) {
+	water, ok := r.waters[waterId]
+	if !ok {
+		return &model.Water{}, repository.ErrWaterNotFound
+	}
+	copied := *water
+	return &copied, nil
+}
+
+func (r *fakeWaterRepository) UpdateWater(ctx context.Context, water *model.Water) (*model.Water, error) {
+	saved := *water
+	r.waters[water.ID] = &saved
+	return water, nil
+}
+
+func (r *fakeWaterRepository) DeleteWater(ctx context.Context, userId, waterId int64) error {
+	water, ok := r.waters[waterId]
+	if !ok || water.UserID != userId {
+		return repository.ErrWaterNotFound
+	}
+	delete(r.waters, waterId)
+	return nil
+}
+
+// GetDailyTotals 本番のSQLと同じく、drank_atの日付(DATE(drank_at))で区切って集計する
+func (r *fakeWaterRepository) GetDailyTotals(ctx context.Context, userId int64, start, end string) ([]*model.DailyTotal, error) {
+	byDate := map[string]*model.DailyTotal{}
+	for _, water := range r.sorted(userId) {
+		date := water.DrankAt[:len(dateLayout)]
+		if date < start || date > end {
+			continue
+		}
+		total, ok := byDate[date]
+		if !ok {
+			total = &model.DailyTotal{Date: date, FirstDrankAt: water.DrankAt}
+			byDate[date] = total
+		}
+		total.TotalVolume += water.Volume
+		factor, ok := r.factors[water.BeverageID]
+		if !ok {
+			factor = 1
+		}
+		total.EffectiveVolume += int64(float64(water.Volume) * factor)
+		total.Count++
+		total.LastDrankAt = water.DrankAt
+	}
+
+	var totals []*model.DailyTotal = []*model.DailyTotal{}
+	for _, total := range byDate {
+		totals = append(totals, total)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Date < totals[j].Date })
+	return totals, nil
+}
+
+func (r *fakeWaterRepository) CountWaters(ctx context.Context, userId int64) (int64, error) {
+	return int64(len(r.sorted(userId))), nil
+}
+
+func (r *fakeWaterRepository) GetLastDrankAt(ctx context.Context, userId int64, timezone string) (time.Time, error) {
+	waters := r.sorted(userId)
+	if len(waters) == 0 {
+		return time.Time{}, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(drankAtLayout, waters[len(waters)-1].DrankAt, loc)
+}
+
+func (r *fakeWaterRepository) GetLocalDrinks(ctx context.Context, userId int64, start, end string) ([]*model.LocalDrink, error) {
+	var drinks []*model.LocalDrink
+	for _, water := range r.sorted(userId) {
+		if date := water.DrankAt[:len(dateLayout)]; date < start || date > end {
+			continue
+		}
+		drankAt, err := time.Parse(drankAtLayout, water.DrankAt)
+		if err != nil {
+			return nil, err
+		}
+		drinks = append(drinks, &model.LocalDrink{Volume: water.Volume, DrankAt: drankAt})
+	}
+	return drinks, nil
+}
+
+// sorted userIdのユーザーの記録を古い順に返す
+func (r *fakeWaterRepository) sorted(userId int64) []*model.Water {
+	var waters []*model.Water
+	for _, water := range r.waters {
+		if water.UserID == userId {
+			waters = append(waters, water)
+		}
+	}
+	sort.Slice(waters, func(i, j int) bool { return waters[i].DrankAt < waters[j].DrankAt })
+	return waters
+}
+
+type fakeUserRepository struct {
+	repository.UserRepository
+	users map[int64]*model.User
+}
+
+func newFakeUserRepository(users ...*model.User) *fakeUserRepository {
+	repo := &fakeUserRepository{users: map[int64]*model.User{}}
+	for _, user := range users {
+		repo.users[user.ID] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) GetUserById(ctx context.Context, id int64) (*model.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return &model.User{}, nil
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return &model.User{}, nil
+}
+
+type fakeGoalRepository struct {
+	repository.GoalRepository
+	goals []*model.Goal
+}
+
+func (r *fakeGoalRepository) GetGoals(ctx context.Context, userId int64) ([]*model.Goal, error) {
+	return r.goals, nil
+}
+
+func (r *fakeGoalRepository) UpsertGoal(ctx context.Context, goal *model.Goal) (*model.Goal, error) {
+	for i, saved := range r.goals {
+		if saved.EffectiveDate == goal.EffectiveDate {
+			r.goals[i] = goal
+			return goal, nil
+		}
+	}
+	r.goals = append(r.goals, goal)
+	sort.Slice(r.goals, func(i, j int) bool { return r.goals[i].EffectiveDate < r.goals[j].EffectiveDate })
+	return goal, nil
+}
+
+type fakeCoachRepository struct {
+	repository.CoachRepository
+	messages []*model.CoachMessage
+}
+
+func (r *fakeCoachRepository) CreateMessage(ctx context.Context, message *model.CoachMessage) (*model.CoachMessage, error) {
+	message.ID = int64(len(r.messages) + 1)
+	r.messages = append(r.messages, message)
+	return message, nil
+}
+
+func (r *fakeCoachRepository) GetMessages(ctx context.Context, userId int64, limit int) ([]*model.CoachMessage, error) {
+	var messages []*model.CoachMessage
+	for _, message := range r.messages {
+		if message.UserID == userId {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return messages, nil
+}
+
+// fakeCoach 受け取った状況と履歴を保持し、replyかerrを返す
+type fakeCoach struct {
+	reply   string
+	err     error
+	context *model.CoachContext
+	history []*model.CoachMessage
+}
+
+func (c *fakeCoach) Reply(ctx context.Context, coachContext *model.CoachContext, history []*model.CoachMessage) (string, error) {
+	c.context, c.history = coachContext, history
+	return c.reply, c.err
+}
+
+type fakeReminderRepository struct {
+	repository.ReminderRepository
+	reminders []*model.Reminder
+}
+
+func (r *fakeReminderRepository) GetEnabledReminders(ctx context.Context) ([]*model.Reminder, error) {
+	var reminders []*model.Reminder
+	for _, reminder := range r.reminders {
+		if reminder.Enabled {
+			reminders = append(reminders, reminder)
+		}
+	}
+	return reminders, nil
+}
+
+func (r *fakeReminderRepository) UpdateTriggeredAt(ctx context.Context, reminderId int64, triggeredAt time.Time) error {
+	for _, reminder := range r.reminders {
+		if reminder.ID == reminderId {
+			formatted := triggeredAt.UTC().Format(drankAtLayout)
+			reminder.LastTriggeredAt = &formatted
+		}
+	}
+	return nil
+}
+
+type fakeBeverageRepository struct {
+	repository.BeverageRepository
+	beverages []*model.Beverage
+	// containers 削除した飲み物をプリセットから外す容器
+	containers *fakeContainerRepository
+}
+
+func (r *fakeBeverageRepository) GetBeverages(ctx context.Context, userId int64) ([]*model.Beverage, error) {
+	return r.beverages, nil
+}
+
+func (r *fakeBeverageRepository) GetBeverage(ctx context.Context, beverageId int64) (*model.Beverage, error) {
+	for _, beverage := range r.beverages {
+		if beverage.ID == beverageId {
+			return beverage, nil
+		}
+	}
+	return &model.Beverage{}, repository.ErrBeverageNotFound
+}
+
+func (r *fakeBeverageRepository) DeleteBeverage(ctx context.Context, beverageId int64, now time.Time) error {
+	for _, beverage := range r.beverages {
+		if beverage.ID == beverageId {
+			beverage.Deleted = true
+		}
+	}
+	if r.containers != nil {
+		for _, container := range r.containers.containers {
+			if container.BeverageID == beverageId {
+				container.BeverageID = 0
+			}
+		}
+	}
+	return nil
+}
+
+type fakeAchievementRepository struct {
+	repository.AchievementRepository
+	achievements []*model.UserAchievement
+	// evaluations 判定待ちのユーザーとVersion
+	evaluations map[int64]int64
+	// onCreate バッジを記録したときに呼ぶ。判定の途中の操作を再現するために使う
+	onCreate func()
+}
+
+func (r *fakeAchievementRepository) GetAchievements(ctx context.Context, userId int64) ([]*model.UserAchievement, error) {
+	var achievements []*model.UserAchievement = []*model.UserAchievement{}
+	for _, achievement := range r.achievements {
+		if achievement.UserID == userId {
+			achievements = append(achievements, achievement)
+		}
+	}
+	return achievements, nil
+}
+
+func (r *fakeAchievementRepository) CreateAchievement(ctx context.Context, achievement *model.UserAchievement) error {
+	r.achievements = append(r.achievements, achievement)
+	if r.onCreate != nil {
+		r.onCreate()
+	}
+	return nil
+}
+
+func (r *fakeAchievementRepository) ScheduleEvaluation(ctx context.Context, userId int64) error {
+	if r.evaluations == nil {
+		r.evaluations = map[int64]int64{}
+	}
+	r.evaluations[userId]++
+	return nil
+}
+
+func (r *fakeAchievementRepository) GetPendingEvaluations(ctx context.Context, limit int64) ([]*model.AchievementEvaluation, error) {
+	var evaluations []*model.AchievementEvaluation = []*model.AchievementEvaluation{}
+	for userId, version := range r.evaluations {
+		evaluations = append(evaluations, &model.AchievementEvaluation{UserID: userId, Version: version})
+	}
+	sort.Slice(evaluations, func(i, j int) bool { return evaluations[i].UserID < evaluations[j].UserID })
+	if int64(len(evaluations)) > limit {
+		evaluations = evaluations[:limit]
+	}
+	return evaluations, nil
+}
+
+func (r *fakeAchievementRepository) CompleteEvaluation(ctx context.Context, evaluation *model.AchievementEvaluation) error {
+	if r.evaluations[evaluation.UserID] == evaluation.Version {
+		delete(r.evaluations, evaluation.UserID)
+	}
+	return nil
+}
+
+type fakeAlertRepository struct {
+	repository.AlertRepository
+	settings map[int64]*model.AlertSettings
+}
+
+func (r *fakeAlertRepository) GetSettings(ctx context.Context, userId int64) (*model.AlertSettings, error) {
+	settings, ok := r.settings[userId]
+	if !ok {
+		return &model.AlertSettings{}, repository.ErrAlertSettingsNotFound
+	}
+	copied := *settings
+	return &copied, nil
+}
+
+func (r *fakeAlertRepository) UpsertSettings(ctx context.Context, settings *model.AlertSettings) (*model.AlertSettings, error) {
+	if r.settings == nil {
+		r.settings = map[int64]*model.AlertSettings{}
+	}
+	saved := *settings
+	r.settings[settings.UserID] = &saved
+	return settings, nil
+}
+
+type fakePushSubscriptionRepository struct {
+	repository.PushSubscriptionRepository
+	subscriptions []*model.PushSubscription
+}
+
+func (r *fakePushSubscriptionRepository) CreateSubscription(ctx context.Context, subscription *model.PushSubscription) (*model.PushSubscription, error) {
+	for _, saved := range r.subscriptions {
+		if saved.Endpoint != subscription.Endpoint {
+			continue
+		}
+		if saved.UserID != subscription.UserID {
+			return &model.PushSubscription{}, repository.ErrPushSubscriptionTaken
+		}
+		saved.P256dh, saved.Auth = subscription.P256dh, subscription.Auth
+		subscription.ID = saved.ID
+		return subscription, nil
+	}
+	saved := *subscription
+	saved.ID = int64(len(r.subscriptions) + 1)
+	r.subscriptions = append(r.subscriptions, &saved)
+	subscription.ID = saved.ID
+	return subscription, nil
+}
+
+type fakeLineRepository struct {
+	repository.LineRepository
+	codes    map[string]*model.LineLinkCode
+	accounts []*model.LineAccount
+	// conflicts CreateLinkCodeがErrLineLinkCodeConflictを返す回数
+	conflicts int
+}
+
+func (r *fakeLineRepository) CreateLinkCode(ctx context.Context, code *model.LineLinkCode) (*model.LineLinkCode, error) {
+	if r.conflicts > 0 {
+		r.conflicts--
+		return &model.LineLinkCode{}, repository.ErrLineLinkCodeConflict
+	}
+	if r.codes == nil {
+		r.codes = map[string]*model.LineLinkCode{}
+	}
+	r.codes[code.Code] = code
+	return code, nil
+}
+
+func (r *fakeLineRepository) ConsumeLinkCode(ctx context.Context, code string, now time.Time) (int64, error) {
+	saved, ok := r.codes[code]
+	if !ok || saved.ExpiresAt <= now.UTC().Format(drankAtLayout) {
+		return 0, repository.ErrLineLinkCodeNotFound
+	}
+	delete(r.codes, code)
+	return saved.UserID, nil
+}
+
+func (r *fakeLineRepository) LinkAccount(ctx context.Context, account *model.LineAccount) (*model.LineAccount, error) {
+	r.accounts = append(r.accounts, account)
+	return account, nil
+}
+
+func (r *fakeLineRepository) GetAccountByLineUserID(ctx context.Context, lineUserId string) (*model.LineAccount, error) {
+	for _, account := range r.accounts {
+		if account.LineUserID == lineUserId {
+			return account, nil
+		}
+	}
+	return &model.LineAccount{}, repository.ErrLineAccountNotFound
+}
+
+// fakeLineClient 送ったメッセージをLINEに送らずに保持する
+type fakeLineClient struct {
+	replies []string
+}
+
+func (c *fakeLineClient) Reply(ctx context.Context, replyToken string, messages ...string) error {
+	c.replies = append(c.replies, messages...)
+	return nil
+}
+
+func (c *fakeLineClient) Push(ctx context.Context, lineUserId string, messages ...string) error {
+	return nil
+}
+
+type fakeSessionRepository struct {
+	repository.SessionRepository
+	sessions []*model.Session
+	// lostRotations RotateSessionが他のリクエストに先に交換されたものとしてfalseを返す回数
+	lostRotations int
+}
+
+func (r *fakeSessionRepository) CreateSession(ctx context.Context, session *model.Session) (*model.Session, error) {
+	session.ID = int64(len(r.sessions) + 1)
+	r.sessions = append(r.sessions, session)
+	return session, nil
+}
+
+func (r *fakeSessionRepository) GetSession(ctx context.Context, sessionId int64) (*model.Session, error) {
+	for _, session := range r.sessions {
+		if session.ID == sessionId {
+			return session, nil
+		}
+	}
+	return &model.Session{}, repository.ErrSessionNotFound
+}
+
+func (r *fakeSessionRepository) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*model.Session, error) {
+	for _, session := range r.sessions {
+		if session.TokenHash == tokenHash {
+			return session, nil
+		}
+	}
+	return &model.Session{}, repository.ErrSessionNotFound
+}
+
+func (r *fakeSessionRepository) RotateSession(ctx context.Context, sessionId int64, now time.Time) (bool, error) {
+	if r.lostRotations > 0 {
+		r.lostRotations--
+		return false, nil
+	}
+	session, err := r.GetSession(ctx, sessionId)
+	if err != nil || session.RotatedAt != nil {
+		return false, err
+	}
+	rotatedAt := now.UTC().Format(drankAtLayout)
+	session.RotatedAt = &rotatedAt
+	return true, nil
+}
+
+func (r *fakeSessionRepository) RevokeFamily(ctx context.Context, familyId string, now time.Time) error {
+	revokedAt := now.UTC().Format(drankAtLayout)
+	for _, session := range r.sessions {
+		if session.FamilyID == familyId && session.RevokedAt == nil {
+			session.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+// revoked familyIdの系列がすべて失効しているかどうか
+func (r *fakeSessionRepository) revoked(familyId string) bool {
+	for _, session := range r.sessions {
+		if session.FamilyID == familyId && session.RevokedAt == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// fakePasswordResetRepository ResetPasswordで変更した内容を保持する
+type fakePasswordResetRepository struct {
+	repository.PasswordResetRepository
+	resets []*model.PasswordReset
+	// passwords ResetPasswordで設定したユーザーごとのハッシュ化したパスワード
+	passwords map[int64]string
+	// revoked ResetPasswordでセッションとパーソナルアクセストークンを失効させたユーザー
+	revoked []int64
+}
+
+func (r *fakePasswordResetRepository) CreatePasswordReset(ctx context.Context, reset *model.PasswordReset) (*model.PasswordReset, error) {
+	reset.ID = int64(len(r.resets) + 1)
+	r.resets = append(r.resets, reset)
+	return reset, nil
+}
+
+func (r *fakePasswordResetRepository) GetPasswordResetByTokenHash(ctx context.Context, tokenHash string) (*model.PasswordReset, error) {
+	for _, reset := range r.resets {
+		if reset.TokenHash == tokenHash {
+			return reset, nil
+		}
+	}
+	return &model.PasswordReset{}, repository.ErrPasswordResetNotFound
+}
+
+func (r *fakePasswordResetRepository) CountPasswordResetsSince(ctx context.Context, userId int64, since time.Time) (int64, error) {
+	var count int64
+	for _, reset := range r.resets {
+		if reset.UserID == userId && reset.CreatedAt >= since.UTC().Format(drankAtLayout) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakePasswordResetRepository) ResetPassword(ctx context.Context, reset *model.PasswordReset, hashedPassword string, now time.Time) (bool, error) {
+	if reset.UsedAt != nil {
+		return false, nil
+	}
+	usedAt := now.UTC().Format(drankAtLayout)
+	for _, saved := range r.resets {
+		if saved.UserID == reset.UserID && saved.UsedAt == nil {
+			saved.UsedAt = &usedAt
+		}
+	}
+	if r.passwords == nil {
+		r.passwords = map[int64]string{}
+	}
+	r.passwords[reset.UserID] = hashedPassword
+	r.revoked = append(r.revoked, reset.UserID)
+	return true, nil
+}
+
+type fakeEmailVerificationRepository struct {
+	repository.EmailVerificationRepository
+	verifications []*model.EmailVerification
+	// verified VerifyEmailで確認済みにしたユーザー
+	verified []int64
+	// err VerifyEmailが返すエラー。トランザクションが失敗した場合は何も変更しない
+	err error
+}
+
+func (r *fakeEmailVerificationRepository) GetEmailVerificationByTokenHash(ctx context.Context, tokenHash string) (*model.EmailVerification, error) {
+	for _, verification := range r.verifications {
+		if verification.TokenHash == tokenHash {
+			return verification, nil
+		}
+	}
+	return &model.EmailVerification{}, repository.ErrEmailVerificationNotFound
+}
+
+func (r *fakeEmailVerificationRepository) VerifyEmail(ctx context.Context, verification *model.EmailVerification, now time.Time) (bool, error) {
+	if r.err != nil {
+		return false, r.err
+	}
+	if verification.UsedAt != nil {
+		return false, nil
+	}
+	usedAt := now.UTC().Format(drankAtLayout)
+	verification.UsedAt = &usedAt
+	r.verified = append(r.verified, verification.UserID)
+	return true, nil
+}
+
+func (r *fakeEmailVerificationRepository) CreateEmailVerification(ctx context.Context, verification *model.EmailVerification) (*model.EmailVerification, error) {
+	verification.ID = int64(len(r.verifications) + 1)
+	r.verifications = append(r.verifications, verification)
+	return verification, nil
+}
+
+func (r *fakeEmailVerificationRepository) CountEmailVerificationsSince(ctx context.Context, userId int64, since time.Time) (int64, error) {
+	var count int64
+	for _, verification := range r.verifications {
+		if verification.UserID == userId && verification.CreatedAt >= since.UTC().Format(drankAtLayout) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// fakeMailUseCase 送信待ちにしたメールを送らずに保持する
+type fakeMailUseCase struct {
+	MailUseCase
+	enqueued []*fakeMail
+}
+
+type fakeMail struct {
+	to       string
+	template string
+	data     interface{}
+}
+
+func (uc *fakeMailUseCase) Enqueue(c context.Context, to, template, locale string, data interface{}) error {
+	uc.enqueued = append(uc.enqueued, &fakeMail{to: to, template: template, data: data})
+	return nil
+}
+
+type fakeContainerRepository struct {
+	repository.ContainerRepository
+	containers []*model.Container
+}
+
+func (r *fakeContainerRepository) GetContainer(ctx context.Context, containerId int64) (*model.Container, error) {
+	for _, container := range r.containers {
+		if container.ID == containerId {
+			return container, nil
+		}
+	}
+	return &model.Container{}, repository.ErrContainerNotFound
+}
+
+func (r *fakeContainerRepository) IncrementUsage(ctx context.Context, containerId int64) error {
+	return nil
+}
+
+type fakeAchievementUseCase struct {
+	AchievementUseCase
+	scheduled []int64
+}
+
+func (uc *fakeAchievementUseCase) Schedule(c context.Context, userId int64) error {
+	uc.scheduled = append(uc.scheduled, userId)
+	return nil
+}
+
+// fixedNow locにおけるvalue(YYYY-MM-DD HH:MM:SS)を現在時刻として返す
+func fixedNow(value string, loc *time.Location) func() time.Time {
+	now, err := time.ParseInLocation(drankAtLayout, value, loc)
+	if err != nil {
+		panic(err)
+	}
+	return func() time.Time { return now }
+}
@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+// maxGoalVolume 目標として設定できる最大量(ml)
+const maxGoalVolume = 10000
+
+type GoalUseCase interface {
+	Fetch(c context.Context, userId int64) (*model.GoalSettings, error)
+	// Set effectiveDateが空の場合はユーザーのタイムゾーンにおける今日から適用する
+	Set(c context.Context, userId, volume int64, effectiveDate string) (*model.Goal, error)
+	Recommend(c context.Context, userId, exerciseMinutes int64) (*model.GoalRecommendation, error)
+}
+
+type goalUseCase struct {
+	repository     repository.GoalRepository
+	userRepository repository.UserRepository
+	timeout        time.Duration
+}
+
+func NewGoalUseCase(goalRepo repository.GoalRepository, userRepo repository.UserRepository) GoalUseCase {
+	return &goalUseCase{
+		repository:     goalRepo,
+		userRepository: userRepo,
+		timeout:        time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *goalUseCase) Fetch(c context.Context, userId int64) (*model.GoalSettings, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	_, loc, err := userLocation(ctx, uc.userRepository, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	goals, err := uc.repository.GetGoals(ctx, userId)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	settings := &model.GoalSettings{
+		Current:   defaultGoalVolume,
+		IsDefault: true,
+		History:   goals,
+	}
+	if goal := goalAt(goals, today(loc).Format(dateLayout)); goal != nil {
+		settings.Current = goal.Volume
+		settings.IsDefault = goal.Source != model.GoalSourceManual
+	}
+	return settings, nil
+}
+
+func (uc *goalUseCase) Set(c context.Context, userId, volume int64, effectiveDate string) (*model.Goal, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if volume <= 0 || volume > maxGoalVolume {
+		return nil, &util.BadRequestError{Err: fmt.Errorf("volume must be between 1 and %d", maxGoalVolume)}
+	}
+
+	var date time.Time
+	if effectiveDate == "" {
+		_, loc, err := userLocation(ctx, uc.userRepository, userId)
+		if err != nil {
+			return nil, err
+		}
+		date = today(loc)
+	} else {
+		var err error
+		if date, err = parseDate(effectiveDate); err != nil {
+			return nil, err
+		}
+	}
+
+	goal, err := uc.repository.UpsertGoal(ctx, &model.Goal{
+		UserID:        userId,
+		Volume:        volume,
+		EffectiveDate: date.Format(dateLayout),
+		Source:        model.GoalSourceManual,
+	})
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return goal, nil
+}
+
+func (uc *goalUseCase) Recommend(c context.Context, userId, exerciseMinutes int64) (*model.GoalRecommendation, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if exerciseMinutes < 0 || exerciseMinutes > maxExerciseMinutes {
+		return nil, &util.BadRequestError{Err: fmt.Errorf("exercise_minutes must be between 0 and %d", maxExerciseMinutes)}
+	}
+
+	user, _, err := userLocation(ctx, uc.userRepository, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return recommendGoal(user, exerciseMinutes), nil
+}
+
+// goalOn date(YYYY-MM-DD)に有効だった目標の量を返す。goalsはEffectiveDateの昇順である前提
+// 該当する目標がない場合はdefaultGoalVolumeとfalseを返す
+func goalOn(goals []*model.Goal, date string) (int64, bool) {
+	if goal := goalAt(goals, date); goal != nil {
+		return goal.Volume, true
+	}
+	return defaultGoalVolume, false
+}
+
+// goalAt date(YYYY-MM-DD)に有効だった目標を返す。該当する目標がない場合はnilを返す
+func goalAt(goals []*model.Goal, date string) *model.Goal {
+	for i := len(goals) - 1; i >= 0; i-- {
+		if goals[i].EffectiveDate <= date {
+			return goals[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/line"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+	"github.com/mikaijun/aquagent/pkg/util/drinkparser"
+)
+
+const (
+	// lineLinkCodeTTL 連携コードの有効期間
+	lineLinkCodeTTL = 10 * time.Minute
+	// lineLinkCodeBytes 総当たりで当てられないように128bitのランダムな値をコードにする
+	lineLinkCodeBytes = 16
+	// lineLinkCodeAttempts コードが既存のものと重複した場合に発行し直す回数
+	lineLinkCodeAttempts = 3
+	lineHelpMessage      = "飲んだ量を「300」「お茶 200」「コップ2杯 3時に」のように送ると記録します。「今日」で今日の合計を確認できます。"
+)
+
+// lineLinkPattern 連携コードのメッセージ。「連携 ABCD...」または「ABCD...」。コードは26文字のbase32で、大文字小文字を区別しない
+var lineLinkPattern = regexp.MustCompile(`^(?:連携|link)?\s*([a-z2-7]{26})$`)
+
+// lineLinkCodeEncoding 入力しやすいようにパディングのないbase32にする
+var lineLinkCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type LineUseCase interface {
+	// IssueLinkCode LINEで送ってもらう連携コードを発行する
+	IssueLinkCode(c context.Context, userId int64) (*model.LineLinkCode, error)
+	Unlink(c context.Context, userId int64) error
+	// VerifySignature X-Line-Signatureがチャネルシークレットによる署名と一致するか確認する
+	VerifySignature(body []byte, signature string) bool
+	HandleEvents(c context.Context, events []*model.LineEvent) error
+}
+
+type lineUseCase struct {
+	repository         repository.LineRepository
+	beverageRepository repository.BeverageRepository
+	waterUseCase       WaterUseCase
+	client             line.Client
+	channelSecret      string
+	timeout            time.Duration
+}
+
+func NewLineUseCase(
+	lineRepo repository.LineRepository,
+	beverageRepo repository.BeverageRepository,
+	waterUseCase WaterUseCase,
+	client line.Client,
+	channelSecret string,
+) LineUseCase {
+	return &lineUseCase{
+		repository:         lineRepo,
+		beverageRepository: beverageRepo,
+		waterUseCase:       waterUseCase,
+		client:             client,
+		channelSecret:      channelSecret,
+		timeout:            time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *lineUseCase) IssueLinkCode(c context.Context, userId int64) (*model.LineLinkCode, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	var err error
+	for i := 0; i < lineLinkCodeAttempts; i++ {
+		b := make([]byte, lineLinkCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, &util.InternalServerError{Err: err}
+		}
+
+		var code *model.LineLinkCode
+		code, err = uc.repository.CreateLinkCode(ctx, &model.LineLinkCode{
+			Code:      lineLinkCodeEncoding.EncodeToString(b),
+			UserID:    userId,
+			ExpiresAt: time.Now().UTC().Add(lineLinkCodeTTL).Format(drankAtLayout),
+		})
+		if errors.Is(err, repository.ErrLineLinkCodeConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, &util.InternalServerError{Err: err}
+		}
+		return code, nil
+	}
+
+	return nil, &util.InternalServerError{Err: err}
+}
+
+func (uc *lineUseCase) Unlink(c context.Context, userId int64) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	err := uc.repository.DeleteAccount(ctx, userId)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return nil
+}
+
+func (uc *lineUseCase) VerifySignature(body []byte, signature string) bool {
+	if uc.channelSecret == "" {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(uc.channelSecret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), decoded)
+}
+
+func (uc *lineUseCase) HandleEvents(c context.Context, events []*model.LineEvent) error {
+	// 1つのイベントの処理に失敗しても残りのイベントは処理する
+	for _, event := range events {
+		if err := uc.handleEvent(c, event); err != nil {
+			log.Printf("failed to handle line event from %s: %v", event.LineUserID, err)
+		}
+	}
+	return nil
+}
+
+func (uc *lineUseCase) handleEvent(c context.Context, event *model.LineEvent) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if event.LineUserID == "" {
+		return nil
+	}
+
+	switch event.Type {
+	case model.LineEventFollow:
+		return uc.client.Reply(ctx, event.ReplyToken, "友だち追加ありがとうございます。アプリで発行した連携コードを送ってください。")
+	case model.LineEventUnfollow:
+		account, err := uc.repository.GetAccountByLineUserID(ctx, event.LineUserID)
+		if errors.Is(err, repository.ErrLineAccountNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return uc.repository.DeleteAccount(ctx, account.UserID)
+	case model.LineEventMessage:
+		if event.Text == "" {
+			return nil
+		}
+		reply, err := uc.handleMessage(ctx, event.LineUserID, normalizeLineText(event.Text))
+		if err != nil {
+			return err
+		}
+		return uc.client.Reply(ctx, event.ReplyToken, reply)
+	}
+
+	return nil
+}
+
+// handleMessage 返信するメッセージを返す
+func (uc *lineUseCase) handleMessage(ctx context.Context, lineUserId, text string) (string, error) {
+	account, err := uc.repository.GetAccountByLineUserID(ctx, lineUserId)
+	if errors.Is(err, repository.ErrLineAccountNotFound) {
+		return uc.link(ctx, lineUserId, text)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(text) {
+	case "今日", "合計", "today", "total":
+		return uc.todayMessage(ctx, account.UserID)
+	case "連携解除", "unlink":
+		if err := uc.repository.DeleteAccount(ctx, account.UserID); err != nil {
+			return "", err
+		}
+		return "連携を解除しました。", nil
+	case "ヘルプ", "help":
+		return lineHelpMessage, nil
+	}
+
+	waters, err := uc.waterUseCase.Parse(ctx, account.UserID, text, true)
+	var badRequest *util.BadRequestError
+	if errors.As(err, &badRequest) {
+		if errors.Is(badRequest.Err, drinkparser.ErrNoDrink) {
+			return lineHelpMessage, nil
+		}
+		return "記録できませんでした: " + badRequest.Err.Error(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	names, err := uc.beverageNames(ctx, account.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	for _, water := range waters {
+		label := "水"
+		if name, ok := names[water.BeverageID]; ok {
+			label = name
+		}
+		lines = append(lines, fmt.Sprintf("%s %dmlを記録しました。", label, water.Volume))
+	}
+
+	total, err := uc.todayMessage(ctx, account.UserID)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(append(lines, total), "\n"), nil
+}
+
+// link 連携していないLINEアカウントからのメッセージは連携コードとして扱う
+func (uc *lineUseCase) link(ctx context.Context, lineUserId, text string) (string, error) {
+	matches := lineLinkPattern.FindStringSubmatch(strings.ToLower(text))
+	if matches == nil {
+		return "まだアカウントと連携していません。アプリで発行した連携コードを送ってください。", nil
+	}
+
+	userId, err := uc.repository.ConsumeLinkCode(ctx, strings.ToUpper(matches[1]), time.Now())
+	if errors.Is(err, repository.ErrLineLinkCodeNotFound) {
+		return "連携コードが正しくないか、有効期限が切れています。", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	_, err = uc.repository.LinkAccount(ctx, &model.LineAccount{UserID: userId, LineUserID: lineUserId})
+	if err != nil {
+		return "", err
+	}
+
+	return "アカウントと連携しました。\n" + lineHelpMessage, nil
+}
+
+func (uc *lineUseCase) todayMessage(ctx context.Context, userId int64) (string, error) {
+	summaries, err := uc.waterUseCase.DailySummary(ctx, userId, "", "")
+	if err != nil {
+		return "", err
+	}
+	if len(summaries) == 0 {
+		return "今日はまだ記録がありません。", nil
+	}
+
+	summary := summaries[0]
+	return fmt.Sprintf("今日の合計: %dml / 目標 %dml (%.0f%%)", summary.EffectiveVolume, summary.Goal, summary.Progress*100), nil
+}
+
+// beverageNames 飲み物のIDから名前を引けるようにする
+func (uc *lineUseCase) beverageNames(ctx context.Context, userId int64) (map[int64]string, error) {
+	beverages, err := uc.beverageRepository.GetBeverages(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[int64]string{}
+	for _, beverage := range beverages {
+		names[beverage.ID] = beverage.Name
+	}
+	return names, nil
+}
+
+// normalizeLineText 全角の数字と英字、空白を半角にそろえる
+func normalizeLineText(text string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - '！' + '!'
+		}
+		return r
+	}, text))
+}
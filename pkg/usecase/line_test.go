@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+func newTestLineUseCase(lineRepo *fakeLineRepository, client *fakeLineClient) LineUseCase {
+	return NewLineUseCase(lineRepo, &fakeBeverageRepository{}, nil, client, "secret")
+}
+
+func TestLineUseCaseIssueLinkCode(t *testing.T) {
+	lineRepo := &fakeLineRepository{conflicts: lineLinkCodeAttempts - 1}
+	uc := newTestLineUseCase(lineRepo, &fakeLineClient{})
+
+	code, err := uc.IssueLinkCode(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("IssueLinkCode() error = %v", err)
+	}
+	if !regexp.MustCompile(`^[A-Z2-7]{26}$`).MatchString(code.Code) {
+		t.Fatalf("Code = %q, want 26 base32 characters", code.Code)
+	}
+
+	other, err := uc.IssueLinkCode(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("IssueLinkCode() error = %v", err)
+	}
+	if other.Code == code.Code {
+		t.Fatalf("issued the same code twice: %s", code.Code)
+	}
+}
+
+func TestLineUseCaseIssueLinkCodeConflict(t *testing.T) {
+	lineRepo := &fakeLineRepository{conflicts: lineLinkCodeAttempts}
+	uc := newTestLineUseCase(lineRepo, &fakeLineClient{})
+
+	var internal *util.InternalServerError
+	if _, err := uc.IssueLinkCode(context.Background(), 1); !errors.As(err, &internal) {
+		t.Fatalf("IssueLinkCode() error = %v, want InternalServerError", err)
+	}
+}
+
+func TestLineUseCaseLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     func(code string) string
+		wantLink bool
+	}{
+		{name: "code", text: func(code string) string { return code }, wantLink: true},
+		{name: "with prefix", text: func(code string) string { return "連携 " + code }, wantLink: true},
+		{name: "lower case", text: func(code string) string { return "link " + strings.ToLower(code) }, wantLink: true},
+		{name: "full width space", text: func(code string) string { return "連携　" + code }, wantLink: true},
+		{name: "digits only", text: func(code string) string { return "123456" }},
+		{name: "wrong code", text: func(code string) string { return strings.Repeat("A", 26) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lineRepo := &fakeLineRepository{}
+			client := &fakeLineClient{}
+			uc := newTestLineUseCase(lineRepo, client)
+
+			code, err := uc.IssueLinkCode(context.Background(), 1)
+			if err != nil {
+				t.Fatalf("IssueLinkCode() error = %v", err)
+			}
+
+			err = uc.HandleEvents(context.Background(), []*model.LineEvent{
+				{Type: model.LineEventMessage, ReplyToken: "reply", LineUserID: "U1", Text: tt.text(code.Code)},
+			})
+			if err != nil {
+				t.Fatalf("HandleEvents() error = %v", err)
+			}
+
+			linked := len(lineRepo.accounts) == 1 && lineRepo.accounts[0].UserID == 1
+			if linked != tt.wantLink {
+				t.Fatalf("linked = %v, want %v (replies %v)", linked, tt.wantLink, client.replies)
+			}
+			if len(client.replies) != 1 {
+				t.Fatalf("replies = %v, want 1", client.replies)
+			}
+		})
+	}
+}
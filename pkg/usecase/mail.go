@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/mailer"
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+	"github.com/mikaijun/aquagent/pkg/util/mailtemplate"
+)
+
+const (
+	// mailBatchSize 1回のジョブで送るメールの最大件数
+	mailBatchSize = 20
+	// maxMailAttempts これだけ失敗したメールは再送しない
+	maxMailAttempts = 5
+	// mailSendTimeout 1通のメールの送信を待つ時間
+	mailSendTimeout = 10 * time.Second
+)
+
+type MailUseCase interface {
+	// Enqueue テンプレートからメールを作ってoutboxに保存する。送信はDeliverPendingで行う
+	Enqueue(c context.Context, to, template, locale string, data interface{}) error
+	// DeliverPending 未送信のメールを送る。失敗したメールは間隔を空けて再送する
+	DeliverPending(c context.Context, now time.Time) error
+}
+
+type mailUseCase struct {
+	repository repository.MailRepository
+	mailer     mailer.Mailer
+	timeout    time.Duration
+}
+
+func NewMailUseCase(mailRepo repository.MailRepository, mailer mailer.Mailer) MailUseCase {
+	return &mailUseCase{
+		repository: mailRepo,
+		mailer:     mailer,
+		timeout:    time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *mailUseCase) Enqueue(c context.Context, to, template, locale string, data interface{}) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	subject, body, err := mailtemplate.Render(template, locale, data)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	now := time.Now().UTC().Format(drankAtLayout)
+	_, err = uc.repository.CreateMail(ctx, &model.Mail{
+		To:            to,
+		Subject:       subject,
+		Body:          body,
+		Status:        model.MailStatusPending,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	})
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return nil
+}
+
+func (uc *mailUseCase) DeliverPending(c context.Context, now time.Time) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	mails, err := uc.repository.GetPendingMails(ctx, now, mailBatchSize)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	for _, mail := range mails {
+		if err := uc.deliver(c, mail, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver 送信に失敗しても他のメールは送り続けるため、結果の記録に失敗した場合だけエラーを返す
+func (uc *mailUseCase) deliver(c context.Context, mail *model.Mail, now time.Time) error {
+	sendCtx, cancel := context.WithTimeout(c, mailSendTimeout)
+	sendErr := uc.mailer.Send(sendCtx, mail)
+	cancel()
+
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if sendErr == nil {
+		return uc.repository.MarkMailSent(ctx, mail.ID, now)
+	}
+
+	log.Printf("failed to send mail %d: %v", mail.ID, sendErr)
+	attempts := mail.Attempts + 1
+	if attempts >= maxMailAttempts {
+		return uc.repository.MarkMailFailed(ctx, mail.ID, sendErr.Error())
+	}
+	// 1分、4分、9分...と再送の間隔を空ける
+	backoff := time.Duration(attempts*attempts) * time.Minute
+	return uc.repository.MarkMailRetry(ctx, mail.ID, sendErr.Error(), now.Add(backoff))
+}
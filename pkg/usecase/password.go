@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+	"github.com/mikaijun/aquagent/pkg/util/mailtemplate"
+)
+
+const (
+	// PasswordResetTTL 再設定用のリンクの有効期間
+	PasswordResetTTL = time.Hour
+	// passwordResetInterval 再設定用のメールを続けて送れない間隔
+	passwordResetInterval = time.Minute
+	// maxPasswordResetsPerHour 1時間に送れる再設定用のメールの最大件数
+	maxPasswordResetsPerHour = 5
+)
+
+var errInvalidPasswordReset = errors.New("reset token is invalid or expired")
+
+type PasswordUseCase interface {
+	// Forgot 登録されていないメールアドレスや、送りすぎで送らなかった場合も成功として扱い、登録の有無を明かさない
+	Forgot(c context.Context, email, locale string) error
+	// Reset パスワードを変更し、すべての端末からログアウトさせてパーソナルアクセストークンも失効させる
+	Reset(c context.Context, token, password string) error
+}
+
+type passwordUseCase struct {
+	userRepository          repository.UserRepository
+	passwordResetRepository repository.PasswordResetRepository
+	mailUseCase             MailUseCase
+	// resetURL トークンをクエリに付けてメールに載せる、再設定画面のURL
+	resetURL string
+	timeout  time.Duration
+}
+
+func NewPasswordUseCase(
+	userRepo repository.UserRepository,
+	passwordResetRepo repository.PasswordResetRepository,
+	mailUseCase MailUseCase,
+	resetURL string,
+) PasswordUseCase {
+	return &passwordUseCase{
+		userRepository:          userRepo,
+		passwordResetRepository: passwordResetRepo,
+		mailUseCase:             mailUseCase,
+		resetURL:                resetURL,
+		timeout:                 time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *passwordUseCase) Forgot(c context.Context, email, locale string) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	user, err := uc.userRepository.GetUserByEmail(ctx, email)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+	if user.ID == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	limited, err := uc.rateLimited(ctx, user.ID, now)
+	if err != nil {
+		return err
+	}
+	if limited {
+		return nil
+	}
+
+	token, hash, err := util.GenerateOpaqueToken()
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	_, err = uc.passwordResetRepository.CreatePasswordReset(ctx, &model.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: hash,
+		CreatedAt: now.Format(drankAtLayout),
+		ExpiresAt: now.Add(PasswordResetTTL).Format(drankAtLayout),
+	})
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return uc.mailUseCase.Enqueue(ctx, user.Email, mailtemplate.PasswordReset, locale, &mailtemplate.PasswordResetData{
+		Username:         user.Username,
+		URL:              uc.resetURL + "?token=" + url.QueryEscape(token),
+		ExpiresInMinutes: int64(PasswordResetTTL.Minutes()),
+	})
+}
+
+func (uc *passwordUseCase) Reset(c context.Context, token, password string) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	reset, err := uc.passwordResetRepository.GetPasswordResetByTokenHash(ctx, util.HashToken(token))
+	if errors.Is(err, repository.ErrPasswordResetNotFound) {
+		return &util.BadRequestError{Err: errInvalidPasswordReset}
+	}
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	now := time.Now()
+	if reset.UsedAt != nil || reset.ExpiresAt <= now.UTC().Format(drankAtLayout) {
+		return &util.BadRequestError{Err: errInvalidPasswordReset}
+	}
+
+	hashedPassword, err := util.HashPassword(password)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	used, err := uc.passwordResetRepository.ResetPassword(ctx, reset, hashedPassword, now)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+	if !used {
+		return &util.BadRequestError{Err: errInvalidPasswordReset}
+	}
+
+	return nil
+}
+
+// rateLimited 同じユーザーに再設定用のメールを送りすぎないようにする
+func (uc *passwordUseCase) rateLimited(ctx context.Context, userId int64, now time.Time) (bool, error) {
+	recent, err := uc.passwordResetRepository.CountPasswordResetsSince(ctx, userId, now.Add(-passwordResetInterval))
+	if err != nil {
+		return false, &util.InternalServerError{Err: err}
+	}
+	hourly, err := uc.passwordResetRepository.CountPasswordResetsSince(ctx, userId, now.Add(-time.Hour))
+	if err != nil {
+		return false, &util.InternalServerError{Err: err}
+	}
+	return recent > 0 || hourly >= maxPasswordResetsPerHour, nil
+}
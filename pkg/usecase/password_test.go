@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/util"
+	"github.com/mikaijun/aquagent/pkg/util/mailtemplate"
+)
+
+func newTestPasswordUseCase() (*passwordUseCase, *fakePasswordResetRepository, *fakeMailUseCase) {
+	resets := &fakePasswordResetRepository{}
+	mails := &fakeMailUseCase{}
+	users := newFakeUserRepository(&model.User{ID: 1, Username: "mikai", Email: "mikai@example.com"})
+	uc := NewPasswordUseCase(users, resets, mails, "https://app.example.com/reset").(*passwordUseCase)
+	return uc, resets, mails
+}
+
+// forgotToken Forgotで送ったメールのURLからトークンを取り出す
+func forgotToken(t *testing.T, mail *fakeMail) string {
+	t.Helper()
+	data, ok := mail.data.(*mailtemplate.PasswordResetData)
+	if !ok {
+		t.Fatalf("mail data = %T, want *mailtemplate.PasswordResetData", mail.data)
+	}
+	u, err := url.Parse(data.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get("token")
+}
+
+func TestPasswordUseCaseForgot(t *testing.T) {
+	uc, resets, mails := newTestPasswordUseCase()
+	ctx := context.Background()
+
+	if err := uc.Forgot(ctx, "unknown@example.com", "ja"); err != nil {
+		t.Fatalf("Forgot() for an unknown email error = %v, want nil", err)
+	}
+	if len(mails.enqueued) != 0 {
+		t.Fatalf("Forgot() for an unknown email sent %d mails, want 0", len(mails.enqueued))
+	}
+
+	if err := uc.Forgot(ctx, "mikai@example.com", "ja"); err != nil {
+		t.Fatalf("Forgot() error = %v", err)
+	}
+	if len(mails.enqueued) != 1 || mails.enqueued[0].to != "mikai@example.com" {
+		t.Fatalf("Forgot() sent %v, want one mail to mikai@example.com", mails.enqueued)
+	}
+	token := forgotToken(t, mails.enqueued[0])
+	if resets.resets[0].TokenHash != util.HashToken(token) {
+		t.Errorf("saved token hash does not match the token in the mail")
+	}
+
+	// 続けて依頼しても成功として扱い、メールは送らない
+	if err := uc.Forgot(ctx, "mikai@example.com", "ja"); err != nil {
+		t.Fatalf("Forgot() within the interval error = %v, want nil", err)
+	}
+	if len(mails.enqueued) != 1 {
+		t.Errorf("Forgot() within the interval sent %d mails, want 1", len(mails.enqueued))
+	}
+}
+
+func TestPasswordUseCaseForgotHourlyLimit(t *testing.T) {
+	uc, resets, mails := newTestPasswordUseCase()
+	now := time.Now().UTC()
+	for i := 0; i < maxPasswordResetsPerHour; i++ {
+		resets.resets = append(resets.resets, &model.PasswordReset{
+			UserID:    1,
+			CreatedAt: now.Add(-time.Duration(i+2) * time.Minute).Format(drankAtLayout),
+		})
+	}
+
+	if err := uc.Forgot(context.Background(), "mikai@example.com", "ja"); err != nil {
+		t.Fatalf("Forgot() over the hourly limit error = %v, want nil", err)
+	}
+	if len(mails.enqueued) != 0 {
+		t.Errorf("Forgot() over the hourly limit sent %d mails, want 0", len(mails.enqueued))
+	}
+}
+
+func TestPasswordUseCaseReset(t *testing.T) {
+	uc, resets, mails := newTestPasswordUseCase()
+	ctx := context.Background()
+	if err := uc.Forgot(ctx, "mikai@example.com", "ja"); err != nil {
+		t.Fatal(err)
+	}
+	token := forgotToken(t, mails.enqueued[0])
+
+	if err := uc.Reset(ctx, token, "new-password"); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if err := util.CheckPassword(resets.passwords[1], "new-password"); err != nil {
+		t.Errorf("password was not updated: %v", err)
+	}
+	if len(resets.revoked) != 1 || resets.revoked[0] != 1 {
+		t.Errorf("revoked = %v, want sessions and tokens of user 1 revoked", resets.revoked)
+	}
+
+	// 同じトークンは2回使えない
+	var badRequest *util.BadRequestError
+	if err := uc.Reset(ctx, token, "another-password"); !errors.As(err, &badRequest) {
+		t.Errorf("Reset() with a used token error = %v, want BadRequestError", err)
+	}
+	if err := uc.Reset(ctx, "unknown", "another-password"); !errors.As(err, &badRequest) {
+		t.Errorf("Reset() with an unknown token error = %v, want BadRequestError", err)
+	}
+}
+
+func TestPasswordUseCaseResetExpired(t *testing.T) {
+	uc, resets, _ := newTestPasswordUseCase()
+	now := time.Now().UTC()
+	resets.resets = append(resets.resets, &model.PasswordReset{
+		ID:        1,
+		UserID:    1,
+		TokenHash: util.HashToken("expired"),
+		CreatedAt: now.Add(-2 * PasswordResetTTL).Format(drankAtLayout),
+		ExpiresAt: now.Add(-PasswordResetTTL).Format(drankAtLayout),
+	})
+
+	var badRequest *util.BadRequestError
+	if err := uc.Reset(context.Background(), "expired", "new-password"); !errors.As(err, &badRequest) {
+		t.Errorf("Reset() with an expired token error = %v, want BadRequestError", err)
+	}
+	if len(resets.revoked) != 0 {
+		t.Errorf("Reset() with an expired token revoked %v", resets.revoked)
+	}
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+type PushUseCase interface {
+	Subscribe(c context.Context, subscription *model.PushSubscription) (*model.PushSubscription, error)
+	Unsubscribe(c context.Context, userId int64, endpoint string) error
+	// PublicKey クライアントが購読時に使うVAPIDの公開鍵
+	PublicKey(c context.Context) (string, error)
+}
+
+type pushUseCase struct {
+	repository     repository.PushSubscriptionRepository
+	vapidPublicKey string
+	timeout        time.Duration
+}
+
+// NewPushUseCase vapidPublicKeyが空の場合はWeb Pushが無効になっている
+func NewPushUseCase(pushRepo repository.PushSubscriptionRepository, vapidPublicKey string) PushUseCase {
+	return &pushUseCase{
+		repository:     pushRepo,
+		vapidPublicKey: vapidPublicKey,
+		timeout:        time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *pushUseCase) Subscribe(c context.Context, subscription *model.PushSubscription) (*model.PushSubscription, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if err := validatePushSubscription(subscription); err != nil {
+		return nil, &util.BadRequestError{Err: err}
+	}
+
+	subscription, err := uc.repository.CreateSubscription(ctx, subscription)
+	if errors.Is(err, repository.ErrPushSubscriptionTaken) {
+		return nil, &util.ForbiddenError{Err: err}
+	}
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return subscription, nil
+}
+
+func (uc *pushUseCase) Unsubscribe(c context.Context, userId int64, endpoint string) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	err := uc.repository.DeleteSubscription(ctx, userId, endpoint)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return nil
+}
+
+func (uc *pushUseCase) PublicKey(c context.Context) (string, error) {
+	if uc.vapidPublicKey == "" {
+		return "", &util.NotFoundError{Err: errors.New("web push is not configured")}
+	}
+	return uc.vapidPublicKey, nil
+}
+
+// validatePushSubscription 鍵はパディングの有無を揃えて保存する
+// サーバーから内部のネットワークにリクエストさせないように、endpointは公開されたhttpsのURLに限る
+// 名前解決の結果は送信時に確認する(notifierimpl.NewPushHTTPClient)
+func validatePushSubscription(subscription *model.PushSubscription) error {
+	endpoint, err := url.Parse(subscription.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Hostname() == "" || endpoint.User != nil {
+		return errors.New("endpoint must be an absolute https url")
+	}
+	host := strings.ToLower(strings.TrimSuffix(endpoint.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("endpoint must be a public host")
+	}
+	if ip := net.ParseIP(host); ip != nil && !util.IsPublicIP(ip) {
+		return errors.New("endpoint must be a public host")
+	}
+
+	p256dh, err := decodePushKey(subscription.P256dh)
+	if err != nil || len(p256dh) != 65 || p256dh[0] != 0x04 {
+		return errors.New("p256dh must be an uncompressed P-256 public key")
+	}
+	auth, err := decodePushKey(subscription.Auth)
+	if err != nil || len(auth) != 16 {
+		return errors.New("auth must be a 16 byte secret")
+	}
+
+	subscription.P256dh = base64.RawURLEncoding.EncodeToString(p256dh)
+	subscription.Auth = base64.RawURLEncoding.EncodeToString(auth)
+	return nil
+}
+
+func decodePushKey(key string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(key, "="))
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+)
+
+const (
+	// maxExerciseMinutes 1日の運動時間として受け付ける最大値
+	maxExerciseMinutes = 24 * 60
+	// exerciseVolumePerMinute 運動1分あたりに追加する量(ml)。1時間あたり600ml
+	exerciseVolumePerMinute = 10
+	minRecommendedVolume    = 1000
+	recommendationStep      = 50
+)
+
+// 体重が分からない場合の性別ごとの目安(ml)
+var baseVolumeBySex = map[string]float64{
+	model.SexMale:   2500,
+	model.SexFemale: 2000,
+}
+
+const baseVolumeUnknownSex = 2200
+
+var activityFactors = map[string]float64{
+	model.ActivitySedentary:  1.0,
+	model.ActivityLight:      1.05,
+	model.ActivityModerate:   1.1,
+	model.ActivityActive:     1.2,
+	model.ActivityVeryActive: 1.3,
+}
+
+// defaultGoalVolume 保存された目標がない日に使う値。プロフィールを変えても過去の判定が変わらないように固定する
+const defaultGoalVolume = baseVolumeUnknownSex
+
+// saveRecommendedGoal 今日から適用される推奨値を保存する。推奨値はプロフィールが変わる前の日には適用しない
+// 手動の目標が有効な場合や、推奨値が変わらない場合は何もしない
+func saveRecommendedGoal(ctx context.Context, goalRepo repository.GoalRepository, user *model.User, loc *time.Location) error {
+	goals, err := goalRepo.GetGoals(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	date := today(loc).Format(dateLayout)
+	volume := recommendGoal(user, 0).Volume
+	if current := goalAt(goals, date); current != nil {
+		if current.Source == model.GoalSourceManual || current.Volume == volume {
+			return nil
+		}
+	}
+
+	_, err = goalRepo.UpsertGoal(ctx, &model.Goal{
+		UserID:        user.ID,
+		Volume:        volume,
+		EffectiveDate: date,
+		Source:        model.GoalSourceRecommended,
+	})
+	return err
+}
+
+// recommendGoal 体重1kgあたりの必要量を年齢で切り替え、活動量と当日の運動時間で補正する
+func recommendGoal(user *model.User, exerciseMinutes int64) *model.GoalRecommendation {
+	recommendation := &model.GoalRecommendation{
+		ExerciseMinutes: exerciseMinutes,
+		ProfileComplete: user.WeightKg > 0,
+	}
+
+	var base float64
+	if user.WeightKg > 0 {
+		base = user.WeightKg * volumePerKg(user.Age)
+	} else if volume, ok := baseVolumeBySex[user.Sex]; ok {
+		base = volume
+	} else {
+		base = baseVolumeUnknownSex
+	}
+
+	if factor, ok := activityFactors[user.ActivityLevel]; ok {
+		base *= factor
+	}
+
+	recommendation.BaseVolume = roundVolume(base)
+	recommendation.ExerciseVolume = exerciseMinutes * exerciseVolumePerMinute
+
+	volume := recommendation.BaseVolume + recommendation.ExerciseVolume
+	if volume < minRecommendedVolume {
+		volume = minRecommendedVolume
+	}
+	if volume > maxGoalVolume {
+		volume = maxGoalVolume
+	}
+	recommendation.Volume = volume
+
+	return recommendation
+}
+
+func volumePerKg(age int64) float64 {
+	switch {
+	case age == 0:
+		return 35
+	case age <= 30:
+		return 40
+	case age <= 55:
+		return 35
+	default:
+		return 30
+	}
+}
+
+// roundVolume 扱いやすいようにrecommendationStep単位に丸める
+func roundVolume(volume float64) int64 {
+	return int64(math.Round(volume/recommendationStep)) * recommendationStep
+}
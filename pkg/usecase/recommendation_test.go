@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+func TestRecommendGoal(t *testing.T) {
+	tests := []struct {
+		name            string
+		user            *model.User
+		exerciseMinutes int64
+		want            int64
+	}{
+		{name: "empty profile", user: &model.User{}, want: 2200},
+		{name: "female without weight", user: &model.User{Sex: model.SexFemale}, want: 2000},
+		{name: "weight and age", user: &model.User{WeightKg: 60, Age: 25}, want: 2400},
+		{name: "activity level", user: &model.User{WeightKg: 60, Age: 40, ActivityLevel: model.ActivityActive}, want: 2500},
+		{name: "exercise", user: &model.User{WeightKg: 60, Age: 40}, exerciseMinutes: 30, want: 2400},
+		{name: "minimum", user: &model.User{WeightKg: 20, Age: 70}, want: minRecommendedVolume},
+		{name: "maximum", user: &model.User{WeightKg: 300, Age: 20}, exerciseMinutes: 600, want: maxGoalVolume},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recommendGoal(tt.user, tt.exerciseMinutes).Volume; got != tt.want {
+				t.Errorf("recommendGoal() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveRecommendedGoal(t *testing.T) {
+	todayDate := today(time.UTC).Format(dateLayout)
+	user := &model.User{ID: 1, Timezone: "UTC", WeightKg: 80, Age: 40}
+
+	tests := []struct {
+		name  string
+		goals []*model.Goal
+		want  []*model.Goal
+	}{
+		{
+			name: "no goal",
+			want: []*model.Goal{{Volume: 2800, EffectiveDate: todayDate, Source: model.GoalSourceRecommended}},
+		},
+		{
+			name:  "profile changed",
+			goals: []*model.Goal{{Volume: 2000, EffectiveDate: "2024-06-01", Source: model.GoalSourceRecommended}},
+			want: []*model.Goal{
+				{Volume: 2000, EffectiveDate: "2024-06-01", Source: model.GoalSourceRecommended},
+				{Volume: 2800, EffectiveDate: todayDate, Source: model.GoalSourceRecommended},
+			},
+		},
+		{
+			name:  "same recommendation",
+			goals: []*model.Goal{{Volume: 2800, EffectiveDate: "2024-06-01", Source: model.GoalSourceRecommended}},
+			want:  []*model.Goal{{Volume: 2800, EffectiveDate: "2024-06-01", Source: model.GoalSourceRecommended}},
+		},
+		{
+			name:  "manual goal",
+			goals: []*model.Goal{{Volume: 1500, EffectiveDate: "2024-06-01", Source: model.GoalSourceManual}},
+			want:  []*model.Goal{{Volume: 1500, EffectiveDate: "2024-06-01", Source: model.GoalSourceManual}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeGoalRepository{goals: tt.goals}
+			if err := saveRecommendedGoal(context.Background(), repo, user, time.UTC); err != nil {
+				t.Fatalf("saveRecommendedGoal() error = %v", err)
+			}
+
+			if len(repo.goals) != len(tt.want) {
+				t.Fatalf("goals = %d, want %d", len(repo.goals), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := repo.goals[i]
+				if got.Volume != want.Volume || got.EffectiveDate != want.EffectiveDate || got.Source != want.Source {
+					t.Errorf("goals[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// プロフィールを変更しても、過去の日はその日に有効だった推奨値で判定する
+func TestProfileChangeKeepsPastGoals(t *testing.T) {
+	user := &model.User{ID: 1, Timezone: "UTC"}
+	goalRepo := &fakeGoalRepository{goals: []*model.Goal{
+		{UserID: 1, Volume: 2000, EffectiveDate: "2024-06-01", Source: model.GoalSourceRecommended},
+	}}
+	waterRepo := newFakeWaterRepository(&model.Water{UserID: 1, Volume: 2100, DrankAt: "2024-06-02 12:00:00"})
+	uc := NewWaterUseCase(waterRepo, newFakeUserRepository(user), goalRepo, &fakeBeverageRepository{}, &fakeContainerRepository{}, &fakeAchievementUseCase{})
+
+	user.WeightKg = 90
+	if err := saveRecommendedGoal(context.Background(), goalRepo, user, time.UTC); err != nil {
+		t.Fatalf("saveRecommendedGoal() error = %v", err)
+	}
+
+	calendar, err := uc.MonthlyCalendar(context.Background(), 1, 2024, 6)
+	if err != nil {
+		t.Fatalf("MonthlyCalendar() error = %v", err)
+	}
+	day := calendar.Days[1]
+	if day.Goal != 2000 || !day.GoalMet {
+		t.Errorf("2024-06-02 = goal %d met %v, want goal 2000 met", day.Goal, day.GoalMet)
+	}
+}
@@ -0,0 +1,293 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/notifier"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+const (
+	clockLayout        = "15:04"
+	minIntervalMinutes = 15
+	maxIntervalMinutes = 24 * 60
+	// fixedReminderGrace 指定時刻からこの時間を過ぎた通知は、停止中に取りこぼしたものとして送らない
+	fixedReminderGrace = 30 * time.Minute
+)
+
+type ReminderUseCase interface {
+	Search(c context.Context, userId int64) ([]*model.Reminder, error)
+	Create(c context.Context, reminder *model.Reminder) (*model.Reminder, error)
+	Update(c context.Context, reminder *model.Reminder) (*model.Reminder, error)
+	Delete(c context.Context, userId, id int64) error
+	// DispatchDue now時点で通知すべきリマインダーをNotifierで配信する
+	DispatchDue(c context.Context, now time.Time) error
+}
+
+type reminderUseCase struct {
+	repository      repository.ReminderRepository
+	waterRepository repository.WaterRepository
+	userRepository  repository.UserRepository
+	notifier        notifier.Notifier
+	timeout         time.Duration
+}
+
+func NewReminderUseCase(
+	reminderRepo repository.ReminderRepository,
+	waterRepo repository.WaterRepository,
+	userRepo repository.UserRepository,
+	n notifier.Notifier,
+) ReminderUseCase {
+	return &reminderUseCase{
+		repository:      reminderRepo,
+		waterRepository: waterRepo,
+		userRepository:  userRepo,
+		notifier:        n,
+		timeout:         time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *reminderUseCase) Search(c context.Context, userId int64) ([]*model.Reminder, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	reminders, err := uc.repository.GetReminders(ctx, userId)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return reminders, nil
+}
+
+func (uc *reminderUseCase) Create(c context.Context, reminder *model.Reminder) (*model.Reminder, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if err := validateReminder(reminder); err != nil {
+		return nil, &util.BadRequestError{Err: err}
+	}
+
+	reminder, err := uc.repository.CreateReminder(ctx, reminder)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return reminder, nil
+}
+
+func (uc *reminderUseCase) Update(c context.Context, reminder *model.Reminder) (*model.Reminder, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	current, err := uc.findOwnReminder(ctx, reminder.UserID, reminder.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateReminder(reminder); err != nil {
+		return nil, &util.BadRequestError{Err: err}
+	}
+
+	updated, err := uc.repository.UpdateReminder(ctx, reminder)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+	updated.LastTriggeredAt = current.LastTriggeredAt
+
+	return updated, nil
+}
+
+func (uc *reminderUseCase) Delete(c context.Context, userId, id int64) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	_, err := uc.findOwnReminder(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+
+	err = uc.repository.DeleteReminder(ctx, id)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return nil
+}
+
+func (uc *reminderUseCase) DispatchDue(c context.Context, now time.Time) error {
+	reminders, err := uc.repository.GetEnabledReminders(c)
+	if err != nil {
+		return err
+	}
+
+	// 1件の通知に失敗しても他のリマインダーの処理は続ける
+	for _, reminder := range reminders {
+		if err := uc.dispatch(c, reminder, now); err != nil {
+			log.Printf("failed to dispatch reminder %d: %v", reminder.ID, err)
+		}
+	}
+	return nil
+}
+
+func (uc *reminderUseCase) dispatch(c context.Context, reminder *model.Reminder, now time.Time) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	_, loc, err := userLocation(ctx, uc.userRepository, reminder.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !reminderDue(reminder, now.In(loc)) {
+		return nil
+	}
+
+	lastDrankAt, err := uc.waterRepository.GetLastDrankAt(ctx, reminder.UserID, loc.String())
+	if err != nil {
+		return err
+	}
+
+	// 直前に飲んでいる場合は通知しないが、次の通知の基準にするため通知したものとして扱う
+	skip := reminder.SkipIfDrankWithinMinutes > 0 && !lastDrankAt.IsZero() &&
+		now.Sub(lastDrankAt) < time.Duration(reminder.SkipIfDrankWithinMinutes)*time.Minute
+	if !skip {
+		err := uc.notifier.Notify(ctx, &model.Notification{
+			UserID: reminder.UserID,
+			Kind:   model.NotificationKindReminder,
+			Title:  "水分補給の時間です",
+			Body:   "コップ1杯の水を飲みましょう",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return uc.repository.UpdateTriggeredAt(ctx, reminder.ID, now)
+}
+
+// findOwnReminder 他のユーザーのリマインダーは操作させない
+func (uc *reminderUseCase) findOwnReminder(ctx context.Context, userId, id int64) (*model.Reminder, error) {
+	reminder, err := uc.repository.GetReminder(ctx, id)
+	if errors.Is(err, repository.ErrReminderNotFound) {
+		return nil, &util.NotFoundError{Err: err}
+	}
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	if reminder.UserID != userId {
+		return nil, &util.ForbiddenError{Err: errors.New("reminder belongs to another user")}
+	}
+
+	return reminder, nil
+}
+
+// reminderDue localはユーザーのタイムゾーンにおける現在時刻
+func reminderDue(reminder *model.Reminder, local time.Time) bool {
+	if !reminder.Enabled || !activeOn(reminder.Days, local.Weekday()) || inQuietHours(reminder, local) {
+		return false
+	}
+
+	var lastTriggeredAt time.Time
+	if reminder.LastTriggeredAt != nil {
+		// last_triggered_atはUTCで保存している
+		lastTriggeredAt, _ = time.Parse(drankAtLayout, *reminder.LastTriggeredAt)
+	}
+
+	switch reminder.Kind {
+	case model.ReminderKindInterval:
+		return lastTriggeredAt.IsZero() ||
+			local.Sub(lastTriggeredAt) >= time.Duration(reminder.IntervalMinutes)*time.Minute
+	case model.ReminderKindFixed:
+		for _, clock := range reminder.Times {
+			c, err := time.Parse(clockLayout, clock)
+			if err != nil {
+				continue
+			}
+			at := time.Date(local.Year(), local.Month(), local.Day(), c.Hour(), c.Minute(), 0, 0, local.Location())
+			if !at.After(local) && local.Sub(at) < fixedReminderGrace && lastTriggeredAt.Before(at) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func activeOn(days []int64, weekday time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, day := range days {
+		if day == int64(weekday) {
+			return true
+		}
+	}
+	return false
+}
+
+// inQuietHours QuietStartがQuietEndより後の場合は日付をまたぐものとして扱う
+func inQuietHours(reminder *model.Reminder, local time.Time) bool {
+	if reminder.QuietStart == "" || reminder.QuietEnd == "" {
+		return false
+	}
+	start, err := time.Parse(clockLayout, reminder.QuietStart)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(clockLayout, reminder.QuietEnd)
+	if err != nil {
+		return false
+	}
+
+	now, from, until := minuteOfDay(local), minuteOfDay(start), minuteOfDay(end)
+	if from <= until {
+		return from <= now && now < until
+	}
+	return now >= from || now < until
+}
+
+func validateReminder(reminder *model.Reminder) error {
+	switch reminder.Kind {
+	case model.ReminderKindInterval:
+		if reminder.IntervalMinutes < minIntervalMinutes || reminder.IntervalMinutes > maxIntervalMinutes {
+			return fmt.Errorf("interval_minutes must be between %d and %d", minIntervalMinutes, maxIntervalMinutes)
+		}
+	case model.ReminderKindFixed:
+		if len(reminder.Times) == 0 {
+			return errors.New("times is required")
+		}
+		for _, clock := range reminder.Times {
+			if _, err := time.Parse(clockLayout, clock); err != nil {
+				return fmt.Errorf("times must be formatted as %q", clockLayout)
+			}
+		}
+	default:
+		return errors.New("kind must be interval or fixed")
+	}
+
+	for _, day := range reminder.Days {
+		if day < 0 || day > 6 {
+			return errors.New("days must be between 0 and 6")
+		}
+	}
+
+	if (reminder.QuietStart == "") != (reminder.QuietEnd == "") {
+		return errors.New("quiet_start and quiet_end must be set together")
+	}
+	for _, clock := range []string{reminder.QuietStart, reminder.QuietEnd} {
+		if _, err := time.Parse(clockLayout, clock); clock != "" && err != nil {
+			return fmt.Errorf("quiet hours must be formatted as %q", clockLayout)
+		}
+	}
+
+	if reminder.SkipIfDrankWithinMinutes < 0 || reminder.SkipIfDrankWithinMinutes > maxIntervalMinutes {
+		return fmt.Errorf("skip_if_drank_within_minutes must be between 0 and %d", maxIntervalMinutes)
+	}
+	return nil
+}
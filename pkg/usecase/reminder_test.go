@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/infrastructure/notifierimpl"
+)
+
+// utcString tのUTCにおける時刻を、last_triggered_atの形式で返す
+func utcString(t time.Time) *string {
+	formatted := t.UTC().Format(drankAtLayout)
+	return &formatted
+}
+
+func TestReminderDue(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2024-06-01は土曜日
+	at := func(value string) time.Time {
+		return fixedNow("2024-06-01 "+value, tokyo)()
+	}
+
+	tests := []struct {
+		name     string
+		reminder model.Reminder
+		now      time.Time
+		want     bool
+	}{
+		{
+			name:     "disabled",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60},
+			now:      at("10:00:00"),
+			want:     false,
+		},
+		{
+			name:     "interval never triggered",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true},
+			now:      at("10:00:00"),
+			want:     true,
+		},
+		{
+			name:     "interval not elapsed",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true, LastTriggeredAt: utcString(at("09:01:00"))},
+			now:      at("10:00:00"),
+			want:     false,
+		},
+		{
+			name:     "interval elapsed",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true, LastTriggeredAt: utcString(at("09:00:00"))},
+			now:      at("10:00:00"),
+			want:     true,
+		},
+		{
+			name:     "fixed before the time",
+			reminder: model.Reminder{Kind: model.ReminderKindFixed, Times: []string{"08:00"}, Enabled: true},
+			now:      at("07:59:59"),
+			want:     false,
+		},
+		{
+			name:     "fixed at the time",
+			reminder: model.Reminder{Kind: model.ReminderKindFixed, Times: []string{"08:00"}, Enabled: true},
+			now:      at("08:00:00"),
+			want:     true,
+		},
+		{
+			name:     "fixed within the grace",
+			reminder: model.Reminder{Kind: model.ReminderKindFixed, Times: []string{"08:00"}, Enabled: true, LastTriggeredAt: utcString(at("08:00:00").AddDate(0, 0, -1))},
+			now:      at("08:29:59"),
+			want:     true,
+		},
+		{
+			// 停止中に取りこぼした通知は送らない
+			name:     "fixed after the grace",
+			reminder: model.Reminder{Kind: model.ReminderKindFixed, Times: []string{"08:00"}, Enabled: true},
+			now:      at("08:30:00"),
+			want:     false,
+		},
+		{
+			name:     "fixed already triggered",
+			reminder: model.Reminder{Kind: model.ReminderKindFixed, Times: []string{"08:00"}, Enabled: true, LastTriggeredAt: utcString(at("08:00:00"))},
+			now:      at("08:10:00"),
+			want:     false,
+		},
+		{
+			name:     "fixed next time after triggering the previous one",
+			reminder: model.Reminder{Kind: model.ReminderKindFixed, Times: []string{"08:00", "08:15"}, Enabled: true, LastTriggeredAt: utcString(at("08:01:00"))},
+			now:      at("08:15:00"),
+			want:     true,
+		},
+		{
+			name:     "inactive weekday",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true, Days: []int64{1, 2, 3, 4, 5}},
+			now:      at("10:00:00"),
+			want:     false,
+		},
+		{
+			name:     "active weekday",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true, Days: []int64{0, 6}},
+			now:      at("10:00:00"),
+			want:     true,
+		},
+		{
+			name:     "quiet hours across midnight before midnight",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true, QuietStart: "22:00", QuietEnd: "07:00"},
+			now:      at("23:30:00"),
+			want:     false,
+		},
+		{
+			name:     "quiet hours across midnight after midnight",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true, QuietStart: "22:00", QuietEnd: "07:00"},
+			now:      at("06:59:00"),
+			want:     false,
+		},
+		{
+			name:     "quiet hours across midnight end",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true, QuietStart: "22:00", QuietEnd: "07:00"},
+			now:      at("07:00:00"),
+			want:     true,
+		},
+		{
+			name:     "quiet hours within a day",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true, QuietStart: "12:00", QuietEnd: "13:00"},
+			now:      at("12:30:00"),
+			want:     false,
+		},
+		{
+			name:     "outside quiet hours within a day",
+			reminder: model.Reminder{Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true, QuietStart: "12:00", QuietEnd: "13:00"},
+			now:      at("21:00:00"),
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := reminderDue(&tt.reminder, tt.now); got != tt.want {
+				t.Errorf("reminderDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReminderUseCaseDispatchDue(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := fixedNow("2024-06-01 08:05:00", tokyo)()
+
+	reminders := &fakeReminderRepository{reminders: []*model.Reminder{
+		{ID: 1, UserID: 1, Kind: model.ReminderKindFixed, Times: []string{"08:00"}, Enabled: true},
+		{ID: 2, UserID: 1, Kind: model.ReminderKindFixed, Times: []string{"09:00"}, Enabled: true},
+		{ID: 3, UserID: 1, Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: false},
+		// 存在しないユーザーのリマインダーで失敗しても、他のリマインダーは通知する
+		{ID: 4, UserID: 99, Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true},
+		{ID: 5, UserID: 2, Kind: model.ReminderKindInterval, IntervalMinutes: 60, Enabled: true},
+	}}
+	n := notifierimpl.NewMemoryNotifier()
+	uc := NewReminderUseCase(
+		reminders,
+		newFakeWaterRepository(),
+		newFakeUserRepository(&model.User{ID: 1, Timezone: "Asia/Tokyo"}, &model.User{ID: 2, Timezone: "UTC"}),
+		n,
+	)
+
+	if err := uc.DispatchDue(context.Background(), now); err != nil {
+		t.Fatalf("DispatchDue() error = %v", err)
+	}
+	notifications := n.Notifications()
+	if len(notifications) != 2 || notifications[0].UserID != 1 || notifications[1].UserID != 2 {
+		t.Fatalf("notifications = %v, want one for user 1 and one for user 2", notifications)
+	}
+	if notifications[0].Kind != model.NotificationKindReminder {
+		t.Errorf("Kind = %q, want %q", notifications[0].Kind, model.NotificationKindReminder)
+	}
+	for _, reminder := range reminders.reminders {
+		triggered := reminder.LastTriggeredAt != nil
+		if want := reminder.ID == 1 || reminder.ID == 5; triggered != want {
+			t.Errorf("reminder %d triggered = %v, want %v", reminder.ID, triggered, want)
+		}
+	}
+	if got := *reminders.reminders[0].LastTriggeredAt; got != "2024-05-31 23:05:00" {
+		t.Errorf("LastTriggeredAt = %q, want the dispatch time in UTC", got)
+	}
+
+	// 同じ時刻の通知は1回だけ送る
+	n.Reset()
+	if err := uc.DispatchDue(context.Background(), now.Add(time.Minute)); err != nil {
+		t.Fatalf("DispatchDue() error = %v", err)
+	}
+	if notifications := n.Notifications(); len(notifications) != 0 {
+		t.Errorf("notifications after dispatching = %v, want none", notifications)
+	}
+}
+
+func TestReminderUseCaseDispatchDueSkipIfDrank(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := fixedNow("2024-06-01 10:00:00", tokyo)()
+
+	tests := []struct {
+		name       string
+		drankAt    string
+		wantNotify bool
+	}{
+		{name: "drank recently", drankAt: "2024-06-01 09:45:00", wantNotify: false},
+		{name: "drank before the window", drankAt: "2024-06-01 09:30:00", wantNotify: true},
+		{name: "never drank", wantNotify: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reminders := &fakeReminderRepository{reminders: []*model.Reminder{
+				{ID: 1, UserID: 1, Kind: model.ReminderKindInterval, IntervalMinutes: 60, SkipIfDrankWithinMinutes: 30, Enabled: true},
+			}}
+			waters := newFakeWaterRepository()
+			if tt.drankAt != "" {
+				waters = newFakeWaterRepository(&model.Water{UserID: 1, Volume: 200, DrankAt: tt.drankAt})
+			}
+			n := notifierimpl.NewMemoryNotifier()
+			uc := NewReminderUseCase(reminders, waters, newFakeUserRepository(&model.User{ID: 1, Timezone: "Asia/Tokyo"}), n)
+
+			if err := uc.DispatchDue(context.Background(), now); err != nil {
+				t.Fatalf("DispatchDue() error = %v", err)
+			}
+			if notified := len(n.Notifications()) == 1; notified != tt.wantNotify {
+				t.Errorf("notified = %v, want %v", notified, tt.wantNotify)
+			}
+			// 見送った場合も次の通知の基準にするため、通知したものとして扱う
+			if reminders.reminders[0].LastTriggeredAt == nil {
+				t.Errorf("LastTriggeredAt was not updated")
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+// RefreshTokenTTL リフレッシュトークンの有効期間。使うたびに延長する
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+type SessionUseCase interface {
+	// Refresh リフレッシュトークンを新しいトークンと交換する
+	// 交換済みのトークンが使われた場合は、盗まれたものとみなして系列ごと失効させる
+	Refresh(c context.Context, refreshToken, userAgent, ipAddress string) (*model.TokenPair, error)
+	// Search currentSessionIdのセッションにはCurrentを設定する
+	Search(c context.Context, userId int64, currentSessionId string) ([]*model.Session, error)
+	Delete(c context.Context, userId, id int64) error
+}
+
+type sessionUseCase struct {
+	repository     repository.SessionRepository
+	userRepository repository.UserRepository
+	timeout        time.Duration
+}
+
+func NewSessionUseCase(sessionRepo repository.SessionRepository, userRepo repository.UserRepository) SessionUseCase {
+	return &sessionUseCase{
+		repository:     sessionRepo,
+		userRepository: userRepo,
+		timeout:        time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *sessionUseCase) Refresh(c context.Context, refreshToken, userAgent, ipAddress string) (*model.TokenPair, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	now := time.Now()
+	session, err := uc.repository.GetSessionByTokenHash(ctx, util.HashToken(refreshToken))
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return nil, &util.UnauthorizedError{Err: errors.New("refresh token is invalid")}
+	}
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	if session.RevokedAt != nil {
+		return nil, &util.UnauthorizedError{Err: errors.New("session is revoked")}
+	}
+	if session.RotatedAt != nil {
+		return nil, uc.revokeReused(ctx, session, now)
+	}
+	if session.ExpiresAt <= now.UTC().Format(drankAtLayout) {
+		return nil, &util.UnauthorizedError{Err: errors.New("refresh token is expired")}
+	}
+
+	rotated, err := uc.repository.RotateSession(ctx, session.ID, now)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+	if !rotated {
+		// 同じトークンが同時に使われた
+		return nil, uc.revokeReused(ctx, session, now)
+	}
+
+	user, err := uc.userRepository.GetUserById(ctx, session.UserID)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+	if user.ID == 0 {
+		return nil, &util.UnauthorizedError{Err: errors.New("user is not exist")}
+	}
+
+	pair, err := issueSession(ctx, uc.repository, user, &model.Session{
+		FamilyID:  session.FamilyID,
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		CreatedAt: session.CreatedAt,
+	}, now)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return pair, nil
+}
+
+func (uc *sessionUseCase) revokeReused(ctx context.Context, session *model.Session, now time.Time) error {
+	if err := uc.repository.RevokeFamily(ctx, session.FamilyID, now); err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+	return &util.UnauthorizedError{Err: errors.New("refresh token reuse detected")}
+}
+
+func (uc *sessionUseCase) Search(c context.Context, userId int64, currentSessionId string) ([]*model.Session, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	sessions, err := uc.repository.GetActiveSessions(ctx, userId, time.Now())
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	for _, session := range sessions {
+		session.Current = currentSessionId != "" && session.FamilyID == currentSessionId
+	}
+	return sessions, nil
+}
+
+func (uc *sessionUseCase) Delete(c context.Context, userId, id int64) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	session, err := uc.repository.GetSession(ctx, id)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return &util.NotFoundError{Err: err}
+	}
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	if session.UserID != userId {
+		return &util.ForbiddenError{Err: errors.New("session belongs to another user")}
+	}
+
+	err = uc.repository.RevokeFamily(ctx, session.FamilyID, time.Now())
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return nil
+}
+
+// startSession ログインした端末の新しい系列を作り、トークンを発行する
+func startSession(ctx context.Context, sessionRepo repository.SessionRepository, user *model.User, userAgent, ipAddress string, now time.Time) (*model.TokenPair, error) {
+	familyId, _, err := util.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return issueSession(ctx, sessionRepo, user, &model.Session{
+		FamilyID:  familyId,
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		CreatedAt: now.UTC().Format(drankAtLayout),
+	}, now)
+}
+
+// issueSession sessionの系列に新しいリフレッシュトークンを保存し、アクセストークンと合わせて返す
+func issueSession(ctx context.Context, sessionRepo repository.SessionRepository, user *model.User, session *model.Session, now time.Time) (*model.TokenPair, error) {
+	refreshToken, hash, err := util.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session.UserID = user.ID
+	session.TokenHash = hash
+	session.LastUsedAt = now.UTC().Format(drankAtLayout)
+	session.ExpiresAt = now.UTC().Add(RefreshTokenTTL).Format(drankAtLayout)
+	if _, err := sessionRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := util.GenerateSignedString(user.ID, user.Username, session.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TokenPair{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(util.AccessTokenTTL.Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int64(RefreshTokenTTL.Seconds()),
+	}, nil
+}
+
+// revokeSession ログアウトしたリフレッシュトークンの系列を失効させる。不明なトークンは無視する
+func revokeSession(ctx context.Context, sessionRepo repository.SessionRepository, refreshToken string, now time.Time) error {
+	session, err := sessionRepo.GetSessionByTokenHash(ctx, util.HashToken(refreshToken))
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return sessionRepo.RevokeFamily(ctx, session.FamilyID, now)
+}
@@ -0,0 +1,163 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+// newTestSession userIdのユーザーのログインを開始し、リフレッシュトークンを返す
+func newTestSession(t *testing.T, sessions *fakeSessionRepository, userId int64) string {
+	t.Helper()
+	t.Setenv("JWT_SECRET_KEY", "secret")
+	pair, err := startSession(context.Background(), sessions, &model.User{ID: userId, Username: "mikai"}, "test", "127.0.0.1", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pair.RefreshToken
+}
+
+func isUnauthorized(err error) bool {
+	var unauthorized *util.UnauthorizedError
+	return errors.As(err, &unauthorized)
+}
+
+func TestSessionUseCaseRefresh(t *testing.T) {
+	sessions := &fakeSessionRepository{}
+	uc := NewSessionUseCase(sessions, newFakeUserRepository(&model.User{ID: 1, Username: "mikai"}))
+	refreshToken := newTestSession(t, sessions, 1)
+	familyId := sessions.sessions[0].FamilyID
+
+	pair, err := uc.Refresh(context.Background(), refreshToken, "browser", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if pair.RefreshToken == refreshToken || pair.AccessToken == "" {
+		t.Fatalf("Refresh() = %+v, want a new token pair", pair)
+	}
+	claims, err := util.ValidateToken(pair.AccessToken)
+	if err != nil || claims.SessionID != familyId {
+		t.Errorf("access token session = %v (%v), want %s", claims, err, familyId)
+	}
+
+	// 交換したトークンは同じ系列に残り、古いトークンは交換済みになる
+	if len(sessions.sessions) != 2 {
+		t.Fatalf("sessions = %d, want 2", len(sessions.sessions))
+	}
+	old, rotated := sessions.sessions[0], sessions.sessions[1]
+	if old.RotatedAt == nil || old.RevokedAt != nil {
+		t.Errorf("old session rotated %v revoked %v, want only rotated", old.RotatedAt, old.RevokedAt)
+	}
+	if rotated.FamilyID != familyId || rotated.CreatedAt != old.CreatedAt || rotated.UserAgent != "browser" {
+		t.Errorf("rotated session = %+v, want the same family with the new user agent", rotated)
+	}
+
+	// 新しいトークンは続けて交換できる
+	if _, err := uc.Refresh(context.Background(), pair.RefreshToken, "browser", "192.0.2.1"); err != nil {
+		t.Errorf("Refresh() with the rotated token error = %v", err)
+	}
+}
+
+func TestSessionUseCaseRefreshReuse(t *testing.T) {
+	sessions := &fakeSessionRepository{}
+	uc := NewSessionUseCase(sessions, newFakeUserRepository(&model.User{ID: 1, Username: "mikai"}))
+	refreshToken := newTestSession(t, sessions, 1)
+	other := newTestSession(t, sessions, 1)
+	familyId := sessions.sessions[0].FamilyID
+
+	pair, err := uc.Refresh(context.Background(), refreshToken, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 交換済みのトークンが使われた場合は、盗まれたものとみなして系列ごと失効させる
+	if _, err := uc.Refresh(context.Background(), refreshToken, "", ""); !isUnauthorized(err) {
+		t.Fatalf("Refresh() with a rotated token error = %v, want UnauthorizedError", err)
+	}
+	if !sessions.revoked(familyId) {
+		t.Errorf("family %s was not revoked", familyId)
+	}
+	if _, err := uc.Refresh(context.Background(), pair.RefreshToken, "", ""); !isUnauthorized(err) {
+		t.Errorf("Refresh() with the token issued after rotation error = %v, want UnauthorizedError", err)
+	}
+
+	// 他の端末のセッションは失効させない
+	if _, err := uc.Refresh(context.Background(), other, "", ""); err != nil {
+		t.Errorf("Refresh() of another session error = %v", err)
+	}
+}
+
+func TestSessionUseCaseRefreshConcurrentRotation(t *testing.T) {
+	sessions := &fakeSessionRepository{}
+	uc := NewSessionUseCase(sessions, newFakeUserRepository(&model.User{ID: 1, Username: "mikai"}))
+	refreshToken := newTestSession(t, sessions, 1)
+	familyId := sessions.sessions[0].FamilyID
+
+	// 読み込んだ後に同じトークンで他のリクエストが先に交換した
+	sessions.lostRotations = 1
+	if _, err := uc.Refresh(context.Background(), refreshToken, "", ""); !isUnauthorized(err) {
+		t.Fatalf("Refresh() losing the rotation error = %v, want UnauthorizedError", err)
+	}
+	if !sessions.revoked(familyId) {
+		t.Errorf("family %s was not revoked", familyId)
+	}
+	if len(sessions.sessions) != 1 {
+		t.Errorf("sessions = %d, want no new session", len(sessions.sessions))
+	}
+}
+
+func TestSessionUseCaseRefreshInvalid(t *testing.T) {
+	sessions := &fakeSessionRepository{}
+	uc := NewSessionUseCase(sessions, newFakeUserRepository(&model.User{ID: 1, Username: "mikai"}))
+	expired := newTestSession(t, sessions, 1)
+	expiredAt := time.Now().UTC().Add(-time.Minute).Format(drankAtLayout)
+	sessions.sessions[0].ExpiresAt = expiredAt
+
+	if _, err := uc.Refresh(context.Background(), "unknown", "", ""); !isUnauthorized(err) {
+		t.Errorf("Refresh() with an unknown token error = %v, want UnauthorizedError", err)
+	}
+	if _, err := uc.Refresh(context.Background(), expired, "", ""); !isUnauthorized(err) {
+		t.Errorf("Refresh() with an expired token error = %v, want UnauthorizedError", err)
+	}
+	// 期限切れは盗用ではないため、交換も失効もしない
+	if session := sessions.sessions[0]; session.RotatedAt != nil || session.RevokedAt != nil {
+		t.Errorf("expired session = %+v, want unchanged", session)
+	}
+}
+
+func TestSessionUseCaseDelete(t *testing.T) {
+	sessions := &fakeSessionRepository{}
+	uc := NewSessionUseCase(sessions, newFakeUserRepository(&model.User{ID: 1, Username: "mikai"}, &model.User{ID: 2, Username: "other"}))
+	refreshToken := newTestSession(t, sessions, 1)
+	pair, err := uc.Refresh(context.Background(), refreshToken, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	familyId := sessions.sessions[0].FamilyID
+
+	var forbidden *util.ForbiddenError
+	if err := uc.Delete(context.Background(), 2, 2); !errors.As(err, &forbidden) {
+		t.Errorf("Delete() of another user's session error = %v, want ForbiddenError", err)
+	}
+	var notFound *util.NotFoundError
+	if err := uc.Delete(context.Background(), 1, 99); !errors.As(err, &notFound) {
+		t.Errorf("Delete() of an unknown session error = %v, want NotFoundError", err)
+	}
+	if sessions.revoked(familyId) {
+		t.Fatalf("family %s was revoked before Delete()", familyId)
+	}
+
+	if err := uc.Delete(context.Background(), 1, 2); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if !sessions.revoked(familyId) {
+		t.Errorf("family %s was not revoked", familyId)
+	}
+	if _, err := uc.Refresh(context.Background(), pair.RefreshToken, "", ""); !isUnauthorized(err) {
+		t.Errorf("Refresh() after Delete() error = %v, want UnauthorizedError", err)
+	}
+}
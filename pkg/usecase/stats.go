@@ -0,0 +1,263 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+// completionRateWindows 達成率を集計する期間(日数)
+var completionRateWindows = []int{30, 90, 365}
+
+// historyStart 全期間を集計する際の開始日
+const historyStart = "1970-01-01"
+
+// maxPatternDays 飲み方の傾向を集計できる最大日数
+const maxPatternDays = 365
+
+type StatsUseCase interface {
+	Streaks(c context.Context, userId int64) (*model.StreakStats, error)
+	// Pattern 今日を含む直近days日間の飲み方の傾向を集計する
+	Pattern(c context.Context, userId int64, days int) (*model.DrinkingPattern, error)
+}
+
+type statsUseCase struct {
+	waterRepository repository.WaterRepository
+	userRepository  repository.UserRepository
+	goalRepository  repository.GoalRepository
+	timeout         time.Duration
+}
+
+func NewStatsUseCase(waterRepo repository.WaterRepository, userRepo repository.UserRepository, goalRepo repository.GoalRepository) StatsUseCase {
+	return &statsUseCase{
+		waterRepository: waterRepo,
+		userRepository:  userRepo,
+		goalRepository:  goalRepo,
+		timeout:         time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *statsUseCase) Streaks(c context.Context, userId int64) (*model.StreakStats, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	user, loc, err := userLocation(ctx, uc.userRepository, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	results, _, err := goalResults(ctx, uc.waterRepository, uc.goalRepository, user, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	return computeStreaks(results, today(loc)), nil
+}
+
+func (uc *statsUseCase) Pattern(c context.Context, userId int64, days int) (*model.DrinkingPattern, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if days < 1 || days > maxPatternDays {
+		return nil, &util.BadRequestError{Err: fmt.Errorf("days must be between 1 and %d", maxPatternDays)}
+	}
+
+	_, loc, err := userLocation(ctx, uc.userRepository, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	end := today(loc)
+	start := end.AddDate(0, 0, -(days - 1))
+
+	drinks, err := uc.waterRepository.GetLocalDrinks(ctx, userId, start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return computePattern(drinks, start, end), nil
+}
+
+// computePattern drinksは古い順に並んでいる前提
+func computePattern(drinks []*model.LocalDrink, start, end time.Time) *model.DrinkingPattern {
+	pattern := &model.DrinkingPattern{}
+
+	// 曜日ごとの日数で割って、1日あたりの平均にする
+	var weekdays [7]int64
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		weekdays[d.Weekday()]++
+		pattern.Days++
+	}
+
+	var sums [7][24]int64
+	byDate := make(map[string][]time.Time)
+	var dates []string
+	for _, drink := range drinks {
+		sums[drink.DrankAt.Weekday()][drink.DrankAt.Hour()] += drink.Volume
+
+		date := drink.DrankAt.Format(dateLayout)
+		if _, ok := byDate[date]; !ok {
+			dates = append(dates, date)
+		}
+		byDate[date] = append(byDate[date], drink.DrankAt)
+	}
+
+	for weekday := range sums {
+		if weekdays[weekday] == 0 {
+			continue
+		}
+		for hour := range sums[weekday] {
+			average := float64(sums[weekday][hour]) / float64(weekdays[weekday])
+			pattern.Heatmap[weekday][hour] = math.Round(average*10) / 10
+		}
+	}
+
+	var firsts, lasts, gaps []int64
+	for _, date := range dates {
+		times := byDate[date]
+		firsts = append(firsts, minuteOfDay(times[0]))
+		lasts = append(lasts, minuteOfDay(times[len(times)-1]))
+
+		// 1回しか飲んでいない日は間隔を計算できないため除く
+		if len(times) < 2 {
+			continue
+		}
+		var longest int64
+		for i := 1; i < len(times); i++ {
+			if gap := int64(times[i].Sub(times[i-1]).Minutes()); gap > longest {
+				longest = gap
+			}
+		}
+		gaps = append(gaps, longest)
+	}
+
+	if len(gaps) > 0 {
+		gap := median(gaps)
+		pattern.TypicalLongestGapMinutes = &gap
+	}
+	if len(firsts) > 0 {
+		first, last := formatMinuteOfDay(median(firsts)), formatMinuteOfDay(median(lasts))
+		pattern.MedianFirstDrink = &first
+		pattern.MedianLastDrink = &last
+	}
+
+	return pattern
+}
+
+func minuteOfDay(t time.Time) int64 {
+	return int64(t.Hour()*60 + t.Minute())
+}
+
+func formatMinuteOfDay(minutes int64) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// median 要素数が偶数の場合は中央の2つの平均(切り捨て)を返す
+func median(values []int64) int64 {
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// goalResults 記録のある日付(YYYY-MM-DD)ごとに、その日に有効だった目標を達成したかどうかを返す
+// 集計に使った全期間の日ごとの集計結果もあわせて返す
+func goalResults(
+	ctx context.Context,
+	waterRepo repository.WaterRepository,
+	goalRepo repository.GoalRepository,
+	user *model.User,
+	loc *time.Location,
+) (map[string]bool, []*model.DailyTotal, error) {
+	totals, err := waterRepo.GetDailyTotals(ctx, user.ID, historyStart, today(loc).Format(dateLayout))
+	if err != nil {
+		return nil, nil, &util.InternalServerError{Err: err}
+	}
+
+	goals, err := goalRepo.GetGoals(ctx, user.ID)
+	if err != nil {
+		return nil, nil, &util.InternalServerError{Err: err}
+	}
+
+	results := make(map[string]bool, len(totals))
+	for _, total := range totals {
+		goal, _ := goalOn(goals, total.Date)
+		results[total.Date] = total.EffectiveVolume >= goal
+	}
+	return results, totals, nil
+}
+
+// computeStreaks 日付はUTCの0時として扱い、AddDateで1日ずつ進めるためDSTの切り替えに影響されない
+func computeStreaks(results map[string]bool, today time.Time) *model.StreakStats {
+	stats := &model.StreakStats{
+		TodayMet:        results[today.Format(dateLayout)],
+		CompletionRates: make(map[string]*model.CompletionRate, len(completionRateWindows)),
+	}
+
+	first := today
+	for date := range results {
+		if d, err := time.Parse(dateLayout, date); err == nil && d.Before(first) {
+			first = d
+		}
+	}
+
+	// 途中に記録のない日があれば、そこで連続記録は途切れる
+	var run int64
+	var runStart time.Time
+	for d := first; !d.After(today); d = d.AddDate(0, 0, 1) {
+		if !results[d.Format(dateLayout)] {
+			run = 0
+			continue
+		}
+		if run == 0 {
+			runStart = d
+		}
+		run++
+		if run > stats.LongestStreak {
+			start, end := runStart.Format(dateLayout), d.Format(dateLayout)
+			stats.LongestStreak = run
+			stats.LongestStreakStart = &start
+			stats.LongestStreakEnd = &end
+		}
+	}
+
+	// 今日はまだ達成できる可能性があるため、未達成なら昨日から数える
+	d := today
+	if !stats.TodayMet {
+		d = d.AddDate(0, 0, -1)
+	}
+	for results[d.Format(dateLayout)] {
+		stats.CurrentStreak++
+		d = d.AddDate(0, 0, -1)
+	}
+
+	for _, window := range completionRateWindows {
+		rate := &model.CompletionRate{}
+		for d := today.AddDate(0, 0, -(window - 1)); !d.After(today); d = d.AddDate(0, 0, 1) {
+			if d.Before(first) {
+				continue
+			}
+			rate.Days++
+			if results[d.Format(dateLayout)] {
+				rate.MetDays++
+			}
+		}
+		if rate.Days > 0 {
+			rate.Rate = math.Round(float64(rate.MetDays)/float64(rate.Days)*100) / 100
+		}
+		stats.CompletionRates[strconv.Itoa(window)] = rate
+	}
+
+	return stats
+}
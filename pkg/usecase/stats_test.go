@@ -0,0 +1,373 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+func metDays(dates ...string) map[string]bool {
+	results := make(map[string]bool, len(dates))
+	for _, date := range dates {
+		results[date] = true
+	}
+	return results
+}
+
+// localToday ユーザーのタイムゾーンの時刻から、todayと同じUTCの0時の日付を作る
+func localToday(t *testing.T, name, value string) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q) error = %v", name, err)
+	}
+	now, err := time.ParseInLocation(drankAtLayout, value, loc)
+	if err != nil {
+		t.Fatalf("ParseInLocation(%q) error = %v", value, err)
+	}
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestComputeStreaks(t *testing.T) {
+	tests := []struct {
+		name         string
+		results      map[string]bool
+		now          string
+		wantCurrent  int64
+		wantLongest  int64
+		wantStart    string
+		wantEnd      string
+		wantTodayMet bool
+	}{
+		{
+			name:    "no records",
+			results: map[string]bool{},
+			now:     "2024-06-10 12:00:00",
+		},
+		{
+			name:         "continues through today",
+			results:      metDays("2024-06-08", "2024-06-09", "2024-06-10"),
+			now:          "2024-06-10 12:00:00",
+			wantCurrent:  3,
+			wantLongest:  3,
+			wantStart:    "2024-06-08",
+			wantEnd:      "2024-06-10",
+			wantTodayMet: true,
+		},
+		{
+			name:        "today not met yet counts from yesterday",
+			results:     metDays("2024-06-08", "2024-06-09"),
+			now:         "2024-06-10 12:00:00",
+			wantCurrent: 2,
+			wantLongest: 2,
+			wantStart:   "2024-06-08",
+			wantEnd:     "2024-06-09",
+		},
+		{
+			name:         "gap day without records breaks the streak",
+			results:      metDays("2024-06-01", "2024-06-02", "2024-06-03", "2024-06-05", "2024-06-06"),
+			now:          "2024-06-06 12:00:00",
+			wantCurrent:  2,
+			wantLongest:  3,
+			wantStart:    "2024-06-01",
+			wantEnd:      "2024-06-03",
+			wantTodayMet: true,
+		},
+		{
+			// 最長が同じ長さの場合は最初の連続記録を返す
+			name:         "missed day breaks the streak",
+			results:      map[string]bool{"2024-06-07": true, "2024-06-08": false, "2024-06-09": true},
+			now:          "2024-06-09 12:00:00",
+			wantCurrent:  1,
+			wantLongest:  1,
+			wantStart:    "2024-06-07",
+			wantEnd:      "2024-06-07",
+			wantTodayMet: true,
+		},
+		{
+			name:        "gap before yesterday ends the current streak",
+			results:     metDays("2024-06-01", "2024-06-02"),
+			now:         "2024-06-10 12:00:00",
+			wantCurrent: 0,
+			wantLongest: 2,
+			wantStart:   "2024-06-01",
+			wantEnd:     "2024-06-02",
+		},
+		{
+			// 2024-03-10 2:00に夏時間が始まり、その日は23時間になる
+			name:         "across the start of DST",
+			results:      metDays("2024-03-09", "2024-03-10", "2024-03-11"),
+			now:          "2024-03-11 00:30:00",
+			wantCurrent:  3,
+			wantLongest:  3,
+			wantStart:    "2024-03-09",
+			wantEnd:      "2024-03-11",
+			wantTodayMet: true,
+		},
+		{
+			// UTCでは翌日になっていても今日として扱う
+			name:         "start of DST late at night",
+			results:      metDays("2024-03-09", "2024-03-10"),
+			now:          "2024-03-10 23:30:00",
+			wantCurrent:  2,
+			wantLongest:  2,
+			wantStart:    "2024-03-09",
+			wantEnd:      "2024-03-10",
+			wantTodayMet: true,
+		},
+		{
+			// 2024-11-03 2:00に夏時間が終わり、その日は25時間になる
+			name:         "across the end of DST",
+			results:      metDays("2024-11-02", "2024-11-03", "2024-11-04"),
+			now:          "2024-11-04 00:30:00",
+			wantCurrent:  3,
+			wantLongest:  3,
+			wantStart:    "2024-11-02",
+			wantEnd:      "2024-11-04",
+			wantTodayMet: true,
+		},
+		{
+			// 11/3が抜けているので今日だけ
+			name:         "end of DST with a gap day",
+			results:      metDays("2024-11-01", "2024-11-02", "2024-11-04"),
+			now:          "2024-11-04 23:30:00",
+			wantCurrent:  1,
+			wantLongest:  2,
+			wantStart:    "2024-11-01",
+			wantEnd:      "2024-11-02",
+			wantTodayMet: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeStreaks(tt.results, localToday(t, "America/New_York", tt.now))
+
+			if got.CurrentStreak != tt.wantCurrent {
+				t.Errorf("CurrentStreak = %d, want %d", got.CurrentStreak, tt.wantCurrent)
+			}
+			if got.LongestStreak != tt.wantLongest {
+				t.Errorf("LongestStreak = %d, want %d", got.LongestStreak, tt.wantLongest)
+			}
+			if got.TodayMet != tt.wantTodayMet {
+				t.Errorf("TodayMet = %v, want %v", got.TodayMet, tt.wantTodayMet)
+			}
+			if tt.wantLongest == 0 {
+				if got.LongestStreakStart != nil || got.LongestStreakEnd != nil {
+					t.Errorf("LongestStreakStart/End = %v/%v, want nil", got.LongestStreakStart, got.LongestStreakEnd)
+				}
+				return
+			}
+			if got.LongestStreakStart == nil || *got.LongestStreakStart != tt.wantStart {
+				t.Errorf("LongestStreakStart = %v, want %s", got.LongestStreakStart, tt.wantStart)
+			}
+			if got.LongestStreakEnd == nil || *got.LongestStreakEnd != tt.wantEnd {
+				t.Errorf("LongestStreakEnd = %v, want %s", got.LongestStreakEnd, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestComputeStreaksCompletionRates(t *testing.T) {
+	// 記録を始めて10日目で、うち1日(DSTの切り替え日)が未達成
+	results := metDays("2024-03-02", "2024-03-03", "2024-03-04", "2024-03-05", "2024-03-06",
+		"2024-03-07", "2024-03-08", "2024-03-09", "2024-03-11")
+	results["2024-03-10"] = false
+
+	got := computeStreaks(results, localToday(t, "America/New_York", "2024-03-11 21:00:00"))
+
+	for _, window := range []string{"30", "90", "365"} {
+		rate := got.CompletionRates[window]
+		if rate == nil {
+			t.Fatalf("CompletionRates[%s] is nil", window)
+		}
+		// 記録を始める前の日は数えない
+		if rate.Days != 10 || rate.MetDays != 9 || rate.Rate != 0.9 {
+			t.Errorf("CompletionRates[%s] = %+v, want 9/10 days", window, rate)
+		}
+	}
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return "nil"
+	}
+	return *v
+}
+
+// localDrink drank_at(YYYY-MM-DD HH:MM)にvolume飲んだ記録を作る
+func localDrink(t *testing.T, drankAt string, volume int64) *model.LocalDrink {
+	t.Helper()
+	parsed, err := time.Parse("2006-01-02 15:04", drankAt)
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", drankAt, err)
+	}
+	return &model.LocalDrink{Volume: volume, DrankAt: parsed}
+}
+
+func TestComputePattern(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, err := time.Parse(dateLayout, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	// 2024-06-01は土曜日
+	type cell struct {
+		weekday time.Weekday
+		hour    int
+		want    float64
+	}
+
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		drinks    [][2]interface{}
+		wantDays  int64
+		wantCells []cell
+		wantGap   *int64
+		wantFirst string
+		wantLast  string
+	}{
+		{
+			name:     "no drinks",
+			start:    "2024-06-01",
+			end:      "2024-06-14",
+			wantDays: 14,
+		},
+		{
+			// 記録のある日数ではなく、期間に含まれるその曜日の日数で割る
+			name:  "heatmap divides by the weekdays in the range",
+			start: "2024-06-01",
+			end:   "2024-06-14",
+			drinks: [][2]interface{}{
+				{"2024-06-01 09:10", int64(400)},
+				{"2024-06-03 18:00", int64(300)},
+			},
+			wantDays: 14,
+			wantCells: []cell{
+				{time.Saturday, 9, 200},
+				{time.Monday, 18, 150},
+				{time.Saturday, 10, 0},
+				{time.Sunday, 9, 0},
+			},
+			wantFirst: "13:35",
+			wantLast:  "13:35",
+		},
+		{
+			name:  "heatmap sums drinks in the same hour",
+			start: "2024-06-01",
+			end:   "2024-06-14",
+			drinks: [][2]interface{}{
+				{"2024-06-01 09:10", int64(400)},
+				{"2024-06-08 09:10", int64(100)},
+				{"2024-06-08 09:50", int64(50)},
+			},
+			wantDays:  14,
+			wantCells: []cell{{time.Saturday, 9, 275}},
+			wantGap:   int64Ptr(40),
+			wantFirst: "09:10",
+			wantLast:  "09:30",
+		},
+		{
+			name:  "heatmap rounds to one decimal place",
+			start: "2024-06-01",
+			end:   "2024-06-21",
+			drinks: [][2]interface{}{
+				{"2024-06-01 12:00", int64(100)},
+			},
+			wantDays:  21,
+			wantCells: []cell{{time.Saturday, 12, 33.3}},
+			wantFirst: "12:00",
+			wantLast:  "12:00",
+		},
+		{
+			// 1回しか飲んでいない日は間隔の計算から除くが、最初と最後の時刻には含める
+			name:  "odd number of gaps",
+			start: "2024-06-01",
+			end:   "2024-06-07",
+			drinks: [][2]interface{}{
+				{"2024-06-01 07:00", int64(200)},
+				{"2024-06-01 08:00", int64(200)},
+				{"2024-06-01 12:00", int64(200)},
+				{"2024-06-02 08:00", int64(200)},
+				{"2024-06-02 09:30", int64(200)},
+				{"2024-06-03 06:00", int64(200)},
+				{"2024-06-03 07:00", int64(200)},
+				{"2024-06-03 08:00", int64(200)},
+				{"2024-06-04 23:00", int64(200)},
+			},
+			wantDays:  7,
+			wantGap:   int64Ptr(90),
+			wantFirst: "07:30",
+			wantLast:  "10:45",
+		},
+		{
+			// 偶数個の場合は中央の2つの平均を切り捨てる
+			name:  "even number of gaps",
+			start: "2024-06-01",
+			end:   "2024-06-07",
+			drinks: [][2]interface{}{
+				{"2024-06-01 07:00", int64(200)},
+				{"2024-06-01 08:00", int64(200)},
+				{"2024-06-02 08:15", int64(200)},
+				{"2024-06-02 10:20", int64(200)},
+			},
+			wantDays:  7,
+			wantGap:   int64Ptr(92),
+			wantFirst: "07:37",
+			wantLast:  "09:10",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var drinks []*model.LocalDrink
+			for _, drink := range tt.drinks {
+				drinks = append(drinks, localDrink(t, drink[0].(string), drink[1].(int64)))
+			}
+
+			got := computePattern(drinks, date(tt.start), date(tt.end))
+			if got.Days != tt.wantDays {
+				t.Errorf("Days = %d, want %d", got.Days, tt.wantDays)
+			}
+			for _, c := range tt.wantCells {
+				if v := got.Heatmap[c.weekday][c.hour]; v != c.want {
+					t.Errorf("Heatmap[%s][%d] = %v, want %v", c.weekday, c.hour, v, c.want)
+				}
+			}
+			if len(tt.drinks) == 0 && got.Heatmap != [7][24]float64{} {
+				t.Errorf("Heatmap = %v, want all zero", got.Heatmap)
+			}
+
+			switch {
+			case tt.wantGap == nil && got.TypicalLongestGapMinutes != nil:
+				t.Errorf("TypicalLongestGapMinutes = %d, want nil", *got.TypicalLongestGapMinutes)
+			case tt.wantGap != nil && got.TypicalLongestGapMinutes == nil:
+				t.Errorf("TypicalLongestGapMinutes = nil, want %d", *tt.wantGap)
+			case tt.wantGap != nil && *got.TypicalLongestGapMinutes != *tt.wantGap:
+				t.Errorf("TypicalLongestGapMinutes = %d, want %d", *got.TypicalLongestGapMinutes, *tt.wantGap)
+			}
+
+			if tt.wantFirst == "" {
+				if got.MedianFirstDrink != nil || got.MedianLastDrink != nil {
+					t.Errorf("MedianFirstDrink, MedianLastDrink = %v, %v, want nil", got.MedianFirstDrink, got.MedianLastDrink)
+				}
+				return
+			}
+			if got.MedianFirstDrink == nil || *got.MedianFirstDrink != tt.wantFirst {
+				t.Errorf("MedianFirstDrink = %s, want %s", stringValue(got.MedianFirstDrink), tt.wantFirst)
+			}
+			if got.MedianLastDrink == nil || *got.MedianLastDrink != tt.wantLast {
+				t.Errorf("MedianLastDrink = %s, want %s", stringValue(got.MedianLastDrink), tt.wantLast)
+			}
+		})
+	}
+}
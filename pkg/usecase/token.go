@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+const (
+	// PersonalAccessTokenPrefix JWTと見分けるためにトークンの先頭に付ける
+	PersonalAccessTokenPrefix = "aqg_pat_"
+	// tokenDisplayLength 一覧に表示するトークンの先頭の文字数
+	tokenDisplayLength = len(PersonalAccessTokenPrefix) + 4
+	maxTokenNameLength = 100
+	maxTokenDays       = 365
+)
+
+type TokenUseCase interface {
+	// Create expiresInDaysが0の場合は無期限。作成したときだけトークンそのものを返す
+	Create(c context.Context, userId int64, name string, scopes []string, expiresInDays int64) (*model.PersonalAccessToken, error)
+	Search(c context.Context, userId int64) ([]*model.PersonalAccessToken, error)
+	Revoke(c context.Context, userId, id int64) error
+	// Authenticate トークンを検証して最終使用日時を記録する
+	Authenticate(c context.Context, token string) (*util.Principal, error)
+}
+
+type tokenUseCase struct {
+	repository repository.TokenRepository
+	timeout    time.Duration
+}
+
+func NewTokenUseCase(tokenRepo repository.TokenRepository) TokenUseCase {
+	return &tokenUseCase{
+		repository: tokenRepo,
+		timeout:    time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *tokenUseCase) Create(c context.Context, userId int64, name string, scopes []string, expiresInDays int64) (*model.PersonalAccessToken, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	name = strings.TrimSpace(name)
+	scopes, err := validateToken(name, scopes, expiresInDays)
+	if err != nil {
+		return nil, &util.BadRequestError{Err: err}
+	}
+
+	secret, _, err := util.GenerateOpaqueToken()
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+	plain := PersonalAccessTokenPrefix + secret
+
+	now := time.Now().UTC()
+	token := &model.PersonalAccessToken{
+		UserID:    userId,
+		Name:      name,
+		Prefix:    plain[:tokenDisplayLength],
+		TokenHash: util.HashToken(plain),
+		Scopes:    scopes,
+		CreatedAt: now.Format(drankAtLayout),
+	}
+	if expiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, int(expiresInDays)).Format(drankAtLayout)
+		token.ExpiresAt = &expiresAt
+	}
+
+	token, err = uc.repository.CreateToken(ctx, token)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	token.Token = plain
+	return token, nil
+}
+
+func (uc *tokenUseCase) Search(c context.Context, userId int64) ([]*model.PersonalAccessToken, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	tokens, err := uc.repository.GetTokens(ctx, userId)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return tokens, nil
+}
+
+func (uc *tokenUseCase) Revoke(c context.Context, userId, id int64) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	token, err := uc.repository.GetToken(ctx, id)
+	if errors.Is(err, repository.ErrTokenNotFound) || (err == nil && token.RevokedAt != nil) {
+		return &util.NotFoundError{Err: repository.ErrTokenNotFound}
+	}
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	if token.UserID != userId {
+		return &util.ForbiddenError{Err: errors.New("token belongs to another user")}
+	}
+
+	err = uc.repository.RevokeToken(ctx, id, time.Now())
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return nil
+}
+
+func (uc *tokenUseCase) Authenticate(c context.Context, plain string) (*util.Principal, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	token, err := uc.repository.GetTokenByHash(ctx, util.HashToken(plain))
+	if errors.Is(err, repository.ErrTokenNotFound) {
+		return nil, &util.UnauthorizedError{Err: errors.New("token is invalid")}
+	}
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil {
+		return nil, &util.UnauthorizedError{Err: errors.New("token is revoked")}
+	}
+	if token.ExpiresAt != nil && *token.ExpiresAt <= now.UTC().Format(drankAtLayout) {
+		return nil, &util.UnauthorizedError{Err: errors.New("token is expired")}
+	}
+
+	// 最終使用日時は表示にしか使わないため、更新に失敗しても認証は成功として扱う
+	if err := uc.repository.UpdateLastUsedAt(ctx, token.ID, now); err != nil {
+		log.Printf("failed to update last used at of token %d: %v", token.ID, err)
+	}
+
+	return &util.Principal{
+		UserID:  token.UserID,
+		TokenID: token.ID,
+		Scopes:  token.Scopes,
+	}, nil
+}
+
+// validateToken 重複を除いたスコープを返す
+func validateToken(name string, scopes []string, expiresInDays int64) ([]string, error) {
+	if name == "" || utf8.RuneCountInString(name) > maxTokenNameLength {
+		return nil, fmt.Errorf("name must be between 1 and %d characters", maxTokenNameLength)
+	}
+	if expiresInDays < 0 || expiresInDays > maxTokenDays {
+		return nil, fmt.Errorf("expires_in_days must be between 0 and %d", maxTokenDays)
+	}
+	if len(scopes) == 0 {
+		return nil, errors.New("scopes must not be empty")
+	}
+
+	seen := map[string]bool{}
+	var result []string
+	for _, scope := range scopes {
+		if !isKnownScope(scope) {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+	return result, nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, s := range model.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/mikaijun/aquagent/pkg/domain/model"
@@ -11,24 +12,44 @@ import (
 )
 
 type UserUseCase interface {
-	Signup(c context.Context, username, email, password string) (*model.User, error)
-	Login(c context.Context, email, password string) (string, *model.User, error)
+	// Signup 確認用のリンクをlocaleの言語でメールに送る
+	Signup(c context.Context, username, email, password, locale string) (*model.User, error)
+	// Login userAgentとipAddressはセッション一覧で端末を見分けるために保存する
+	Login(c context.Context, email, password, userAgent, ipAddress string) (*model.TokenPair, *model.User, error)
+	// Logout refreshTokenのセッションを失効させる
+	Logout(c context.Context, refreshToken string) error
 	Fetch(c context.Context, userId int64) (*model.User, error)
+	Update(c context.Context, user *model.User) (*model.User, error)
 }
 
 type userUseCase struct {
-	repository repository.UserRepository
-	timeout    time.Duration
+	repository          repository.UserRepository
+	sessionRepository   repository.SessionRepository
+	goalRepository      repository.GoalRepository
+	verificationUseCase VerificationUseCase
+	// requireEmailVerification trueの場合はメールアドレスを確認するまでログインできない
+	requireEmailVerification bool
+	timeout                  time.Duration
 }
 
-func NewUserUseCase(userRepo repository.UserRepository) UserUseCase {
+func NewUserUseCase(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	goalRepo repository.GoalRepository,
+	verificationUseCase VerificationUseCase,
+	requireEmailVerification bool,
+) UserUseCase {
 	return &userUseCase{
-		repository: userRepo,
-		timeout:    time.Duration(2) * time.Second,
+		repository:               userRepo,
+		sessionRepository:        sessionRepo,
+		goalRepository:           goalRepo,
+		verificationUseCase:      verificationUseCase,
+		requireEmailVerification: requireEmailVerification,
+		timeout:                  time.Duration(2) * time.Second,
 	}
 }
 
-func (uc *userUseCase) Signup(c context.Context, username, email, password string) (*model.User, error) {
+func (uc *userUseCase) Signup(c context.Context, username, email, password, locale string) (*model.User, error) {
 	ctx, cancel := context.WithTimeout(c, uc.timeout)
 	defer cancel()
 
@@ -58,32 +79,59 @@ func (uc *userUseCase) Signup(c context.Context, username, email, password strin
 		return nil, &util.InternalServerError{Err: err}
 	}
 
+	uc.saveRecommendedGoal(ctx, user)
+
+	// 登録は済んでいるため、メールを送れなくても失敗にしない。確認メールは再送できる
+	if err := uc.verificationUseCase.Send(ctx, user.ID, locale); err != nil {
+		log.Printf("failed to send verification email to user %d: %v", user.ID, err)
+	}
+
 	return user, nil
 }
 
-func (uc *userUseCase) Login(c context.Context, email, password string) (string, *model.User, error) {
+func (uc *userUseCase) Login(c context.Context, email, password, userAgent, ipAddress string) (*model.TokenPair, *model.User, error) {
 	ctx, cancel := context.WithTimeout(c, uc.timeout)
 	defer cancel()
 
 	user, err := uc.repository.GetUserByEmail(ctx, email)
 	if err != nil {
-		return "", nil, &util.InternalServerError{Err: err}
+		return nil, nil, &util.InternalServerError{Err: err}
 	}
 	if user.ID == 0 {
-		return "", nil, &util.BadRequestError{Err: errors.New("user is not exist")}
+		return nil, nil, &util.BadRequestError{Err: errors.New("user is not exist")}
 	}
 
 	err = util.CheckPassword(user.Password, password)
 	if err != nil {
-		return "", nil, &util.BadRequestError{Err: errors.New("password is incorrect")}
+		return nil, nil, &util.BadRequestError{Err: errors.New("password is incorrect")}
+	}
+
+	if uc.requireEmailVerification && user.EmailVerifiedAt == nil {
+		return nil, nil, &util.ForbiddenError{Err: errors.New("email is not verified")}
+	}
+
+	pair, err := startSession(ctx, uc.sessionRepository, user, userAgent, ipAddress, time.Now())
+	if err != nil {
+		return nil, nil, &util.InternalServerError{Err: err}
+	}
+
+	return pair, user, nil
+}
+
+func (uc *userUseCase) Logout(c context.Context, refreshToken string) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if refreshToken == "" {
+		return nil
 	}
 
-	signedString, err := util.GenerateSignedString(user.ID, user.Username)
+	err := revokeSession(ctx, uc.sessionRepository, refreshToken, time.Now())
 	if err != nil {
-		return "", nil, &util.InternalServerError{Err: err}
+		return &util.InternalServerError{Err: err}
 	}
 
-	return signedString, user, nil
+	return nil
 }
 
 func (uc *userUseCase) Fetch(c context.Context, userId int64) (*model.User, error) {
@@ -99,3 +147,82 @@ func (uc *userUseCase) Fetch(c context.Context, userId int64) (*model.User, erro
 	}
 	return user, nil
 }
+
+// Update ゼロ値でない項目だけを更新する
+func (uc *userUseCase) Update(c context.Context, user *model.User) (*model.User, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	current, err := uc.repository.GetUserById(ctx, user.ID)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+	if current.ID == 0 {
+		return nil, &util.BadRequestError{Err: errors.New("user is not exist")}
+	}
+
+	if user.Username != "" {
+		current.Username = user.Username
+	}
+	if user.Timezone != "" {
+		if _, err := time.LoadLocation(user.Timezone); err != nil {
+			return nil, &util.BadRequestError{Err: errors.New("timezone is invalid")}
+		}
+		current.Timezone = user.Timezone
+	}
+	if user.WeightKg != 0 {
+		if user.WeightKg < 0 || user.WeightKg > 500 {
+			return nil, &util.BadRequestError{Err: errors.New("weight_kg is invalid")}
+		}
+		current.WeightKg = user.WeightKg
+	}
+	if user.Age != 0 {
+		if user.Age < 0 || user.Age > 150 {
+			return nil, &util.BadRequestError{Err: errors.New("age is invalid")}
+		}
+		current.Age = user.Age
+	}
+	if user.Sex != "" {
+		switch user.Sex {
+		case model.SexMale, model.SexFemale, model.SexOther:
+		default:
+			return nil, &util.BadRequestError{Err: errors.New("sex is invalid")}
+		}
+		current.Sex = user.Sex
+	}
+	if user.ActivityLevel != "" {
+		switch user.ActivityLevel {
+		case model.ActivitySedentary, model.ActivityLight, model.ActivityModerate, model.ActivityActive, model.ActivityVeryActive:
+		default:
+			return nil, &util.BadRequestError{Err: errors.New("activity_level is invalid")}
+		}
+		current.ActivityLevel = user.ActivityLevel
+	}
+	if user.CaffeineLimitMg != 0 {
+		if user.CaffeineLimitMg < 0 || user.CaffeineLimitMg > 2000 {
+			return nil, &util.BadRequestError{Err: errors.New("caffeine_limit_mg is invalid")}
+		}
+		current.CaffeineLimitMg = user.CaffeineLimitMg
+	}
+
+	updated, err := uc.repository.UpdateUser(ctx, current)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	if user.WeightKg != 0 || user.Age != 0 || user.Sex != "" || user.ActivityLevel != "" {
+		uc.saveRecommendedGoal(ctx, updated)
+	}
+	return updated, nil
+}
+
+// saveRecommendedGoal ユーザーの登録やプロフィールの更新は済んでいるため、保存に失敗しても成功として扱う
+func (uc *userUseCase) saveRecommendedGoal(ctx context.Context, user *model.User) {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err == nil {
+		err = saveRecommendedGoal(ctx, uc.goalRepository, user, loc)
+	}
+	if err != nil {
+		log.Printf("failed to save recommended goal of user %d: %v", user.ID, err)
+	}
+}
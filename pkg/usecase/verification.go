@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+	"github.com/mikaijun/aquagent/pkg/util/mailtemplate"
+)
+
+const (
+	// EmailVerificationTTL 確認用のリンクの有効期間
+	EmailVerificationTTL = 24 * time.Hour
+	// verificationResendInterval 確認メールを続けて送れない間隔
+	verificationResendInterval = time.Minute
+	// maxVerificationsPerHour 1時間に送れる確認メールの最大件数
+	maxVerificationsPerHour = 5
+)
+
+var errInvalidEmailVerification = errors.New("verification token is invalid or expired")
+
+type VerificationUseCase interface {
+	// Send 確認用のリンクをメールで送る。送りすぎないように回数を制限する
+	Send(c context.Context, userId int64, locale string) error
+	// Resend 登録されていないメールアドレスや確認済み、送りすぎで送らなかった場合も成功として扱い、登録の有無を明かさない
+	Resend(c context.Context, email, locale string) error
+	Verify(c context.Context, token string) error
+}
+
+type verificationUseCase struct {
+	repository     repository.EmailVerificationRepository
+	userRepository repository.UserRepository
+	mailUseCase    MailUseCase
+	// verifyURL トークンをクエリに付けてメールに載せる、確認用のURL
+	verifyURL string
+	timeout   time.Duration
+}
+
+func NewVerificationUseCase(
+	verificationRepo repository.EmailVerificationRepository,
+	userRepo repository.UserRepository,
+	mailUseCase MailUseCase,
+	verifyURL string,
+) VerificationUseCase {
+	return &verificationUseCase{
+		repository:     verificationRepo,
+		userRepository: userRepo,
+		mailUseCase:    mailUseCase,
+		verifyURL:      verifyURL,
+		timeout:        time.Duration(2) * time.Second,
+	}
+}
+
+func (uc *verificationUseCase) Send(c context.Context, userId int64, locale string) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	user, err := uc.userRepository.GetUserById(ctx, userId)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+	if user.ID == 0 {
+		return &util.BadRequestError{Err: errors.New("user is not exist")}
+	}
+	if user.EmailVerifiedAt != nil {
+		return &util.BadRequestError{Err: errors.New("email is already verified")}
+	}
+
+	now := time.Now()
+	limited, err := uc.rateLimited(ctx, user.ID, now)
+	if err != nil {
+		return err
+	}
+	if limited {
+		return &util.TooManyRequestsError{Err: errors.New("verification email was sent recently")}
+	}
+
+	return uc.send(ctx, user, locale, now)
+}
+
+func (uc *verificationUseCase) Resend(c context.Context, email, locale string) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	user, err := uc.userRepository.GetUserByEmail(ctx, email)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+	if user.ID == 0 || user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	limited, err := uc.rateLimited(ctx, user.ID, now)
+	if err != nil {
+		return err
+	}
+	if limited {
+		return nil
+	}
+
+	return uc.send(ctx, user, locale, now)
+}
+
+// rateLimited 同じユーザーに確認メールを送りすぎないようにする
+func (uc *verificationUseCase) rateLimited(ctx context.Context, userId int64, now time.Time) (bool, error) {
+	recent, err := uc.repository.CountEmailVerificationsSince(ctx, userId, now.Add(-verificationResendInterval))
+	if err != nil {
+		return false, &util.InternalServerError{Err: err}
+	}
+	hourly, err := uc.repository.CountEmailVerificationsSince(ctx, userId, now.Add(-time.Hour))
+	if err != nil {
+		return false, &util.InternalServerError{Err: err}
+	}
+	return recent > 0 || hourly >= maxVerificationsPerHour, nil
+}
+
+func (uc *verificationUseCase) send(ctx context.Context, user *model.User, locale string, now time.Time) error {
+	token, hash, err := util.GenerateOpaqueToken()
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	_, err = uc.repository.CreateEmailVerification(ctx, &model.EmailVerification{
+		UserID:    user.ID,
+		TokenHash: hash,
+		CreatedAt: now.UTC().Format(drankAtLayout),
+		ExpiresAt: now.UTC().Add(EmailVerificationTTL).Format(drankAtLayout),
+	})
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	return uc.mailUseCase.Enqueue(ctx, user.Email, mailtemplate.EmailVerification, locale, &mailtemplate.EmailVerificationData{
+		Username:       user.Username,
+		URL:            uc.verifyURL + "?token=" + url.QueryEscape(token),
+		ExpiresInHours: int64(EmailVerificationTTL.Hours()),
+	})
+}
+
+func (uc *verificationUseCase) Verify(c context.Context, token string) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	verification, err := uc.repository.GetEmailVerificationByTokenHash(ctx, util.HashToken(token))
+	if errors.Is(err, repository.ErrEmailVerificationNotFound) {
+		return &util.BadRequestError{Err: errInvalidEmailVerification}
+	}
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	now := time.Now()
+	if verification.UsedAt != nil || verification.ExpiresAt <= now.UTC().Format(drankAtLayout) {
+		return &util.BadRequestError{Err: errInvalidEmailVerification}
+	}
+
+	used, err := uc.repository.VerifyEmail(ctx, verification, now)
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+	if !used {
+		return &util.BadRequestError{Err: errInvalidEmailVerification}
+	}
+
+	return nil
+}
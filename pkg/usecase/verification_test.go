@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+func newTestVerificationUseCase(users ...*model.User) (VerificationUseCase, *fakeMailUseCase) {
+	mails := &fakeMailUseCase{}
+	uc := NewVerificationUseCase(&fakeEmailVerificationRepository{}, newFakeUserRepository(users...), mails, "https://app.example.com/verify")
+	return uc, mails
+}
+
+// addTestVerification tokenの確認用のトークンをexpiresAtまで有効なものとして登録する
+func addTestVerification(repo *fakeEmailVerificationRepository, token string, expiresAt time.Time) *model.EmailVerification {
+	verification := &model.EmailVerification{
+		ID:        int64(len(repo.verifications) + 1),
+		UserID:    1,
+		TokenHash: util.HashToken(token),
+		ExpiresAt: expiresAt.UTC().Format(drankAtLayout),
+	}
+	repo.verifications = append(repo.verifications, verification)
+	return verification
+}
+
+func TestVerificationUseCaseSendCooldown(t *testing.T) {
+	uc, mails := newTestVerificationUseCase(&model.User{ID: 1, Username: "mikai", Email: "mikai@example.com"})
+	ctx := context.Background()
+
+	if err := uc.Send(ctx, 1, "ja"); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	// ログインしているユーザーには送れない理由を返す
+	var tooMany *util.TooManyRequestsError
+	if err := uc.Send(ctx, 1, "ja"); !errors.As(err, &tooMany) {
+		t.Errorf("Send() within the interval error = %v, want TooManyRequestsError", err)
+	}
+	if len(mails.enqueued) != 1 {
+		t.Errorf("sent %d mails, want 1", len(mails.enqueued))
+	}
+}
+
+func TestVerificationUseCaseResendDoesNotRevealAccounts(t *testing.T) {
+	verifiedAt := "2024-06-01 00:00:00"
+	uc, mails := newTestVerificationUseCase(
+		&model.User{ID: 1, Username: "mikai", Email: "mikai@example.com"},
+		&model.User{ID: 2, Username: "verified", Email: "verified@example.com", EmailVerifiedAt: &verifiedAt},
+	)
+	ctx := context.Background()
+
+	// 未登録、確認済み、送りすぎのいずれも同じ結果にする
+	for _, email := range []string{"unknown@example.com", "verified@example.com", "mikai@example.com", "mikai@example.com"} {
+		if err := uc.Resend(ctx, email, "ja"); err != nil {
+			t.Errorf("Resend(%q) error = %v, want nil", email, err)
+		}
+	}
+	if len(mails.enqueued) != 1 || mails.enqueued[0].to != "mikai@example.com" {
+		t.Errorf("sent %v, want one mail to mikai@example.com", mails.enqueued)
+	}
+}
+
+func TestVerificationUseCaseVerify(t *testing.T) {
+	repo := &fakeEmailVerificationRepository{}
+	uc := NewVerificationUseCase(repo, newFakeUserRepository(&model.User{ID: 1}), &fakeMailUseCase{}, "https://app.example.com/verify")
+	now := time.Now()
+	addTestVerification(repo, "valid", now.Add(time.Hour))
+	addTestVerification(repo, "expired", now.Add(-time.Minute))
+
+	if err := uc.Verify(context.Background(), "valid"); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(repo.verified) != 1 || repo.verified[0] != 1 {
+		t.Errorf("verified = %v, want user 1", repo.verified)
+	}
+
+	for _, token := range []string{"valid", "expired", "unknown"} {
+		var badRequest *util.BadRequestError
+		if err := uc.Verify(context.Background(), token); !errors.As(err, &badRequest) {
+			t.Errorf("Verify(%q) error = %v, want BadRequestError", token, err)
+		}
+	}
+	if len(repo.verified) != 1 {
+		t.Errorf("verified = %v, want only the first verification", repo.verified)
+	}
+}
+
+func TestVerificationUseCaseVerifyFailure(t *testing.T) {
+	repo := &fakeEmailVerificationRepository{err: errors.New("connection reset")}
+	uc := NewVerificationUseCase(repo, newFakeUserRepository(&model.User{ID: 1}), &fakeMailUseCase{}, "https://app.example.com/verify")
+	verification := addTestVerification(repo, "valid", time.Now().Add(time.Hour))
+
+	var internal *util.InternalServerError
+	if err := uc.Verify(context.Background(), "valid"); !errors.As(err, &internal) {
+		t.Fatalf("Verify() error = %v, want InternalServerError", err)
+	}
+	// 失敗した場合はトークンを使用済みにしないため、もう一度確認できる
+	if verification.UsedAt != nil {
+		t.Errorf("UsedAt = %v, want unused", *verification.UsedAt)
+	}
+	repo.err = nil
+	if err := uc.Verify(context.Background(), "valid"); err != nil {
+		t.Errorf("Verify() retry error = %v", err)
+	}
+}
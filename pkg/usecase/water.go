@@ -2,28 +2,72 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/mikaijun/aquagent/pkg/domain/model"
 	"github.com/mikaijun/aquagent/pkg/domain/repository"
+	"github.com/mikaijun/aquagent/pkg/util"
+	"github.com/mikaijun/aquagent/pkg/util/drinkparser"
+)
+
+const (
+	// maxWaterVolume 1回の記録で受け付ける最大量(ml)
+	maxWaterVolume = 5000
+	// maxCaffeineMg, maxAlcoholG 1回の記録で受け付ける最大の摂取量。DBの列(numeric(6,1), numeric(5,1))に収まる範囲にする
+	maxCaffeineMg = 10000
+	maxAlcoholG   = 2000
+	drankAtLayout = "2006-01-02 15:04:05"
 )
 
 type WaterUseCase interface {
 	Search(c context.Context, userId int64, filter map[string]interface{}) ([]*model.Water, error)
 	Create(c context.Context, water *model.Water) (*model.Water, error)
 	CreateRandomWaters(c context.Context) ([]*model.Water, error)
-	Delete(c context.Context, id int64) error
+	Update(c context.Context, water *model.Water) (*model.Water, error)
+	Delete(c context.Context, userId, id int64) error
+	// Parse 自由な文から記録を取り出す。createの場合はそのまま記録する
+	Parse(c context.Context, userId int64, text string, create bool) ([]*model.Water, error)
+	// DailySummary start, endはユーザーのタイムゾーンにおける日付。空の場合は今日になる
+	DailySummary(c context.Context, userId int64, start, end string) ([]*model.DailySummary, error)
+	// WeeklyChart startから7日分を集計する。空の場合は今日を含む直近7日になる
+	WeeklyChart(c context.Context, userId int64, start string) (*model.WeeklyChart, error)
+	MonthlyCalendar(c context.Context, userId int64, year, month int) (*model.MonthlyCalendar, error)
 }
 
 type waterUseCase struct {
-	repository repository.WaterRepository
-	timeout    time.Duration
+	repository          repository.WaterRepository
+	userRepository      repository.UserRepository
+	goalRepository      repository.GoalRepository
+	beverageRepository  repository.BeverageRepository
+	containerRepository repository.ContainerRepository
+	achievementUseCase  AchievementUseCase
+	// now 文から記録するときの現在時刻。テストでは固定する
+	now     func() time.Time
+	timeout time.Duration
 }
 
-func NewWaterUseCase(waterRepo repository.WaterRepository) WaterUseCase {
+func NewWaterUseCase(
+	waterRepo repository.WaterRepository,
+	userRepo repository.UserRepository,
+	goalRepo repository.GoalRepository,
+	beverageRepo repository.BeverageRepository,
+	containerRepo repository.ContainerRepository,
+	achievementUseCase AchievementUseCase,
+) WaterUseCase {
 	return &waterUseCase{
-		repository: waterRepo,
-		timeout:    time.Duration(2) * time.Second,
+		repository:          waterRepo,
+		userRepository:      userRepo,
+		goalRepository:      goalRepo,
+		beverageRepository:  beverageRepo,
+		containerRepository: containerRepo,
+		achievementUseCase:  achievementUseCase,
+		now:                 time.Now,
+		timeout:             time.Duration(2) * time.Second,
 	}
 }
 
@@ -43,14 +87,57 @@ func (uc *waterUseCase) Create(c context.Context, water *model.Water) (*model.Wa
 	ctx, cancel := context.WithTimeout(c, uc.timeout)
 	defer cancel()
 
+	if err := uc.prepare(ctx, water); err != nil {
+		return nil, err
+	}
+
 	water, err := uc.repository.CreateWater(ctx, water)
 	if err != nil {
-		return nil, err
+		return nil, &util.InternalServerError{Err: err}
 	}
 
+	if water.ContainerID != 0 {
+		// 使用回数は並び順にしか使わないため、更新に失敗しても記録は成功として扱う
+		if err := uc.containerRepository.IncrementUsage(ctx, water.ContainerID); err != nil {
+			log.Printf("failed to increment container usage: %v", err)
+		}
+	}
+
+	uc.evaluateAchievements(ctx, water.UserID)
+
 	return water, nil
 }
 
+// prepare 記録する前に容器のプリセットを反映し、内容を検証する
+func (uc *waterUseCase) prepare(ctx context.Context, water *model.Water) error {
+	// 容器が指定された場合、量と飲み物が未指定なら容器のプリセットを使う
+	if water.ContainerID != 0 {
+		container, err := findOwnContainer(ctx, uc.containerRepository, water.UserID, water.ContainerID)
+		if err != nil {
+			return err
+		}
+		if water.Volume == 0 {
+			water.Volume = container.Volume
+		}
+		if water.BeverageID == 0 {
+			water.BeverageID = container.BeverageID
+		}
+	}
+
+	if water.Volume == 0 {
+		return &util.BadRequestError{Err: errors.New("volume or container_id is required")}
+	}
+
+	if err := checkBeverage(ctx, uc.beverageRepository, water.UserID, water.BeverageID); err != nil {
+		return err
+	}
+
+	if err := validateWater(water); err != nil {
+		return &util.BadRequestError{Err: err}
+	}
+	return nil
+}
+
 func (uc *waterUseCase) CreateRandomWaters(c context.Context) ([]*model.Water, error) {
 	ctx, cancel := context.WithTimeout(c, uc.timeout)
 	defer cancel()
@@ -63,19 +150,369 @@ func (uc *waterUseCase) CreateRandomWaters(c context.Context) ([]*model.Water, e
 	return waters, nil
 }
 
-func (uc *waterUseCase) Delete(c context.Context, id int64) error {
+// Update ゼロ値(CaffeineMgとAlcoholGはnil)でない項目だけを更新する
+func (uc *waterUseCase) Update(c context.Context, water *model.Water) (*model.Water, error) {
 	ctx, cancel := context.WithTimeout(c, uc.timeout)
 	defer cancel()
 
-	_, err := uc.repository.GetWater(ctx, id)
+	current, err := uc.findOwnWater(ctx, water.UserID, water.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = uc.repository.DeleteWater(ctx, id)
+	if water.Volume != 0 {
+		current.Volume = water.Volume
+	}
+	if water.BeverageID != 0 {
+		if err := checkBeverage(ctx, uc.beverageRepository, water.UserID, water.BeverageID); err != nil {
+			return nil, err
+		}
+		current.BeverageID = water.BeverageID
+	}
+	if water.CaffeineMg != nil {
+		current.CaffeineMg = water.CaffeineMg
+	}
+	if water.AlcoholG != nil {
+		current.AlcoholG = water.AlcoholG
+	}
+	if water.DrankAt != "" {
+		current.DrankAt = water.DrankAt
+	}
+
+	if err := validateWater(current); err != nil {
+		return nil, &util.BadRequestError{Err: err}
+	}
+
+	updated, err := uc.repository.UpdateWater(ctx, current)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	return updated, nil
+}
+
+func (uc *waterUseCase) Delete(c context.Context, userId, id int64) error {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	_, err := uc.findOwnWater(ctx, userId, id)
 	if err != nil {
 		return err
 	}
 
+	err = uc.repository.DeleteWater(ctx, userId, id)
+	if errors.Is(err, repository.ErrWaterNotFound) {
+		return &util.NotFoundError{Err: err}
+	}
+	if err != nil {
+		return &util.InternalServerError{Err: err}
+	}
+
+	uc.evaluateAchievements(ctx, userId)
+
 	return nil
 }
+
+func (uc *waterUseCase) Parse(c context.Context, userId int64, text string, create bool) ([]*model.Water, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	_, loc, err := userLocation(ctx, uc.userRepository, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	beverages, err := uc.beverageRepository.GetBeverages(ctx, userId)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	// ユーザーが登録した飲み物も名前で指定できるようにする。名前が重複する場合は後に並ぶユーザーのものを優先する
+	// ユーザーの飲み物にはコードがないため、IDから解析用のコードを作る
+	words := map[string]string{}
+	byCode := map[string]*model.Beverage{}
+	for _, beverage := range beverages {
+		code := beverage.Code
+		if code == "" {
+			code = "user:" + strconv.FormatInt(beverage.ID, 10)
+		} else {
+			words[beverage.Code] = code
+		}
+		words[beverage.Name] = code
+		byCode[code] = beverage
+	}
+
+	drinks, err := drinkparser.NewParser().WithBeverages(words).Parse(text, uc.now().In(loc))
+	if errors.Is(err, drinkparser.ErrNoDrink) {
+		return nil, &util.BadRequestError{Err: err}
+	}
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	// 一部だけ記録されることがないように、すべて検証してから記録する
+	var waters []*model.Water = []*model.Water{}
+	for _, drink := range drinks {
+		water := &model.Water{
+			UserID:  userId,
+			Volume:  drink.Volume,
+			DrankAt: drink.DrankAt.In(loc).Format(drankAtLayout),
+		}
+		if beverage, ok := byCode[drink.Beverage]; ok {
+			water.BeverageID = beverage.ID
+		}
+		if err := uc.prepare(ctx, water); err != nil {
+			return nil, err
+		}
+		waters = append(waters, water)
+	}
+
+	if !create {
+		return waters, nil
+	}
+
+	waters, err = uc.repository.CreateWaters(ctx, waters)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	uc.evaluateAchievements(ctx, userId)
+
+	return waters, nil
+}
+
+// evaluateAchievements バッジは後でまとめて判定するため、記録の操作は判定の結果を待たない
+// 判定待ちにできなくても記録は保存済みのため、ログに残して続ける
+func (uc *waterUseCase) evaluateAchievements(ctx context.Context, userId int64) {
+	if err := uc.achievementUseCase.Schedule(ctx, userId); err != nil {
+		log.Printf("failed to schedule achievement evaluation for user %d: %v", userId, err)
+	}
+}
+
+func (uc *waterUseCase) DailySummary(c context.Context, userId int64, start, end string) ([]*model.DailySummary, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	user, loc, err := userLocation(ctx, uc.userRepository, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate, endDate := today(loc), today(loc)
+	if start != "" {
+		if startDate, err = parseDate(start); err != nil {
+			return nil, err
+		}
+	}
+	if end != "" {
+		if endDate, err = parseDate(end); err != nil {
+			return nil, err
+		}
+	}
+
+	dates, err := dateRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	totalByDate, err := uc.dailyTotals(ctx, userId, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	goals, err := uc.goalRepository.GetGoals(ctx, userId)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	// 記録のない日も0として返す
+	summaries := make([]*model.DailySummary, 0, len(dates))
+	for _, date := range dates {
+		summary := &model.DailySummary{Date: date.Format(dateLayout)}
+		summary.Goal, _ = goalOn(goals, summary.Date)
+		if total, ok := totalByDate[summary.Date]; ok {
+			summary.TotalVolume = total.TotalVolume
+			summary.EffectiveVolume = total.EffectiveVolume
+			summary.Count = total.Count
+			summary.FirstDrankAt = &total.FirstDrankAt
+			summary.LastDrankAt = &total.LastDrankAt
+			summary.CaffeineMg = math.Round(total.CaffeineMg*10) / 10
+			summary.AlcoholG = math.Round(total.AlcoholG*10) / 10
+		}
+		summary.Progress = progress(summary.EffectiveVolume, summary.Goal)
+		summary.CaffeineLimitMg = user.CaffeineLimitMg
+		summary.CaffeineWarning = summary.CaffeineMg > float64(user.CaffeineLimitMg)
+		summary.AlcoholWarning = summary.AlcoholG > 0
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+func (uc *waterUseCase) WeeklyChart(c context.Context, userId int64, start string) (*model.WeeklyChart, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	_, loc, err := userLocation(ctx, uc.userRepository, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	startDate := today(loc).AddDate(0, 0, -6)
+	if start != "" {
+		if startDate, err = parseDate(start); err != nil {
+			return nil, err
+		}
+	}
+	endDate := startDate.AddDate(0, 0, 6)
+
+	dates, err := dateRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	totalByDate, err := uc.dailyTotals(ctx, userId, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	chart := &model.WeeklyChart{
+		Start: startDate.Format(dateLayout),
+		End:   endDate.Format(dateLayout),
+		Days:  make([]*model.ChartDay, 0, len(dates)),
+	}
+	for _, date := range dates {
+		day := &model.ChartDay{Date: date.Format(dateLayout)}
+		if total, ok := totalByDate[day.Date]; ok {
+			day.TotalVolume = total.TotalVolume
+			day.EffectiveVolume = total.EffectiveVolume
+		}
+
+		chart.Total += day.TotalVolume
+		chart.EffectiveTotal += day.EffectiveVolume
+		// 同量の場合は古い日を優先する
+		if chart.BestDay == nil || day.EffectiveVolume > chart.BestDay.EffectiveVolume {
+			chart.BestDay = day
+		}
+		if chart.WorstDay == nil || day.EffectiveVolume < chart.WorstDay.EffectiveVolume {
+			chart.WorstDay = day
+		}
+		chart.Days = append(chart.Days, day)
+	}
+	chart.Average = math.Round(float64(chart.Total)/float64(len(chart.Days))*10) / 10
+	chart.EffectiveAverage = math.Round(float64(chart.EffectiveTotal)/float64(len(chart.Days))*10) / 10
+
+	return chart, nil
+}
+
+func (uc *waterUseCase) MonthlyCalendar(c context.Context, userId int64, year, month int) (*model.MonthlyCalendar, error) {
+	ctx, cancel := context.WithTimeout(c, uc.timeout)
+	defer cancel()
+
+	if year < 1 || month < 1 || month > 12 {
+		return nil, &util.BadRequestError{Err: errors.New("year or month is invalid")}
+	}
+
+	// 存在しないユーザーの場合はエラーにする
+	if _, _, err := userLocation(ctx, uc.userRepository, userId); err != nil {
+		return nil, err
+	}
+
+	startDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 1, -1)
+
+	dates, err := dateRange(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	totalByDate, err := uc.dailyTotals(ctx, userId, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	goals, err := uc.goalRepository.GetGoals(ctx, userId)
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	calendar := &model.MonthlyCalendar{
+		Year:  year,
+		Month: month,
+		Days:  make([]*model.CalendarDay, 0, len(dates)),
+	}
+	for _, date := range dates {
+		day := &model.CalendarDay{Date: date.Format(dateLayout)}
+		day.Goal, _ = goalOn(goals, day.Date)
+		if total, ok := totalByDate[day.Date]; ok {
+			day.TotalVolume = total.TotalVolume
+			day.EffectiveVolume = total.EffectiveVolume
+			day.Count = total.Count
+		}
+		day.GoalMet = day.EffectiveVolume >= day.Goal
+		calendar.Days = append(calendar.Days, day)
+	}
+
+	return calendar, nil
+}
+
+// dailyTotals 日付(YYYY-MM-DD)をキーにした日ごとの集計結果を返す
+func (uc *waterUseCase) dailyTotals(ctx context.Context, userId int64, start, end time.Time) (map[string]*model.DailyTotal, error) {
+	totals, err := uc.repository.GetDailyTotals(ctx, userId, start.Format(dateLayout), end.Format(dateLayout))
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	totalByDate := make(map[string]*model.DailyTotal, len(totals))
+	for _, total := range totals {
+		totalByDate[total.Date] = total
+	}
+	return totalByDate, nil
+}
+
+// findOwnWater 他のユーザーの記録は操作させない
+func (uc *waterUseCase) findOwnWater(ctx context.Context, userId, id int64) (*model.Water, error) {
+	water, err := uc.repository.GetWater(ctx, id)
+	if errors.Is(err, repository.ErrWaterNotFound) {
+		return nil, &util.NotFoundError{Err: err}
+	}
+	if err != nil {
+		return nil, &util.InternalServerError{Err: err}
+	}
+
+	if water.UserID != userId {
+		return nil, &util.ForbiddenError{Err: errors.New("water belongs to another user")}
+	}
+
+	return water, nil
+}
+
+func validateWater(water *model.Water) error {
+	if water.Volume <= 0 || water.Volume > maxWaterVolume {
+		return fmt.Errorf("volume must be between 1 and %d", maxWaterVolume)
+	}
+
+	if _, err := time.Parse(drankAtLayout, water.DrankAt); err != nil {
+		return fmt.Errorf("drank_at must be formatted as %q", drankAtLayout)
+	}
+
+	return validateIntake(water)
+}
+
+func validateIntake(water *model.Water) error {
+	if water.CaffeineMg != nil && (*water.CaffeineMg < 0 || *water.CaffeineMg > maxCaffeineMg) {
+		return fmt.Errorf("caffeine_mg must be between 0 and %d", maxCaffeineMg)
+	}
+	if water.AlcoholG != nil && (*water.AlcoholG < 0 || *water.AlcoholG > maxAlcoholG) {
+		return fmt.Errorf("alcohol_g must be between 0 and %d", maxAlcoholG)
+	}
+	return nil
+}
+
+// progress 目標に対する達成率。小数第2位で丸める
+func progress(volume, goal int64) float64 {
+	if goal <= 0 {
+		return 0
+	}
+	return math.Round(float64(volume)/float64(goal)*100) / 100
+}
@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+	"github.com/mikaijun/aquagent/pkg/util"
+)
+
+func newTestWaterUseCase(waterRepo *fakeWaterRepository, users ...*model.User) *waterUseCase {
+	return NewWaterUseCase(
+		waterRepo,
+		newFakeUserRepository(users...),
+		&fakeGoalRepository{},
+		&fakeBeverageRepository{},
+		&fakeContainerRepository{},
+		&fakeAchievementUseCase{},
+	).(*waterUseCase)
+}
+
+func float64Ptr(v float64) *float64 {
+	return &v
+}
+
+func TestWaterUseCaseCreateValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		water   *model.Water
+		wantErr bool
+	}{
+		{name: "valid", water: &model.Water{Volume: 200, DrankAt: "2024-06-01 08:00:00"}},
+		{name: "max volume", water: &model.Water{Volume: maxWaterVolume, DrankAt: "2024-06-01 08:00:00"}},
+		{name: "negative volume", water: &model.Water{Volume: -100, DrankAt: "2024-06-01 08:00:00"}, wantErr: true},
+		{name: "too large volume", water: &model.Water{Volume: maxWaterVolume + 1, DrankAt: "2024-06-01 08:00:00"}, wantErr: true},
+		{name: "unparsable drank_at", water: &model.Water{Volume: 200, DrankAt: "yesterday"}, wantErr: true},
+		{name: "empty drank_at", water: &model.Water{Volume: 200}, wantErr: true},
+		{name: "negative caffeine", water: &model.Water{Volume: 200, DrankAt: "2024-06-01 08:00:00", CaffeineMg: float64Ptr(-1)}, wantErr: true},
+		{name: "max caffeine", water: &model.Water{Volume: 200, DrankAt: "2024-06-01 08:00:00", CaffeineMg: float64Ptr(maxCaffeineMg)}},
+		{name: "too large caffeine", water: &model.Water{Volume: 200, DrankAt: "2024-06-01 08:00:00", CaffeineMg: float64Ptr(100000)}, wantErr: true},
+		{name: "max alcohol", water: &model.Water{Volume: 200, DrankAt: "2024-06-01 08:00:00", AlcoholG: float64Ptr(maxAlcoholG)}},
+		{name: "too large alcohol", water: &model.Water{Volume: 200, DrankAt: "2024-06-01 08:00:00", AlcoholG: float64Ptr(maxAlcoholG + 0.1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeWaterRepository()
+			uc := newTestWaterUseCase(repo)
+			tt.water.UserID = 1
+
+			_, err := uc.Create(context.Background(), tt.water)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+				if len(repo.waters) != 1 {
+					t.Fatalf("saved %d waters, want 1", len(repo.waters))
+				}
+				return
+			}
+
+			var badRequest *util.BadRequestError
+			if !errors.As(err, &badRequest) {
+				t.Fatalf("Create() error = %v, want BadRequestError", err)
+			}
+			if len(repo.waters) != 0 {
+				t.Fatalf("saved %d waters, want 0", len(repo.waters))
+			}
+		})
+	}
+}
+
+func TestWaterUseCaseUpdateValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		update  *model.Water
+		wantErr bool
+	}{
+		{name: "volume", update: &model.Water{Volume: 300}},
+		{name: "drank_at", update: &model.Water{DrankAt: "2024-06-01 09:30:00"}},
+		{name: "negative volume", update: &model.Water{Volume: -100}, wantErr: true},
+		{name: "too large volume", update: &model.Water{Volume: maxWaterVolume + 1}, wantErr: true},
+		{name: "unparsable drank_at", update: &model.Water{DrankAt: "2024/06/01"}, wantErr: true},
+		{name: "too large caffeine", update: &model.Water{CaffeineMg: float64Ptr(maxCaffeineMg + 1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeWaterRepository(&model.Water{UserID: 1, Volume: 200, DrankAt: "2024-06-01 08:00:00"})
+			uc := newTestWaterUseCase(repo)
+			tt.update.ID = 1
+			tt.update.UserID = 1
+
+			_, err := uc.Update(context.Background(), tt.update)
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Update() error = %v", err)
+				}
+				return
+			}
+
+			var badRequest *util.BadRequestError
+			if !errors.As(err, &badRequest) {
+				t.Fatalf("Update() error = %v, want BadRequestError", err)
+			}
+			if repo.waters[1].Volume != 200 || repo.waters[1].DrankAt != "2024-06-01 08:00:00" {
+				t.Fatalf("water was updated: %+v", repo.waters[1])
+			}
+		})
+	}
+}
+
+func TestWaterUseCaseOwnership(t *testing.T) {
+	repo := newFakeWaterRepository(&model.Water{UserID: 1, Volume: 200, DrankAt: "2024-06-01 08:00:00"})
+	uc := newTestWaterUseCase(repo)
+
+	var forbidden *util.ForbiddenError
+	_, err := uc.Update(context.Background(), &model.Water{ID: 1, UserID: 2, Volume: 300})
+	if !errors.As(err, &forbidden) {
+		t.Fatalf("Update() error = %v, want ForbiddenError", err)
+	}
+	if err := uc.Delete(context.Background(), 2, 1); !errors.As(err, &forbidden) {
+		t.Fatalf("Delete() error = %v, want ForbiddenError", err)
+	}
+
+	var notFound *util.NotFoundError
+	if err := uc.Delete(context.Background(), 1, 99); !errors.As(err, &notFound) {
+		t.Fatalf("Delete() error = %v, want NotFoundError", err)
+	}
+	if _, ok := repo.waters[1]; !ok {
+		t.Fatal("water was deleted by another user")
+	}
+
+	if err := uc.Delete(context.Background(), 1, 1); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := repo.waters[1]; ok {
+		t.Fatal("water was not deleted")
+	}
+}
+
+// 日本時間の朝8時の記録は、UTCでは前日の23時だがその日の記録として集計する
+func TestWaterUseCaseLocalDayBoundary(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{ID: 1, Timezone: "Asia/Tokyo"}
+
+	tests := []struct {
+		name   string
+		record func(uc *waterUseCase) error
+	}{
+		{
+			name: "create",
+			record: func(uc *waterUseCase) error {
+				_, err := uc.Create(context.Background(), &model.Water{UserID: 1, Volume: 200, DrankAt: "2024-06-01 08:00:00"})
+				return err
+			},
+		},
+		{
+			name: "parse",
+			record: func(uc *waterUseCase) error {
+				uc.now = fixedNow("2024-06-01 08:30:00", tokyo)
+				_, err := uc.Parse(context.Background(), 1, "200ml 8時に", true)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeWaterRepository()
+			uc := newTestWaterUseCase(repo, user)
+			if err := tt.record(uc); err != nil {
+				t.Fatalf("record error = %v", err)
+			}
+
+			if got := repo.waters[1].DrankAt; got != "2024-06-01 08:00:00" {
+				t.Fatalf("saved drank_at = %q, want %q", got, "2024-06-01 08:00:00")
+			}
+
+			summaries, err := uc.DailySummary(context.Background(), 1, "2024-05-31", "2024-06-01")
+			if err != nil {
+				t.Fatalf("DailySummary() error = %v", err)
+			}
+			if summaries[0].Count != 0 {
+				t.Errorf("2024-05-31 count = %d, want 0", summaries[0].Count)
+			}
+			if summaries[1].Count != 1 || summaries[1].TotalVolume != 200 {
+				t.Errorf("2024-06-01 = %+v, want 1 record of 200ml", summaries[1])
+			}
+			if summaries[1].FirstDrankAt == nil || *summaries[1].FirstDrankAt != "2024-06-01 08:00:00" {
+				t.Errorf("2024-06-01 first_drank_at = %v", summaries[1].FirstDrankAt)
+			}
+		})
+	}
+}
+
+func TestWaterUseCaseParseCreatesAllOrNothing(t *testing.T) {
+	repo := newFakeWaterRepository()
+	uc := newTestWaterUseCase(repo, &model.User{ID: 1, Timezone: "Asia/Tokyo"})
+
+	// 2件目が上限を超えているため、1件目も記録しない
+	_, err := uc.Parse(context.Background(), 1, "200ml と 6000ml", true)
+	var badRequest *util.BadRequestError
+	if !errors.As(err, &badRequest) {
+		t.Fatalf("Parse() error = %v, want BadRequestError", err)
+	}
+	if len(repo.waters) != 0 {
+		t.Fatalf("saved %d waters, want 0", len(repo.waters))
+	}
+
+	waters, err := uc.Parse(context.Background(), 1, "200ml と 300ml", true)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(waters) != 2 || len(repo.waters) != 2 || waters[0].ID == 0 || waters[1].ID == 0 {
+		t.Fatalf("Parse() = %+v, saved %d waters, want 2", waters, len(repo.waters))
+	}
+}
+
+func TestWaterUseCaseParseBeverages(t *testing.T) {
+	repo := newFakeWaterRepository()
+	uc := newTestWaterUseCase(repo, &model.User{ID: 1, Timezone: "Asia/Tokyo"})
+	uc.beverageRepository = &fakeBeverageRepository{beverages: []*model.Beverage{
+		{ID: 1, Code: "water", Name: "水", HydrationFactor: 1},
+		{ID: 2, Code: "tea", Name: "お茶", HydrationFactor: 1},
+		{ID: 10, UserID: 1, Name: "プロテイン", HydrationFactor: 0.9},
+	}}
+
+	waters, err := uc.Parse(context.Background(), 1, "プロテイン 300ml と お茶 200ml と 100ml", false)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// 飲み物が書かれていない記録はユーザーの飲み物にしない
+	want := []int64{10, 2, 0}
+	if len(waters) != len(want) {
+		t.Fatalf("Parse() returned %d waters, want %d", len(waters), len(want))
+	}
+	for i, beverageId := range want {
+		if waters[i].BeverageID != beverageId {
+			t.Errorf("waters[%d].BeverageID = %d, want %d", i, waters[i].BeverageID, beverageId)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package util
+
+import "net"
+
+// IsPublicIP ループバック、プライベート、リンクローカルなど外部に公開されていないアドレスはfalse
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified())
+}
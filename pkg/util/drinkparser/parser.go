@@ -0,0 +1,423 @@
+// Package drinkparser 「コップ2杯 3時に」「two cups of tea at 3pm」のような自由な文から飲んだ量と時刻を取り出す
+// 外部のサービスを使わず、語彙と規則だけで決定的に解析する
+package drinkparser
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrNoDrink = errors.New("no drink found in text")
+
+// Drink 文から取り出した1回分の記録
+type Drink struct {
+	// Volume ml単位
+	Volume int64
+	// Beverage 飲み物のコード。書かれていない場合は空
+	Beverage string
+	// Container 書かれていた容器の語。書かれていない場合は空
+	Container string
+	// DrankAt Parseに渡したnowと同じタイムゾーンの時刻
+	DrankAt time.Time
+}
+
+type Parser struct {
+	beverages       map[string]string
+	beveragePattern *regexp.Regexp
+}
+
+// NewParser 共通の飲み物の語彙で解析する
+func NewParser() *Parser {
+	return newParser(defaultBeverages)
+}
+
+// WithBeverages 飲み物の語彙を追加したParserを返す。wordsは語からコードへの対応
+func (p *Parser) WithBeverages(words map[string]string) *Parser {
+	beverages := make(map[string]string, len(p.beverages)+len(words))
+	for word, code := range p.beverages {
+		beverages[word] = code
+	}
+	for word, code := range words {
+		if word = normalize(word); word != "" {
+			beverages[word] = code
+		}
+	}
+	return newParser(beverages)
+}
+
+func newParser(beverages map[string]string) *Parser {
+	words := make([]string, 0, len(beverages))
+	for word := range beverages {
+		words = append(words, word)
+	}
+	return &Parser{
+		beverages:       beverages,
+		beveragePattern: regexp.MustCompile(alternation(words, true)),
+	}
+}
+
+var (
+	thousandsPattern = regexp.MustCompile(`(\d),(\d{3})`)
+	separatorPattern = regexp.MustCompile(`[,、。;&+]|\band\b|\bthen\b|と`)
+	halfPattern      = regexp.MustCompile(`\bhalf\s+(?:a|an)\s+`)
+	halfJaPattern    = regexp.MustCompile(`半分の?`)
+	articlePattern   = regexp.MustCompile(`\b(?:a|an)\s+(cups?|glass(?:es)?|mugs?|bottles?|cans?|sips?|tumblers?|hour|minute)\b`)
+	numberPattern    = regexp.MustCompile(`\b(` + strings.Join(mapKeys(numberWords), "|") + `)\b`)
+	kanjiPattern     = regexp.MustCompile(`([一二三四五六七八九十]+)(杯|本|缶|口|時|分)`)
+
+	// 時刻の表現。解析の前に取り除き、数字が量として扱われないようにする
+	relativeJaPattern = regexp.MustCompile(`(\d+)\s*(分|時間)前`)
+	relativeEnPattern = regexp.MustCompile(`(\d+)\s*(minutes?|mins?|hours?|hrs?)\s+ago\b`)
+	clockJaPattern    = regexp.MustCompile(`(午前|午後)?\s*(\d{1,2})\s*時\s*(?:(\d{1,2})\s*分|(半))?\s*(?:頃|ごろ)?に?`)
+	clockEnPattern    = regexp.MustCompile(`(?:\bat\s+|\baround\s+)?\b(\d{1,2})(?::(\d{2}))?\s*(am|pm)\b`)
+	clock24Pattern    = regexp.MustCompile(`(?:\bat\s+|\baround\s+)?\b(\d{1,2}):(\d{2})\b`)
+	clockAtPattern    = regexp.MustCompile(`\b(?:at|around)\s+(\d{1,2})\b`)
+	yesterdayPattern  = regexp.MustCompile(`昨日|きのう|\byesterday\b`)
+	todayPattern      = regexp.MustCompile(`今日|きょう|\btoday\b`)
+	nowPattern        = regexp.MustCompile(`たった今|今さっき|さっき|今|いま|\bjust\s+now\b|\bright\s+now\b|\bnow\b|\bjust\b`)
+
+	volumePattern     = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*` + alternation(termWords(units), false))
+	counterPattern    = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(` + strings.Join(termWords(counters), "|") + `)`)
+	containerPattern  = regexp.MustCompile(`(?:(\d+(?:\.\d+)?)\s*)?` + alternation(termWords(containers), true))
+	multiplierPattern = regexp.MustCompile(`(?:\bx|×|\*)\s*(\d+)\b`)
+	barePattern       = regexp.MustCompile(`\d+(?:\.\d+)?`)
+)
+
+// segment 区切り文字で分けた文の一部から取り出した内容
+type segment struct {
+	hasDrink  bool
+	volume    float64
+	count     float64
+	beverage  string
+	container string
+	at        *time.Time
+}
+
+// Parse textに含まれる記録を順に返す。nowは時刻の解釈の基準で、ユーザーのタイムゾーンで渡す
+// 時刻が書かれていない記録は、文中の他の時刻、なければnowになる
+func (p *Parser) Parse(text string, now time.Time) ([]*Drink, error) {
+	text = normalize(text)
+	text = thousandsPattern.ReplaceAllString(text, "$1$2")
+
+	var segments []*segment
+	var sharedAt *time.Time
+	for _, part := range separatorPattern.Split(text, -1) {
+		s := p.parseSegment(part, now)
+		if s.at != nil && sharedAt == nil {
+			sharedAt = s.at
+		}
+		if s.hasDrink {
+			segments = append(segments, s)
+		}
+	}
+	if len(segments) == 0 {
+		return nil, ErrNoDrink
+	}
+
+	var drinks []*Drink
+	for _, s := range segments {
+		at := now
+		if s.at != nil {
+			at = *s.at
+		} else if sharedAt != nil {
+			at = *sharedAt
+		}
+		drinks = append(drinks, &Drink{
+			Volume:    int64(math.Round(s.volume * s.count)),
+			Beverage:  s.beverage,
+			Container: s.container,
+			DrankAt:   at,
+		})
+	}
+	return drinks, nil
+}
+
+func (p *Parser) parseSegment(text string, now time.Time) *segment {
+	s := &segment{count: 1}
+
+	text = halfPattern.ReplaceAllString(text, "0.5 ")
+	text = articlePattern.ReplaceAllString(text, "1 $1")
+	text = numberPattern.ReplaceAllStringFunc(text, func(word string) string { return numberWords[word] })
+	text = kanjiPattern.ReplaceAllStringFunc(text, func(match string) string {
+		m := kanjiPattern.FindStringSubmatch(match)
+		// 「一口」は容器の語として扱う
+		if m[0] == "一口" {
+			return m[0]
+		}
+		return strconv.Itoa(kanjiNumber(m[1])) + m[2]
+	})
+
+	text = s.parseTime(text, now)
+	// 時刻の「半」を取り除いてから扱う
+	text = replaceFirst(halfJaPattern, text, func(m []string) {
+		s.count = 0.5
+	})
+
+	var explicit, perCounter float64
+	text = replaceFirst(volumePattern, text, func(m []string) {
+		explicit = parseFloat(m[1]) * unitMl(m[2])
+	})
+	text = replaceFirst(counterPattern, text, func(m []string) {
+		s.count *= parseFloat(m[1])
+		perCounter = termMl(counters, m[2])
+	})
+	text = replaceFirst(containerPattern, text, func(m []string) {
+		// 「2 cups」のように容器の前にある数は個数として扱う
+		if m[1] != "" {
+			s.count *= parseFloat(m[1])
+		}
+		s.container = m[2]
+	})
+	text = replaceFirst(multiplierPattern, text, func(m []string) {
+		s.count *= parseFloat(m[1])
+	})
+	text = replaceFirst(p.beveragePattern, text, func(m []string) {
+		s.beverage = p.beverages[m[1]]
+	})
+
+	// 単位のない数はmlとして扱う
+	var bare float64
+	if m := barePattern.FindString(text); m != "" {
+		bare = parseFloat(m)
+	}
+
+	switch {
+	case explicit > 0:
+		s.volume = explicit
+	case bare > 0:
+		s.volume = bare
+	case s.container != "":
+		s.volume = termMl(containers, s.container)
+	case perCounter > 0:
+		s.volume = perCounter
+	default:
+		s.volume = defaultServing
+	}
+	s.hasDrink = explicit > 0 || bare > 0 || perCounter > 0 || s.container != "" || s.beverage != ""
+	return s
+}
+
+// parseTime 時刻の表現を取り除いた文を返す
+func (s *segment) parseTime(text string, now time.Time) string {
+	reference := now
+	yesterday := false
+	text = replaceFirst(yesterdayPattern, text, func(m []string) {
+		yesterday = true
+		// 昨日のうちで最も遅い時刻を基準にする
+		y, mo, d := now.AddDate(0, 0, -1).Date()
+		reference = time.Date(y, mo, d, 23, 59, 59, 0, now.Location())
+	})
+	text = todayPattern.ReplaceAllString(text, " ")
+
+	setAt := func(t time.Time) {
+		if s.at == nil {
+			s.at = &t
+		}
+	}
+
+	text = replaceFirst(relativeJaPattern, text, func(m []string) {
+		setAt(now.Add(-relativeDuration(m[1], m[2])))
+	})
+	text = replaceFirst(relativeEnPattern, text, func(m []string) {
+		setAt(now.Add(-relativeDuration(m[1], m[2])))
+	})
+	text = replaceFirst(clockJaPattern, text, func(m []string) {
+		minute := atoi(m[3])
+		if m[4] != "" {
+			minute = 30
+		}
+		setAt(resolveClock(reference, atoi(m[2]), minute, ampm(m[1])))
+	})
+	text = replaceFirst(clockEnPattern, text, func(m []string) {
+		setAt(resolveClock(reference, atoi(m[1]), atoi(m[2]), m[3]))
+	})
+	text = replaceFirst(clock24Pattern, text, func(m []string) {
+		setAt(resolveClock(reference, atoi(m[1]), atoi(m[2]), ""))
+	})
+	text = replaceFirst(clockAtPattern, text, func(m []string) {
+		setAt(resolveClock(reference, atoi(m[1]), 0, ""))
+	})
+	text = replaceFirst(nowPattern, text, func(m []string) {
+		setAt(reference)
+	})
+
+	if yesterday {
+		setAt(now.AddDate(0, 0, -1))
+	}
+	return text
+}
+
+// resolveClock referenceより後にならない最も近い時刻にする
+// 午前・午後が書かれていない12時以前の時刻は、午前と午後の両方を候補にする
+func resolveClock(reference time.Time, hour, minute int, meridiem string) time.Time {
+	hours := []int{hour}
+	switch meridiem {
+	case "am":
+		hours = []int{hour % 12}
+	case "pm":
+		hours = []int{hour%12 + 12}
+	default:
+		if hour < 12 {
+			hours = append(hours, hour+12)
+		}
+	}
+
+	var best time.Time
+	for _, day := range []int{0, -1} {
+		y, mo, d := reference.AddDate(0, 0, day).Date()
+		for _, h := range hours {
+			candidate := time.Date(y, mo, d, h, minute, 0, 0, reference.Location())
+			if !candidate.After(reference) && candidate.After(best) {
+				best = candidate
+			}
+		}
+	}
+	return best
+}
+
+func relativeDuration(value, unit string) time.Duration {
+	n := time.Duration(atoi(value))
+	switch {
+	case unit == "時間" || strings.HasPrefix(unit, "h"):
+		return n * time.Hour
+	default:
+		return n * time.Minute
+	}
+}
+
+func ampm(word string) string {
+	switch word {
+	case "午前":
+		return "am"
+	case "午後":
+		return "pm"
+	}
+	return ""
+}
+
+// kanjiNumber 「二十五」のような99以下の漢数字を数にする
+func kanjiNumber(s string) int {
+	n, digit := 0, 0
+	for _, r := range s {
+		if r == '十' {
+			if digit == 0 {
+				digit = 1
+			}
+			n += digit * 10
+			digit = 0
+			continue
+		}
+		digit = kanjiDigits[r]
+	}
+	return n + digit
+}
+
+// normalize 全角の英数字と記号を半角にし、小文字にそろえる
+func normalize(text string) string {
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - '！' + '!'
+		}
+		return r
+	}, text)
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// replaceFirst 最初に一致した部分をfに渡し、空白に置き換える
+func replaceFirst(pattern *regexp.Regexp, text string, f func(m []string)) string {
+	loc := pattern.FindStringSubmatchIndex(text)
+	if loc == nil {
+		return text
+	}
+	m := make([]string, len(loc)/2)
+	for i := range m {
+		if loc[2*i] >= 0 {
+			m[i] = text[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	f(m)
+	return text[:loc[0]] + " " + text[loc[1]:]
+}
+
+// alternation 長い語から順に照合するグループを作る。英字の語は単語の途中で終わらないようにし、
+// leadingBoundaryの場合は単語の途中からも始まらないようにする
+func alternation(words []string, leadingBoundary bool) string {
+	sorted := append([]string(nil), words...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if utf8.RuneCountInString(sorted[i]) != utf8.RuneCountInString(sorted[j]) {
+			return utf8.RuneCountInString(sorted[i]) > utf8.RuneCountInString(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	var patterns []string
+	for _, word := range sorted {
+		pattern := regexp.QuoteMeta(word)
+		if isASCII(word) {
+			pattern += `\b`
+			if leadingBoundary {
+				pattern = `\b` + pattern
+			}
+		}
+		patterns = append(patterns, pattern)
+	}
+	return `(` + strings.Join(patterns, "|") + `)`
+}
+
+func termWords(terms []term) []string {
+	var words []string
+	for _, t := range terms {
+		words = append(words, t.word)
+	}
+	return words
+}
+
+func termMl(terms []term, word string) float64 {
+	for _, t := range terms {
+		if t.word == word {
+			return t.ml
+		}
+	}
+	return 0
+}
+
+func unitMl(word string) float64 {
+	return termMl(units, word)
+}
+
+func mapKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,119 @@
+package drinkparser
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 1, 10, 30, 0, 0, tokyo)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 6, day, hour, minute, 0, 0, tokyo)
+	}
+	yesterday := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 31, hour, minute, 0, 0, tokyo)
+	}
+
+	tests := []struct {
+		text string
+		want []*Drink
+	}{
+		// 日本語の個数と容器
+		{text: "コップ2杯 3時に", want: []*Drink{{Volume: 400, Container: "コップ", DrankAt: at(1, 3, 0)}}},
+		{text: "コップ三杯", want: []*Drink{{Volume: 600, Container: "コップ", DrankAt: now}}},
+		{text: "ビール2缶", want: []*Drink{{Volume: 700, Beverage: "alcohol", DrankAt: now}}},
+		{text: "ペットボトル1本", want: []*Drink{{Volume: 500, Container: "ペットボトル", DrankAt: now}}},
+		{text: "お茶 200", want: []*Drink{{Volume: 200, Beverage: "tea", DrankAt: now}}},
+		{text: "300", want: []*Drink{{Volume: 300, DrankAt: now}}},
+		// 英語の数詞と容器
+		{text: "two cups of tea at 3pm", want: []*Drink{{Volume: 400, Beverage: "tea", Container: "cups", DrankAt: yesterday(15, 0)}}},
+		{text: "half a cup of milk", want: []*Drink{{Volume: 100, Beverage: "milk", Container: "cup", DrankAt: now}}},
+		{text: "a glass of water", want: []*Drink{{Volume: 200, Beverage: "water", Container: "glass", DrankAt: now}}},
+		// 単位
+		{text: "500ml bottle just now", want: []*Drink{{Volume: 500, Container: "bottle", DrankAt: now}}},
+		{text: "1.5l", want: []*Drink{{Volume: 1500, DrankAt: now}}},
+		{text: "12oz coffee", want: []*Drink{{Volume: 355, Beverage: "coffee", DrankAt: now}}},
+		{text: "2,000ml", want: []*Drink{{Volume: 2000, DrankAt: now}}},
+		{text: "５００ｍｌ", want: []*Drink{{Volume: 500, DrankAt: now}}},
+		{text: "2デシリットル", want: []*Drink{{Volume: 200, DrankAt: now}}},
+		// 相対的な時刻
+		{text: "30分前に水500ml", want: []*Drink{{Volume: 500, Beverage: "water", DrankAt: at(1, 10, 0)}}},
+		{text: "2時間前 コーヒー", want: []*Drink{{Volume: 200, Beverage: "coffee", DrankAt: at(1, 8, 30)}}},
+		{text: "coffee an hour ago", want: []*Drink{{Volume: 200, Beverage: "coffee", DrankAt: at(1, 9, 30)}}},
+		{text: "昨日 ビール", want: []*Drink{{Volume: 200, Beverage: "alcohol", DrankAt: yesterday(10, 30)}}},
+		// 絶対的な時刻。nowより後になる時刻は前日として扱う
+		{text: "午後2時半にコーヒー", want: []*Drink{{Volume: 200, Beverage: "coffee", DrankAt: yesterday(14, 30)}}},
+		{text: "午前9時15分 200ml", want: []*Drink{{Volume: 200, DrankAt: at(1, 9, 15)}}},
+		{text: "500ml at 14:45", want: []*Drink{{Volume: 500, DrankAt: yesterday(14, 45)}}},
+		{text: "water at 9", want: []*Drink{{Volume: 200, Beverage: "water", DrankAt: at(1, 9, 0)}}},
+		{text: "昨日の8時に300ml", want: []*Drink{{Volume: 300, DrankAt: yesterday(20, 0)}}},
+		// 複数の記録。時刻が1つだけの場合はすべてに使う
+		{text: "水500mlとお茶200ml 8時に", want: []*Drink{
+			{Volume: 500, Beverage: "water", DrankAt: at(1, 8, 0)},
+			{Volume: 200, Beverage: "tea", DrankAt: at(1, 8, 0)},
+		}},
+		{text: "a cup of coffee at 7am and 500ml water at 9am", want: []*Drink{
+			{Volume: 200, Beverage: "coffee", Container: "cup", DrankAt: at(1, 7, 0)},
+			{Volume: 500, Beverage: "water", DrankAt: at(1, 9, 0)},
+		}},
+	}
+
+	parser := NewParser()
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := parser.Parse(tt.text, now)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Parse() returned %d drinks, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				if got[i].Volume != want.Volume || got[i].Beverage != want.Beverage || got[i].Container != want.Container {
+					t.Errorf("drink[%d] = %+v, want %+v", i, got[i], want)
+				}
+				if !got[i].DrankAt.Equal(want.DrankAt) {
+					t.Errorf("drink[%d].DrankAt = %s, want %s", i, got[i].DrankAt, want.DrankAt)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNoDrink(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC)
+	for _, text := range []string{"", "こんにちは", "hello", "3時に", "at 9"} {
+		t.Run(text, func(t *testing.T) {
+			if _, err := NewParser().Parse(text, now); !errors.Is(err, ErrNoDrink) {
+				t.Fatalf("Parse() error = %v, want ErrNoDrink", err)
+			}
+		})
+	}
+}
+
+func TestParseWithBeverages(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC)
+	parser := NewParser().WithBeverages(map[string]string{"プロテイン": "", "ＢＣＡＡ": "bcaa"})
+
+	got, err := parser.Parse("bcaa 500ml", now)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Beverage != "bcaa" || got[0].Volume != 500 {
+		t.Fatalf("Parse() = %+v, want 500ml of bcaa", got)
+	}
+
+	// 共通の語彙も引き続き使える
+	got, err = parser.Parse("お茶", now)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Beverage != "tea" {
+		t.Fatalf("Parse() = %+v, want tea", got)
+	}
+}
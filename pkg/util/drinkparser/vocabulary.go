@@ -0,0 +1,133 @@
+package drinkparser
+
+// defaultServing 量も容器も書かれていない場合の1杯あたりの量(ml)
+const defaultServing = 200
+
+type term struct {
+	word string
+	// ml 単位の場合は1単位あたりの量、容器の場合は1つあたりの量
+	ml float64
+}
+
+// units 量の単位。長い語から順に照合する
+var units = []term{
+	{"ミリリットル", 1},
+	{"millilitres", 1},
+	{"milliliters", 1},
+	{"millilitre", 1},
+	{"milliliter", 1},
+	{"デシリットル", 100},
+	{"リットル", 1000},
+	{"ounces", 29.5735},
+	{"litres", 1000},
+	{"liters", 1000},
+	{"ounce", 29.5735},
+	{"litre", 1000},
+	{"liter", 1000},
+	{"ミリ", 1},
+	{"ml", 1},
+	{"cc", 1},
+	{"dl", 100},
+	{"oz", 29.5735},
+	{"l", 1000},
+}
+
+// containers 容器と1つあたりの量
+var containers = []term{
+	{"ペットボトル", 500},
+	{"マグカップ", 250},
+	{"tumblers", 500},
+	{"ジョッキ", 500},
+	{"glasses", 200},
+	{"bottles", 500},
+	{"tumbler", 500},
+	{"コップ", 200},
+	{"グラス", 200},
+	{"カップ", 200},
+	{"湯呑み", 150},
+	{"湯のみ", 150},
+	{"ボトル", 500},
+	{"bottle", 500},
+	{"glass", 200},
+	{"cups", 200},
+	{"mugs", 250},
+	{"cans", 350},
+	{"sips", 30},
+	{"水筒", 500},
+	{"マグ", 250},
+	{"cup", 200},
+	{"mug", 250},
+	{"can", 350},
+	{"sip", 30},
+	{"一口", 30},
+}
+
+// counters 日本語の助数詞と、容器が書かれていない場合の1つあたりの量
+var counters = []term{
+	{"杯", 200},
+	{"本", 500},
+	{"缶", 350},
+	{"口", 30},
+}
+
+// defaultBeverages 飲み物を表す語と、beveragesテーブルの共通の飲み物のコード
+var defaultBeverages = map[string]string{
+	"水":            "water",
+	"お水":           "water",
+	"ミネラルウォーター":    "water",
+	"water":        "water",
+	"お茶":           "tea",
+	"茶":            "tea",
+	"緑茶":           "tea",
+	"麦茶":           "tea",
+	"紅茶":           "tea",
+	"ほうじ茶":         "tea",
+	"烏龍茶":          "tea",
+	"ウーロン茶":        "tea",
+	"tea":          "tea",
+	"green tea":    "tea",
+	"コーヒー":         "coffee",
+	"珈琲":           "coffee",
+	"coffee":       "coffee",
+	"牛乳":           "milk",
+	"ミルク":          "milk",
+	"milk":         "milk",
+	"ジュース":         "juice",
+	"juice":        "juice",
+	"スポーツドリンク":     "sports_drink",
+	"ポカリ":          "sports_drink",
+	"ポカリスエット":      "sports_drink",
+	"アクエリアス":       "sports_drink",
+	"sports drink": "sports_drink",
+	"お酒":           "alcohol",
+	"酒":            "alcohol",
+	"ビール":          "alcohol",
+	"ワイン":          "alcohol",
+	"beer":         "alcohol",
+	"wine":         "alcohol",
+	"alcohol":      "alcohol",
+}
+
+// numberWords 英語の数詞
+var numberWords = map[string]string{
+	"one":    "1",
+	"two":    "2",
+	"three":  "3",
+	"four":   "4",
+	"five":   "5",
+	"six":    "6",
+	"seven":  "7",
+	"eight":  "8",
+	"nine":   "9",
+	"ten":    "10",
+	"eleven": "11",
+	"twelve": "12",
+	"twenty": "20",
+	"thirty": "30",
+	"forty":  "40",
+	"fifty":  "50",
+}
+
+var kanjiDigits = map[rune]int{
+	'一': 1, '二': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
@@ -17,3 +17,39 @@ type InternalServerError struct {
 func (e *InternalServerError) Error() string {
 	return "Internal Server Error"
 }
+
+// UnauthorizedError HTTP Status Code: 401
+type UnauthorizedError struct {
+	Err error
+}
+
+func (e *UnauthorizedError) Error() string {
+	return "Unauthorized Error"
+}
+
+// ForbiddenError HTTP Status Code: 403
+type ForbiddenError struct {
+	Err error
+}
+
+func (e *ForbiddenError) Error() string {
+	return "Forbidden Error"
+}
+
+// NotFoundError HTTP Status Code: 404
+type NotFoundError struct {
+	Err error
+}
+
+func (e *NotFoundError) Error() string {
+	return "Not Found Error"
+}
+
+// TooManyRequestsError HTTP Status Code: 429
+type TooManyRequestsError struct {
+	Err error
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return "Too Many Requests Error"
+}
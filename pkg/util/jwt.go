@@ -9,9 +9,14 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
+// AccessTokenTTL アクセストークンの有効期間。失効はリフレッシュトークンで管理するため短くする
+const AccessTokenTTL = 15 * time.Minute
+
 type MyJWTClaims struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
+	// SessionID トークンを発行したセッション(リフレッシュトークンの系列)
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -19,52 +24,70 @@ func GetJWTSecret() []byte {
 	return []byte(os.Getenv("JWT_SECRET_KEY"))
 }
 
-func GenerateSignedString(userId int64, username string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, MyJWTClaims{
-		ID:       strconv.Itoa(int(userId)),
-		Username: username,
+// GenerateSignedString 鍵が読み込まれている場合はactiveの鍵で署名し、ヘッダーにkidを付ける
+func GenerateSignedString(userId int64, username, sessionId string) (string, error) {
+	claims := MyJWTClaims{
+		ID:        strconv.Itoa(int(userId)),
+		Username:  username,
+		SessionID: sessionId,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    strconv.Itoa(int(userId)),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 		},
-	})
+	}
+
+	if keyRing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(GetJWTSecret())
+	}
 
-	return token.SignedString(GetJWTSecret())
+	active := keyRing.Active()
+	token := jwt.NewWithClaims(active.Method, claims)
+	token.Header["kid"] = active.Kid
+	return token.SignedString(active.Private)
 }
 
-func ValidateToken(signedToken string) (err error) {
-	token, err := jwt.ParseWithClaims(
-		signedToken,
-		&MyJWTClaims{},
-		func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return GetJWTSecret(), nil
-		},
-	)
+// verificationKey トークンのkidに対応する鍵を選ぶ。鍵が読み込まれている場合はHS256を受け付けない
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if keyRing == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return GetJWTSecret(), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := keyRing.Key(kid)
+	if !ok {
+		return nil, errors.New("unknown key id")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public, nil
+}
+
+// ValidateToken 署名と有効期限を検証し、トークンに含まれるクレームを返す
+func ValidateToken(signedToken string) (*MyJWTClaims, error) {
+	claims := &MyJWTClaims{}
+	token, err := jwt.ParseWithClaims(signedToken, claims, verificationKey)
 
 	if err != nil {
-		v, _ := err.(*jwt.ValidationError)
-		switch v.Errors {
-		case jwt.ValidationErrorSignatureInvalid:
+		var v *jwt.ValidationError
+		switch {
+		case errors.As(err, &v) && v.Errors == jwt.ValidationErrorSignatureInvalid:
 			// token invalid
-			err = errors.New("signature validation failed")
-			return
-		case jwt.ValidationErrorExpired:
+			return nil, errors.New("signature validation failed")
+		case errors.As(err, &v) && v.Errors == jwt.ValidationErrorExpired:
 			// token expired
-			err = errors.New("token is expired")
-			return
+			return nil, errors.New("token is expired")
 		default:
-			err = errors.New("token is invalid")
-			return
+			return nil, errors.New("token is invalid")
 		}
 	}
 
 	if !token.Valid {
-		err = errors.New("unauthorized")
-		return
+		return nil, errors.New("unauthorized")
 	}
 
-	return
+	return claims, nil
 }
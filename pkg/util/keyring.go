@@ -0,0 +1,193 @@
+package util
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// minRSAKeyBits 受け付けるRSA鍵の最小の長さ
+const minRSAKeyBits = 2048
+
+// SigningKey kidで識別する署名鍵。検証にしか使わない鍵はPrivateがnil
+type SigningKey struct {
+	Kid     string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeyRing 署名に使う鍵(active)と、発行済みのトークンを検証するために残している鍵(retiring)
+type KeyRing struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// JSONWebKey RFC 7517の公開鍵。RSAとEd25519の項目だけを持つ
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// keyRing InitKeyRingで読み込んだ鍵。nilの場合はJWT_SECRET_KEYによるHS256で署名する
+var keyRing *KeyRing
+
+// InitKeyRing JWT_KEY_DIRの鍵を読み込み、JWT_ACTIVE_KIDの鍵で署名するようにする
+// JWT_KEY_DIRが設定されていない場合は何もしない
+func InitKeyRing() error {
+	dir := os.Getenv("JWT_KEY_DIR")
+	if dir == "" {
+		return nil
+	}
+
+	ring, err := LoadKeyRing(dir, os.Getenv("JWT_ACTIVE_KID"))
+	if err != nil {
+		return err
+	}
+	keyRing = ring
+	return nil
+}
+
+// LoadKeyRing dirにある「<kid>.pem」をすべて読み込む。activeKid以外の鍵は検証にだけ使う
+// 鍵はPKCS#8またはPKCS#1の秘密鍵か、検証専用のPKIXの公開鍵
+func LoadKeyRing(dir, activeKid string) (*KeyRing, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ring := &KeyRing{keys: map[string]*SigningKey{}}
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := loadSigningKey(path, kid)
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", path, err)
+		}
+		ring.keys[kid] = key
+	}
+
+	active, ok := ring.keys[activeKid]
+	if !ok {
+		return nil, fmt.Errorf("active key %q is not found in %s", activeKid, dir)
+	}
+	if active.Private == nil {
+		return nil, fmt.Errorf("active key %q has no private key", activeKid)
+	}
+	ring.active = active
+	return ring, nil
+}
+
+func loadSigningKey(path, kid string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no pem block")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported pem type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{Kid: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Private, key.Public = k, &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Private, key.Public = k, k.Public()
+	case *rsa.PublicKey, ed25519.PublicKey:
+		key.Public = k
+	default:
+		return nil, errors.New("only rsa and ed25519 keys are supported")
+	}
+
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("rsa key must be at least %d bits", minRSAKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	}
+	return key, nil
+}
+
+func (r *KeyRing) Active() *SigningKey {
+	return r.active
+}
+
+func (r *KeyRing) Key(kid string) (*SigningKey, bool) {
+	key, ok := r.keys[kid]
+	return key, ok
+}
+
+// JWKS 検証に使えるすべての鍵の公開鍵。kidの順に並べる
+func (r *KeyRing) JWKS() *JSONWebKeySet {
+	set := &JSONWebKeySet{Keys: []JSONWebKey{}}
+	kids := make([]string, 0, len(r.keys))
+	for kid := range r.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	for _, kid := range kids {
+		key := r.keys[kid]
+		jwk := JSONWebKey{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// JWKS 公開している鍵。HS256で署名している場合は空
+func JWKS() *JSONWebKeySet {
+	if keyRing == nil {
+		return &JSONWebKeySet{Keys: []JSONWebKey{}}
+	}
+	return keyRing.JWKS()
+}
@@ -0,0 +1,290 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// writePEM dirに「<kid>.pem」としてkeyを書き出す
+func writePEM(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeRSAKey(t *testing.T, dir, kid string, bits int) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	return key
+}
+
+func writeEd25519Key(t *testing.T, dir, kid string) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "PRIVATE KEY", der)
+	return key
+}
+
+// useKeyRing テストの間だけringで署名する
+func useKeyRing(t *testing.T, ring *KeyRing) {
+	t.Helper()
+	previous := keyRing
+	keyRing = ring
+	t.Cleanup(func() { keyRing = previous })
+}
+
+func TestLoadKeyRing(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := writeRSAKey(t, dir, "2024-01", minRSAKeyBits)
+	edKey := writeEd25519Key(t, dir, "2024-06")
+	// 検証だけに使う公開鍵
+	_, retiring, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKIXPublicKey(retiring.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "2023-12", "PUBLIC KEY", der)
+
+	ring, err := LoadKeyRing(dir, "2024-06")
+	if err != nil {
+		t.Fatalf("LoadKeyRing() error = %v", err)
+	}
+	if active := ring.Active(); active.Kid != "2024-06" || active.Method != jwt.SigningMethodEdDSA {
+		t.Errorf("Active() = %s %s, want 2024-06 EdDSA", active.Kid, active.Method.Alg())
+	}
+	if key, ok := ring.Key("2024-01"); !ok || key.Method != jwt.SigningMethodRS256 {
+		t.Errorf("Key(2024-01) = %v, %v, want RS256", key, ok)
+	}
+	if key, ok := ring.Key("2023-12"); !ok || key.Private != nil {
+		t.Errorf("Key(2023-12) = %v, %v, want a verification only key", key, ok)
+	}
+
+	jwks := ring.JWKS()
+	if len(jwks.Keys) != 3 {
+		t.Fatalf("JWKS() has %d keys, want 3", len(jwks.Keys))
+	}
+	if kids := []string{jwks.Keys[0].Kid, jwks.Keys[1].Kid, jwks.Keys[2].Kid}; kids[0] != "2023-12" || kids[1] != "2024-01" || kids[2] != "2024-06" {
+		t.Errorf("JWKS() kids = %v, want sorted by kid", kids)
+	}
+
+	rsaJWK := jwks.Keys[1]
+	if rsaJWK.Kty != "RSA" || rsaJWK.Alg != "RS256" || rsaJWK.Use != "sig" {
+		t.Errorf("rsa jwk = %+v", rsaJWK)
+	}
+	if n, _ := base64.RawURLEncoding.DecodeString(rsaJWK.N); new(big.Int).SetBytes(n).Cmp(rsaKey.N) != 0 {
+		t.Errorf("rsa jwk n does not match the key")
+	}
+	if e, _ := base64.RawURLEncoding.DecodeString(rsaJWK.E); new(big.Int).SetBytes(e).Int64() != int64(rsaKey.E) {
+		t.Errorf("rsa jwk e does not match the key")
+	}
+
+	edJWK := jwks.Keys[2]
+	if edJWK.Kty != "OKP" || edJWK.Crv != "Ed25519" || edJWK.Alg != "EdDSA" {
+		t.Errorf("ed25519 jwk = %+v", edJWK)
+	}
+	if x, _ := base64.RawURLEncoding.DecodeString(edJWK.X); string(x) != string(edKey.Public().(ed25519.PublicKey)) {
+		t.Errorf("ed25519 jwk x does not match the key")
+	}
+	// Ed25519の鍵にRSAの項目は含めない
+	if edJWK.N != "" || edJWK.E != "" {
+		t.Errorf("ed25519 jwk has rsa members: %+v", edJWK)
+	}
+}
+
+func TestLoadKeyRingErrors(t *testing.T) {
+	t.Run("active key is missing", func(t *testing.T) {
+		dir := t.TempDir()
+		writeEd25519Key(t, dir, "a")
+		if _, err := LoadKeyRing(dir, "b"); err == nil {
+			t.Error("LoadKeyRing() error = nil, want active key is not found")
+		}
+	})
+
+	t.Run("active key has no private key", func(t *testing.T) {
+		dir := t.TempDir()
+		pub, _, _ := ed25519.GenerateKey(rand.Reader)
+		der, _ := x509.MarshalPKIXPublicKey(pub)
+		writePEM(t, dir, "a", "PUBLIC KEY", der)
+		if _, err := LoadKeyRing(dir, "a"); err == nil {
+			t.Error("LoadKeyRing() error = nil, want active key has no private key")
+		}
+	})
+
+	t.Run("rsa key is too short", func(t *testing.T) {
+		dir := t.TempDir()
+		writeRSAKey(t, dir, "a", 1024)
+		if _, err := LoadKeyRing(dir, "a"); err == nil {
+			t.Error("LoadKeyRing() error = nil, want rsa key must be at least 2048 bits")
+		}
+	})
+
+	t.Run("not a pem file", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "a.pem"), []byte("secret"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadKeyRing(dir, "a"); err == nil {
+			t.Error("LoadKeyRing() error = nil, want no pem block")
+		}
+	})
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "old", minRSAKeyBits)
+	writeEd25519Key(t, dir, "new")
+
+	oldRing, err := LoadKeyRing(dir, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeyRing(t, oldRing)
+	issuedBefore, err := GenerateSignedString(1, "mikai", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRing, err := LoadKeyRing(dir, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeyRing(t, newRing)
+	issuedAfter, err := GenerateSignedString(1, "mikai", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(issuedAfter, &MyJWTClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "new" || parsed.Method != jwt.SigningMethodEdDSA {
+		t.Errorf("new token header = %v, want kid new signed with EdDSA", parsed.Header)
+	}
+
+	// 切り替える前に発行したトークンも、鍵が残っている間は検証できる
+	for name, token := range map[string]string{"before rotation": issuedBefore, "after rotation": issuedAfter} {
+		claims, err := ValidateToken(token)
+		if err != nil {
+			t.Errorf("ValidateToken(%s) error = %v", name, err)
+			continue
+		}
+		if claims.ID != "1" || claims.Username != "mikai" || claims.SessionID != "session" {
+			t.Errorf("ValidateToken(%s) claims = %+v", name, claims)
+		}
+	}
+}
+
+func TestValidateTokenRejectsUnexpectedKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := writeRSAKey(t, dir, "rsa", minRSAKeyBits)
+	ring, err := LoadKeyRing(dir, "rsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	useKeyRing(t, ring)
+	t.Setenv("JWT_SECRET_KEY", "secret")
+
+	claims := MyJWTClaims{
+		ID:               "1",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+	}
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	der := x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		// 鍵が読み込まれている場合はJWT_SECRET_KEYで署名したトークンを受け付けない
+		{name: "hs256 with the secret", token: sign(jwt.SigningMethodHS256, "", []byte("secret"))},
+		// 公開鍵をHMACの鍵として使った署名を受け付けない
+		{name: "hs256 with the public key", token: sign(jwt.SigningMethodHS256, "rsa", der)},
+		{name: "unknown kid", token: sign(jwt.SigningMethodEdDSA, "other", otherKey)},
+		{name: "algorithm does not match the kid", token: sign(jwt.SigningMethodEdDSA, "rsa", otherKey)},
+		{name: "expired", token: func() string {
+			expired := claims
+			expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, expired)
+			token.Header["kid"] = "rsa"
+			signed, err := token.SignedString(rsaKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signed
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ValidateToken(tt.token); err == nil {
+				t.Error("ValidateToken() error = nil, want rejected")
+			}
+		})
+	}
+
+	if _, err := ValidateToken(sign(jwt.SigningMethodRS256, "rsa", rsaKey)); err != nil {
+		t.Errorf("ValidateToken() with the rsa key error = %v", err)
+	}
+}
+
+func TestHS256WithoutKeyRing(t *testing.T) {
+	useKeyRing(t, nil)
+	t.Setenv("JWT_SECRET_KEY", "secret")
+
+	signed, err := GenerateSignedString(1, "mikai", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(signed); err != nil {
+		t.Errorf("ValidateToken() error = %v", err)
+	}
+	if keys := JWKS().Keys; len(keys) != 0 {
+		t.Errorf("JWKS() = %v, want no keys", keys)
+	}
+
+	// 他の署名方式のトークンは受け付けない
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	other, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, MyJWTClaims{ID: "1"}).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(other); err == nil {
+		t.Error("ValidateToken() with EdDSA error = nil, want unexpected signing method")
+	}
+}
@@ -0,0 +1,89 @@
+package mailtemplate
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Locale メールの言語
+const (
+	LocaleJa = "ja"
+	LocaleEn = "en"
+)
+
+// テンプレートの名前。templates/<名前>.<言語>.tmpl に件名と本文を定義する
+const (
+	PasswordReset     = "password_reset"
+	EmailVerification = "email_verification"
+)
+
+// PasswordResetData パスワード再設定のメールに埋め込む値
+type PasswordResetData struct {
+	Username         string
+	URL              string
+	ExpiresInMinutes int64
+}
+
+// EmailVerificationData メールアドレスの確認のメールに埋め込む値
+type EmailVerificationData struct {
+	Username       string
+	URL            string
+	ExpiresInHours int64
+}
+
+//go:embed templates/*.tmpl
+var files embed.FS
+
+// templates ファイルごとに件名と本文を定義するため、ファイル単位で読み込む
+var templates = mustParse()
+
+func mustParse() map[string]*template.Template {
+	entries, err := files.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+
+	parsed := map[string]*template.Template{}
+	for _, entry := range entries {
+		parsed[entry.Name()] = template.Must(template.ParseFS(files, "templates/"+entry.Name()))
+	}
+	return parsed
+}
+
+// ResolveLocale Accept-Languageなどの言語指定から、対応している言語を選ぶ
+// 英語が指定されていなければ日本語にする
+func ResolveLocale(language string) string {
+	for _, tag := range strings.Split(language, ",") {
+		tag = strings.ToLower(strings.TrimSpace(strings.SplitN(tag, ";", 2)[0]))
+		switch {
+		case tag == LocaleJa || strings.HasPrefix(tag, LocaleJa+"-"):
+			return LocaleJa
+		case tag == LocaleEn || strings.HasPrefix(tag, LocaleEn+"-"):
+			return LocaleEn
+		}
+	}
+	return LocaleJa
+}
+
+// Render テンプレートから件名と本文を作る
+func Render(name, locale string, data interface{}) (subject, body string, err error) {
+	t, ok := templates[name+"."+ResolveLocale(locale)+".tmpl"]
+	if !ok {
+		return "", "", fmt.Errorf("mail template %s is not found", name)
+	}
+
+	var b bytes.Buffer
+	if err := t.ExecuteTemplate(&b, "subject", data); err != nil {
+		return "", "", err
+	}
+	subject = strings.TrimSpace(b.String())
+
+	b.Reset()
+	if err := t.ExecuteTemplate(&b, "body", data); err != nil {
+		return "", "", err
+	}
+	return subject, strings.TrimSpace(b.String()) + "\n", nil
+}
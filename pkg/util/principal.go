@@ -0,0 +1,67 @@
+package util
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Principal 認証済みのリクエストを送ったユーザー
+type Principal struct {
+	UserID   int64
+	Username string
+	// SessionID アクセストークンを発行したセッション
+	SessionID string
+	// TokenID パーソナルアクセストークンで認証した場合に設定する
+	TokenID int64
+	// Scopes パーソナルアクセストークンに許可された操作。ログインしたユーザーはすべて許可される
+	Scopes []string
+}
+
+// IsToken パーソナルアクセストークンで認証したかどうか
+func (p *Principal) IsToken() bool {
+	return p.TokenID != 0
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	if !p.IsToken() {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type principalContextKey struct{}
+
+// SetPrincipal リクエストのcontext.Contextにprincipalを設定する。usecaseにもc.Request.Context()で渡る
+func SetPrincipal(c *gin.Context, principal *Principal) {
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), principalContextKey{}, principal))
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// FindPrincipal Middlewareが設定したprincipalを返す
+// 認証されていないリクエストの場合はUnauthorizedErrorを返す
+func FindPrincipal(c *gin.Context) (*Principal, error) {
+	principal, ok := PrincipalFromContext(c.Request.Context())
+	if !ok {
+		return nil, &UnauthorizedError{Err: errors.New("not authenticated")}
+	}
+	return principal, nil
+}
+
+func FindUserId(c *gin.Context) (int64, error) {
+	principal, err := FindPrincipal(c)
+	if err != nil {
+		return 0, err
+	}
+	return principal.UserID, nil
+}
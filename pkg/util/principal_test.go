@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/mikaijun/aquagent/pkg/domain/model"
+)
+
+func TestPrincipalHasScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *Principal
+		scope     string
+		want      bool
+	}{
+		// ログインしたユーザーはすべての操作ができる
+		{name: "session", principal: &Principal{UserID: 1}, scope: model.ScopeWatersWrite, want: true},
+		{name: "token with the scope", principal: &Principal{UserID: 1, TokenID: 1, Scopes: []string{model.ScopeWatersRead, model.ScopeStatsRead}}, scope: model.ScopeStatsRead, want: true},
+		{name: "token without the scope", principal: &Principal{UserID: 1, TokenID: 1, Scopes: []string{model.ScopeWatersRead}}, scope: model.ScopeWatersWrite, want: false},
+		{name: "token without scopes", principal: &Principal{UserID: 1, TokenID: 1}, scope: model.ScopeWatersRead, want: false},
+		// スコープは完全に一致する場合だけ許可する
+		{name: "prefix does not match", principal: &Principal{UserID: 1, TokenID: 1, Scopes: []string{"waters"}}, scope: model.ScopeWatersRead, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.HasScope(tt.scope); got != tt.want {
+				t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}